@@ -0,0 +1,178 @@
+package assistant
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"chat-api/handlers"
+	"chat-api/internal/testutil"
+	"chat-api/models"
+	"chat-api/repository"
+	"chat-api/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func collect(t *testing.T, responder service.Responder, req service.AssistantRequest) []string {
+	t.Helper()
+	var chunks []string
+	err := responder.Respond(context.Background(), req, func(chunk string) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	require.NoError(t, err)
+	return chunks
+}
+
+func TestFake(t *testing.T) {
+	req := service.AssistantRequest{
+		Name:    "assistant",
+		Title:   "ops",
+		History: make([]models.Message, 3),
+		Message: models.Message{Text: "@assistant status?", AuthorName: "alice"},
+	}
+
+	first := collect(t, Fake{}, req)
+	assert.Equal(t, first, collect(t, Fake{}, req))
+	assert.Equal(t, `Hi alice! I have read 3 messages in "ops". You said: @assistant status?`, strings.Join(first, ""))
+	assert.Greater(t, len(first), 1)
+}
+
+func TestOpenAI(t *testing.T) {
+	var got completionRequest
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"All \"}}]}\n\n")
+		fmt.Fprint(w, ": keep-alive\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"green\"}}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer provider.Close()
+
+	req := service.AssistantRequest{
+		Name:  "assistant",
+		Title: "ops",
+		History: []models.Message{
+			{Text: "deploy done", AuthorName: "bob"},
+			{Text: "nice", AuthorName: "assistant"},
+			{Text: "@assistant status?"},
+		},
+	}
+	chunks := collect(t, NewOpenAI(provider.URL+"/v1/", "sk-test", "gpt-test"), req)
+
+	assert.Equal(t, []string{"All ", "green"}, chunks)
+	assert.Equal(t, "gpt-test", got.Model)
+	assert.True(t, got.Stream)
+	require.Len(t, got.Messages, 4)
+	assert.Equal(t, "system", got.Messages[0].Role)
+	assert.Equal(t, chatMessage{Role: "user", Content: "bob: deploy done"}, got.Messages[1])
+	assert.Equal(t, chatMessage{Role: "assistant", Content: "nice"}, got.Messages[2])
+	assert.Equal(t, chatMessage{Role: "user", Content: "anonymous: @assistant status?"}, got.Messages[3])
+}
+
+func TestOpenAI_Error(t *testing.T) {
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+	}))
+	defer provider.Close()
+
+	err := NewOpenAI(provider.URL, "", "gpt-test").Respond(context.Background(), service.AssistantRequest{}, func(string) error { return nil })
+	assert.ErrorContains(t, err, "429")
+	assert.ErrorContains(t, err, "quota exceeded")
+}
+
+// streams - учёт потоков вместо server.Server
+type streams struct {
+	draining chan struct{}
+	active   sync.WaitGroup
+}
+
+func (s *streams) Draining() <-chan struct{} { return s.draining }
+
+func (s *streams) TrackStream() func() {
+	s.active.Add(1)
+	return s.active.Done
+}
+
+func TestHandler(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository(nil)
+	chatAssistant := service.NewAssistant(repo, Fake{}, service.AssistantConfig{
+		Name: "assistant", HistoryLimit: 10, Timeout: time.Second, Budget: 5, BudgetWindow: time.Hour,
+	}, testutil.DiscardLogger{})
+	chats := service.NewChatService(repo, service.WithAssistant(chatAssistant))
+
+	chat, err := chats.CreateChat(ctx, "ops")
+	require.NoError(t, err)
+
+	streams := &streams{draining: make(chan struct{})}
+	router := handlers.NewRouter()
+	router.Mount("", NewHandler(chatAssistant, streams))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	put := func(path, body string) int {
+		req, err := http.NewRequest(http.MethodPut, server.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusBadRequest, put(fmt.Sprintf("/chats/%d/assistant", chat.ID), `{}`))
+	assert.Equal(t, http.StatusNotFound, put("/chats/999/assistant", `{"enabled":true}`))
+	assert.Equal(t, http.StatusOK, put(fmt.Sprintf("/chats/%d/assistant", chat.ID), `{"enabled":true}`))
+
+	resp, err := http.Get(server.URL + "/chats/999/assistant/stream")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = http.Get(fmt.Sprintf("%s/chats/%d/assistant/stream", server.URL, chat.ID))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	_, err = chats.SendMessage(service.WithAuthor(ctx, "alice"), chat.ID, "@assistant ping")
+	require.NoError(t, err)
+
+	var text strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var event service.AssistantEvent
+		require.NoError(t, json.Unmarshal([]byte(data), &event))
+		if event.Message != nil {
+			assert.Equal(t, text.String(), event.Message.Text)
+			assert.Equal(t, "assistant", event.Message.AuthorName)
+			break
+		}
+		text.WriteString(event.Chunk)
+	}
+	assert.Contains(t, text.String(), "You said: @assistant ping")
+	chatAssistant.Wait()
+
+	// при остановке поток закрывается, и сервер дожидается его завершения
+	close(streams.draining)
+	_, err = io.Copy(io.Discard, resp.Body)
+	assert.NoError(t, err)
+	streams.active.Wait()
+}
@@ -0,0 +1,36 @@
+package assistant
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"chat-api/service"
+)
+
+// Fake - детерминированный Responder для разработки и тестов: пересказывает
+// упоминание и размер истории, отдавая ответ по словам
+type Fake struct{}
+
+func (Fake) Respond(ctx context.Context, req service.AssistantRequest, emit func(chunk string) error) error {
+	text := fmt.Sprintf("Hi %s! I have read %d messages in %q. You said: %s",
+		author(req.Message.AuthorName), len(req.History), req.Title, req.Message.Text)
+
+	words := strings.Fields(text)
+	for i, word := range words {
+		if i < len(words)-1 {
+			word += " "
+		}
+		if err := emit(word); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func author(name string) string {
+	if name == "" {
+		return "there"
+	}
+	return name
+}
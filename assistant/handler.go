@@ -0,0 +1,128 @@
+package assistant
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"chat-api/handlers"
+	"chat-api/repository"
+	"chat-api/service"
+)
+
+// Streams - учёт потоковых соединений при остановке; в приложении это server.Server
+type Streams interface {
+	// Draining - закрывается в начале остановки, открытые потоки завершаются
+	Draining() <-chan struct{}
+	// TrackStream - остановка ждёт поток, пока не вызвана возвращённая функция
+	TrackStream() func()
+}
+
+// Handler - включение ассистента в чате и поток его ответов в формате server-sent events
+type Handler struct {
+	assistant *service.Assistant
+	streams   Streams
+}
+
+func NewHandler(assistant *service.Assistant, streams Streams) *Handler {
+	return &Handler{assistant: assistant, streams: streams}
+}
+
+func (h *Handler) Register(g *handlers.Group) {
+	g.Put("/chats/{id}/assistant", h.SetEnabled)
+	g.Get("/chats/{id}/assistant/stream", h.Stream)
+}
+
+type setEnabledRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+func (h *Handler) SetEnabled(w http.ResponseWriter, r *http.Request) {
+	id, err := handlers.PathParamUint(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
+		return
+	}
+
+	var req setEnabledRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Enabled == nil {
+		http.Error(w, "Invalid JSON: enabled is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.assistant.SetEnabled(r.Context(), id, *req.Enabled); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to update assistant", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"chat_id": id, "assistant_enabled": *req.Enabled})
+}
+
+// Stream - события ответов ассистента в чате: chunk - очередная часть, message - сохранённый ответ, error - сбой
+func (h *Handler) Stream(w http.ResponseWriter, r *http.Request) {
+	id, err := handlers.PathParamUint(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.assistant.CheckChat(r.Context(), id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to open assistant stream", http.StatusInternalServerError)
+		return
+	}
+
+	done := h.streams.TrackStream()
+	defer done()
+
+	events, cancel := h.assistant.Subscribe(id)
+	defer cancel()
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.streams.Draining():
+			return
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventName(event), data); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func eventName(event service.AssistantEvent) string {
+	switch {
+	case event.Error != "":
+		return "error"
+	case event.Message != nil:
+		return "message"
+	default:
+		return "chunk"
+	}
+}
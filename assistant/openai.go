@@ -0,0 +1,134 @@
+package assistant
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"chat-api/service"
+)
+
+// OpenAI - Responder поверх OpenAI-совместимого API chat completions с потоковой выдачей
+type OpenAI struct {
+	endpoint string
+	apiKey   string
+	model    string
+	client   *http.Client
+}
+
+// NewOpenAI - endpoint без /chat/completions, например https://api.openai.com/v1
+func NewOpenAI(endpoint, apiKey, model string) *OpenAI {
+	return &OpenAI{
+		endpoint: strings.TrimRight(endpoint, "/"),
+		apiKey:   apiKey,
+		model:    model,
+		client:   &http.Client{},
+	}
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type completionRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+}
+
+type completionChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+}
+
+func (o *OpenAI) Respond(ctx context.Context, req service.AssistantRequest, emit func(chunk string) error) error {
+	body, err := json.Marshal(completionRequest{
+		Model:    o.model,
+		Messages: prompt(req),
+		Stream:   true,
+	})
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, o.endpoint+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "text/event-stream")
+	if o.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	resp, err := o.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return fmt.Errorf("provider responded with %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			return nil
+		}
+
+		var chunk completionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("invalid stream chunk: %w", err)
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			if err := emit(choice.Delta.Content); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("stream ended without [DONE]")
+}
+
+// prompt - история чата в ролях chat completions: свои ответы - assistant, остальное - user с подписью автора
+func prompt(req service.AssistantRequest) []chatMessage {
+	messages := []chatMessage{{
+		Role:    "system",
+		Content: fmt.Sprintf("You are %s, an assistant in the group chat %q. Reply briefly to the last message that mentions you.", req.Name, req.Title),
+	}}
+
+	for _, m := range req.History {
+		if m.AuthorName == req.Name {
+			messages = append(messages, chatMessage{Role: "assistant", Content: m.Text})
+			continue
+		}
+		name := m.AuthorName
+		if name == "" {
+			name = "anonymous"
+		}
+		messages = append(messages, chatMessage{Role: "user", Content: name + ": " + m.Text})
+	}
+	return messages
+}
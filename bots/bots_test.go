@@ -0,0 +1,271 @@
+package bots
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"chat-api/handlers"
+	"chat-api/internal/testutil"
+	"chat-api/models"
+	"chat-api/repository"
+	"chat-api/service"
+	"chat-api/tracing"
+	"chat-api/webhooks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// env - SQLite со схемой, реестр с встроенными командами и сервис чатов поверх него
+type env struct {
+	store    *Store
+	repo     repository.ChatRepository
+	chats    service.ChatService
+	registry *Registry
+	bots     *Service
+	chat     *models.Chat
+}
+
+func newEnv(t *testing.T) *env {
+	t.Helper()
+	ctx := context.Background()
+
+	db := testutil.SQLite(t)
+
+	store := NewStore(db)
+	registry := NewRegistry(store, time.Second, true)
+	registry.Register("poll", Poll())
+
+	repo := repository.NewRepository(db, testutil.DiscardLogger{})
+	chat, err := repo.Create(ctx, &models.Chat{Title: "ops"})
+	require.NoError(t, err)
+
+	return &env{
+		store:    store,
+		repo:     repo,
+		chats:    service.NewChatService(repo, service.WithCommands(registry)),
+		registry: registry,
+		bots:     NewService(store, registry),
+		chat:     chat,
+	}
+}
+
+func (e *env) messages(t *testing.T) []models.Message {
+	t.Helper()
+	messages, err := e.repo.ListMessages(context.Background(), e.chat.ID, 0, 100)
+	require.NoError(t, err)
+	return messages
+}
+
+// TestRegistry_ExternalBot - команда бота уходит на его адрес с подписью, ответ пишется
+// в чат от имени бота или возвращается только вызвавшему
+func TestRegistry_ExternalBot(t *testing.T) {
+	ctx := context.Background()
+	e := newEnv(t)
+
+	var (
+		mu          sync.Mutex
+		calls       []Invocation
+		traceparent string
+		reply       = `{"text":"deploying prod"}`
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		traceparent = r.Header.Get("traceparent")
+		err := webhooks.Verify("0123456789abcdef", r.Header.Get(webhooks.HeaderSignature), r.Header.Get(webhooks.HeaderTimestamp),
+			body, time.Minute, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		var inv Invocation
+		json.Unmarshal(body, &inv)
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, inv)
+		if inv.Args == "fail" {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		io.WriteString(w, reply)
+	}))
+	defer server.Close()
+
+	_, err := e.bots.Create(ctx, models.CreateBotRequest{Name: "Deployer", URL: server.URL, Secret: "0123456789abcdef", Commands: []string{"/Deploy"}})
+	require.NoError(t, err)
+
+	testutil.WithTracing(t)
+	userCtx, span := tracing.Tracer().Start(service.WithAuthor(ctx, "alice"), "POST /chats/{id}/messages")
+	defer span.End()
+	message, err := e.chats.SendMessage(userCtx, e.chat.ID, "/deploy prod")
+	require.NoError(t, err)
+	assert.Contains(t, traceparent, span.SpanContext().TraceID().String())
+	assert.Equal(t, "/deploy prod", message.Text)
+	require.Equal(t, []Invocation{{Command: "deploy", Args: "prod", Text: "/deploy prod", ChatID: e.chat.ID, User: "alice"}}, calls)
+
+	messages := e.messages(t)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "Deployer", messages[0].AuthorName)
+	assert.Equal(t, "deploying prod", messages[0].Text)
+
+	reply = `{"text":"only for alice","ephemeral":true}`
+	message, err = e.chats.SendMessage(userCtx, e.chat.ID, "/deploy status")
+	require.NoError(t, err)
+	assert.True(t, message.Ephemeral)
+	assert.Equal(t, "Deployer", message.AuthorName)
+
+	_, err = e.chats.SendMessage(userCtx, e.chat.ID, "/deploy fail")
+	assert.ErrorContains(t, err, "command /deploy failed")
+	assert.Len(t, e.messages(t), 2, "neither ephemeral replies nor failed commands are stored")
+
+	_, err = e.chats.SendMessage(userCtx, e.chat.ID, "/unknown stays text")
+	require.NoError(t, err)
+	assert.Len(t, e.messages(t), 3)
+}
+
+// TestRegistry_PrivateAddress - без allowPrivate бот на внутреннем адресе не создаётся и не вызывается,
+// а клиент получает 502 без адреса и ошибки соединения
+func TestRegistry_PrivateAddress(t *testing.T) {
+	ctx := context.Background()
+	e := newEnv(t)
+
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	registry := NewRegistry(e.store, time.Second, false)
+	bots := NewService(e.store, registry)
+	for _, url := range []string{server.URL, "http://169.254.169.254/latest/meta-data", "http://10.0.0.5/hook", "http://[::1]:8080/"} {
+		_, err := bots.Create(ctx, models.CreateBotRequest{Name: "Internal", URL: url, Commands: []string{"deploy"}})
+		assert.ErrorIs(t, err, ErrInvalid, url)
+	}
+
+	// адрес мог стать внутренним уже после создания, например после смены DNS
+	_, err := e.bots.Create(ctx, models.CreateBotRequest{Name: "Deployer", URL: server.URL, Commands: []string{"deploy"}})
+	require.NoError(t, err)
+
+	router := handlers.NewRouter()
+	router.Mount("", handlers.ChatModule(handlers.NewChatHandler(service.NewChatService(e.repo, service.WithCommands(registry)))))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/chats/"+jsonUint(e.chat.ID)+"/messages", strings.NewReader(`{"text":"/deploy"}`)))
+
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+	assert.Equal(t, "bot Deployer is unavailable\n", rec.Body.String())
+	assert.False(t, called)
+	assert.Empty(t, e.messages(t))
+}
+
+func TestPoll(t *testing.T) {
+	e := newEnv(t)
+
+	_, err := e.chats.SendMessage(service.WithAuthor(context.Background(), "bob"), e.chat.ID, "/poll Lunch? | Pizza | Sushi")
+	require.NoError(t, err)
+
+	messages := e.messages(t)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "Poll", messages[0].AuthorName)
+	assert.Equal(t, "📊 Lunch? (asked by bob)\n1. Pizza\n2. Sushi\nReply with the option number.", messages[0].Text)
+
+	usage, err := e.chats.SendMessage(context.Background(), e.chat.ID, "/poll Lunch? | Pizza")
+	require.NoError(t, err)
+	assert.True(t, usage.Ephemeral)
+	assert.Contains(t, usage.Text, "Usage: /poll")
+}
+
+// recordingPoster - запоминает сообщения сработавших напоминаний
+type recordingPoster struct {
+	mu     sync.Mutex
+	posted []string
+}
+
+func (p *recordingPoster) SendMessage(ctx context.Context, chatID uint, text string) (*models.Message, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.posted = append(p.posted, service.AuthorFromContext(ctx)+": "+text)
+	return &models.Message{ChatID: chatID, Text: text}, nil
+}
+
+func (p *recordingPoster) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.posted)
+}
+
+func TestReminders(t *testing.T) {
+	ctx := context.Background()
+	poster := &recordingPoster{}
+	reminders := NewReminders(poster, testutil.DiscardLogger{})
+
+	reply, err := reminders.Handle(ctx, service.Command{ChatID: 1, Name: "remind", Args: "10ms standup", User: "alice"})
+	require.NoError(t, err)
+	assert.True(t, reply.Ephemeral)
+	assert.Equal(t, "I will remind you in 10ms.", reply.Text)
+
+	require.Eventually(t, func() bool { return poster.count() == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"Reminder: ⏰ alice: standup"}, poster.posted)
+
+	reply, err = reminders.Handle(ctx, service.Command{ChatID: 1, Name: "remind", Args: "48h later"})
+	require.NoError(t, err)
+	assert.Contains(t, reply.Text, "Usage: /remind")
+
+	_, err = reminders.Handle(ctx, service.Command{ChatID: 1, Name: "remind", Args: "20ms cancelled"})
+	require.NoError(t, err)
+	reminders.Stop()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 1, poster.count(), "stopped reminders do not fire")
+}
+
+func TestHandler(t *testing.T) {
+	e := newEnv(t)
+
+	router := handlers.NewRouter()
+	router.Mount("", NewHandler(e.bots))
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusBadRequest, do("POST", "/bots", `{"name":"CI","url":"ci.local","commands":["build"]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do("POST", "/bots", `{"name":"CI","url":"http://ci.local","commands":["build now"]}`).Code)
+	assert.Equal(t, http.StatusConflict, do("POST", "/bots", `{"name":"CI","url":"http://ci.local","commands":["poll"]}`).Code)
+
+	rec := do("POST", "/bots", `{"name":"CI","url":"http://ci.local","commands":["build","build","test"]}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var bot models.BotResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &bot))
+	assert.Equal(t, []string{"build", "test"}, bot.Commands)
+	assert.True(t, strings.HasPrefix(bot.Secret, secretPrefix))
+
+	assert.Equal(t, http.StatusConflict, do("POST", "/bots", `{"name":"CI","url":"http://ci.local","commands":["lint"]}`).Code)
+	rec = do("POST", "/bots", `{"name":"Other","url":"http://ci.local","commands":["lint","test"]}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "/test")
+
+	rec = do("GET", "/bots", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), bot.Secret)
+
+	assert.Equal(t, http.StatusNoContent, do("DELETE", "/bots/"+jsonUint(bot.ID), "").Code)
+	assert.Equal(t, http.StatusNotFound, do("DELETE", "/bots/"+jsonUint(bot.ID), "").Code)
+
+	// команды удалённого бота больше не обрабатываются
+	_, handled, err := e.registry.Dispatch(context.Background(), service.Command{ChatID: e.chat.ID, Name: "build"})
+	require.NoError(t, err)
+	assert.False(t, handled)
+}
+
+func jsonUint(n uint) string {
+	return strconv.FormatUint(uint64(n), 10)
+}
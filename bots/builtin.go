@@ -0,0 +1,144 @@
+package bots
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"chat-api/models"
+	"chat-api/service"
+)
+
+const (
+	// maxReminderDelay - дальше напоминать нельзя: таймеры живут в памяти процесса
+	maxReminderDelay = 24 * time.Hour
+	// reminderPostTimeout - таймаут записи сработавшего напоминания
+	reminderPostTimeout = 10 * time.Second
+
+	maxPollOptions = 10
+
+	reminderAuthor = "Reminder"
+	pollAuthor     = "Poll"
+)
+
+// Poster - запись сообщения в чат; в приложении это service.ChatService
+type Poster interface {
+	SendMessage(ctx context.Context, chatID uint, text string) (*models.Message, error)
+}
+
+// Logger - логгер ошибок сработавших напоминаний
+type Logger interface {
+	LogError(ctx context.Context, operation string, err error)
+}
+
+// Reminders - команда /remind <длительность> <текст>: через указанное время бот пишет
+// текст в чат. Напоминания хранятся в памяти и теряются при перезапуске
+type Reminders struct {
+	poster Poster
+	logger Logger
+
+	mu      sync.Mutex
+	timers  map[*time.Timer]struct{}
+	stopped bool
+}
+
+func NewReminders(poster Poster, logger Logger) *Reminders {
+	return &Reminders{
+		poster: poster,
+		logger: logger,
+		timers: make(map[*time.Timer]struct{}),
+	}
+}
+
+func (r *Reminders) Handle(ctx context.Context, cmd service.Command) (*service.CommandReply, error) {
+	delayStr, text, _ := strings.Cut(cmd.Args, " ")
+	text = strings.TrimSpace(text)
+	delay, err := time.ParseDuration(delayStr)
+	if err != nil || delay <= 0 || delay > maxReminderDelay || text == "" {
+		return &service.CommandReply{
+			Author:    reminderAuthor,
+			Text:      fmt.Sprintf("Usage: /remind <duration> <text>, e.g. /remind 15m standup. Duration up to %s.", maxReminderDelay),
+			Ephemeral: true,
+		}, nil
+	}
+
+	// "⏰" в начале: сработавшее напоминание не разбирается как команда
+	reminder := "⏰ " + text
+	if cmd.User != "" {
+		reminder = "⏰ " + cmd.User + ": " + text
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		return nil, fmt.Errorf("reminders are stopped")
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
+		r.mu.Lock()
+		delete(r.timers, timer)
+		r.mu.Unlock()
+
+		postCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), reminderPostTimeout)
+		defer cancel()
+		// напоминание пишет бот, а не вызвавший участник: медленный режим к нему не относится
+		postCtx = service.WithAuthor(service.WithMember(postCtx, ""), reminderAuthor)
+		if _, err := r.poster.SendMessage(postCtx, cmd.ChatID, reminder); err != nil {
+			r.logger.LogError(postCtx, "Post reminder:", err)
+		}
+	})
+	r.timers[timer] = struct{}{}
+
+	return &service.CommandReply{
+		Author:    reminderAuthor,
+		Text:      "I will remind you in " + delay.String() + ".",
+		Ephemeral: true,
+	}, nil
+}
+
+// Stop - отменяет несработавшие напоминания
+func (r *Reminders) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopped = true
+	for timer := range r.timers {
+		timer.Stop()
+	}
+	clear(r.timers)
+}
+
+// Poll - команда /poll Вопрос | вариант | вариант: бот публикует опрос с пронумерованными
+// вариантами, участники отвечают номером
+func Poll() CommandHandler {
+	return CommandFunc(func(ctx context.Context, cmd service.Command) (*service.CommandReply, error) {
+		parts := strings.Split(cmd.Args, "|")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		question, options := parts[0], parts[1:]
+
+		if question == "" || len(options) < 2 || len(options) > maxPollOptions || slices.Contains(options, "") {
+			return &service.CommandReply{
+				Author:    pollAuthor,
+				Text:      fmt.Sprintf("Usage: /poll <question> | <option> | <option>, from 2 to %d options.", maxPollOptions),
+				Ephemeral: true,
+			}, nil
+		}
+
+		var text strings.Builder
+		text.WriteString("📊 " + question)
+		if cmd.User != "" {
+			text.WriteString(" (asked by " + cmd.User + ")")
+		}
+		for i, option := range options {
+			fmt.Fprintf(&text, "\n%d. %s", i+1, option)
+		}
+		text.WriteString("\nReply with the option number.")
+
+		return &service.CommandReply{Author: pollAuthor, Text: text.String()}, nil
+	})
+}
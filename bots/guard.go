@@ -0,0 +1,43 @@
+package bots
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"syscall"
+)
+
+// forbiddenAddr - адреса внутри сети сервера: через адрес бота нельзя обращаться к внутренним сервисам
+func forbiddenAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast()
+}
+
+// checkHost - хост бота не должен указывать на запрещённые адреса. Проверка при создании
+// отсекает очевидные ошибки; DNS может измениться, поэтому адрес проверяется и при соединении
+func checkHost(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("%w: cannot resolve url host %s", ErrInvalid, host)
+	}
+	for _, addr := range addrs {
+		if forbiddenAddr(addr) {
+			return fmt.Errorf("%w: url must not point to a loopback, link-local or private address", ErrInvalid)
+		}
+	}
+	return nil
+}
+
+// dialControl - проверяет адрес, к которому действительно идёт соединение, после разрешения имени
+func dialControl(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if forbiddenAddr(addrPort.Addr()) {
+		return fmt.Errorf("bot address %s is not allowed", addrPort.Addr())
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package bots
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"chat-api/handlers"
+	"chat-api/models"
+)
+
+// Handler - API ботов: создание, список, удаление
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) Register(g *handlers.Group) {
+	g.Post("/bots", h.Create)
+	g.Get("/bots", h.List)
+	g.Delete("/bots/{id}", h.Delete)
+}
+
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateBotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	bot, err := h.service.Create(r.Context(), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, bot)
+}
+
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	bots, err := h.service.List(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, bots)
+}
+
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := handlers.PathParamUint(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid bot ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), id); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
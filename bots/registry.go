@@ -0,0 +1,159 @@
+package bots
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"chat-api/models"
+	"chat-api/service"
+	"chat-api/tracing"
+	"chat-api/webhooks"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// maxReplySize - ограничение на тело ответа внешнего бота
+const maxReplySize = 64 << 10
+
+// CommandHandler - обработчик слэш-команды внутри процесса
+type CommandHandler interface {
+	Handle(ctx context.Context, cmd service.Command) (*service.CommandReply, error)
+}
+
+type CommandFunc func(ctx context.Context, cmd service.Command) (*service.CommandReply, error)
+
+func (f CommandFunc) Handle(ctx context.Context, cmd service.Command) (*service.CommandReply, error) {
+	return f(ctx, cmd)
+}
+
+// Invocation - тело запроса к внешнему боту
+type Invocation struct {
+	Command string `json:"command"`
+	Args    string `json:"args"`
+	Text    string `json:"text"`
+	ChatID  uint   `json:"chat_id"`
+	User    string `json:"user,omitempty"`
+}
+
+// Reply - ответ внешнего бота; пустое тело или пустой text - без ответа в чат
+type Reply struct {
+	Text      string `json:"text"`
+	Ephemeral bool   `json:"ephemeral,omitempty"`
+}
+
+// Registry - реестр слэш-команд: встроенные обработчики и команды ботов из базы.
+// Реализует service.Commands
+type Registry struct {
+	builtins     map[string]CommandHandler
+	store        *Store
+	client       *http.Client
+	allowPrivate bool
+}
+
+// NewRegistry - allowPrivate разрешает ботов на loopback, link-local и частных адресах,
+// например для локальной разработки; иначе такие адреса не принимаются ни при создании, ни при соединении
+func NewRegistry(store *Store, timeout time.Duration, allowPrivate bool) *Registry {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// прокси соединялся бы сам, в обход проверки адреса
+	transport.Proxy = nil
+	if !allowPrivate {
+		dialer := &net.Dialer{Timeout: timeout, Control: dialControl}
+		transport.DialContext = dialer.DialContext
+	}
+
+	return &Registry{
+		builtins:     make(map[string]CommandHandler),
+		store:        store,
+		client:       &http.Client{Timeout: timeout, Transport: transport},
+		allowPrivate: allowPrivate,
+	}
+}
+
+// Register - встроенная команда; её имя нельзя занять ботом. Вызывается до начала работы
+func (r *Registry) Register(name string, handler CommandHandler) {
+	r.builtins[name] = handler
+}
+
+func (r *Registry) isBuiltin(name string) bool {
+	_, ok := r.builtins[name]
+	return ok
+}
+
+func (r *Registry) Dispatch(ctx context.Context, cmd service.Command) (*service.CommandReply, bool, error) {
+	if handler, ok := r.builtins[cmd.Name]; ok {
+		reply, err := handler.Handle(ctx, cmd)
+		return reply, true, err
+	}
+
+	bot, err := r.store.FindByCommand(ctx, cmd.Name)
+	if errors.Is(err, ErrNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, true, err
+	}
+
+	reply, err := r.call(ctx, bot, cmd)
+	return reply, true, err
+}
+
+// call - POST команды на адрес бота с подписью как у исходящих вебхуков.
+// Сбои бота - *service.BotError: подробности не должны попадать в ответ клиенту
+func (r *Registry) call(ctx context.Context, bot *models.Bot, cmd service.Command) (_ *service.CommandReply, err error) {
+	ctx, span := tracing.StartClient(ctx, "Bot.Call", attribute.String("bot.name", bot.Name), attribute.String("bot.command", cmd.Name))
+	defer func() { tracing.End(span, err) }()
+
+	body, err := json.Marshal(Invocation{
+		Command: cmd.Name,
+		Args:    cmd.Args,
+		Text:    cmd.Text,
+		ChatID:  cmd.ChatID,
+		User:    cmd.User,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := time.Now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, bot.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "chat-api-bots")
+	req.Header.Set(webhooks.HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(webhooks.HeaderSignature, webhooks.Sign(bot.Secret, timestamp, body))
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, &service.BotError{Bot: bot.Name, Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &service.BotError{Bot: bot.Name, Err: fmt.Errorf("responded %s", resp.Status)}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxReplySize))
+	if err != nil {
+		return nil, &service.BotError{Bot: bot.Name, Err: err}
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+
+	var reply Reply
+	if err := json.Unmarshal(data, &reply); err != nil {
+		return nil, &service.BotError{Bot: bot.Name, Err: fmt.Errorf("invalid reply: %w", err)}
+	}
+	return &service.CommandReply{Author: bot.Name, Text: reply.Text, Ephemeral: reply.Ephemeral}, nil
+}
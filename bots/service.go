@@ -0,0 +1,181 @@
+package bots
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"chat-api/models"
+)
+
+const (
+	secretPrefix = "bot_"
+
+	maxNameLength    = 100
+	maxURLLength     = 2000
+	maxCommandLength = 32
+	maxCommands      = 20
+	minSecretLength  = 16
+)
+
+var (
+	// ErrInvalid - запрос не прошёл проверку
+	ErrInvalid = errors.New("invalid bot")
+	// ErrConflict - имя бота или команда уже заняты
+	ErrConflict = errors.New("bot conflict")
+)
+
+// Service - управление ботами для API
+type Service struct {
+	store    *Store
+	registry *Registry
+}
+
+func NewService(store *Store, registry *Registry) *Service {
+	return &Service{store: store, registry: registry}
+}
+
+// Create - создаёт бота с командами; секрет подписи генерируется, если не передан,
+// и возвращается только здесь
+func (s *Service) Create(ctx context.Context, req models.CreateBotRequest) (*models.BotResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxNameLength {
+		return nil, fmt.Errorf("%w: name must be from 1 to %d characters", ErrInvalid, maxNameLength)
+	}
+
+	target, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http(s) url", ErrInvalid)
+	}
+	if len(target.String()) > maxURLLength {
+		return nil, fmt.Errorf("%w: url cannot exceed %d characters", ErrInvalid, maxURLLength)
+	}
+	if !s.registry.allowPrivate {
+		if err := checkHost(ctx, target.Hostname()); err != nil {
+			return nil, err
+		}
+	}
+
+	commands, err := s.commandNames(req.Commands)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = newSecret(); err != nil {
+			return nil, err
+		}
+	} else if len(secret) < minSecretLength {
+		return nil, fmt.Errorf("%w: secret must be at least %d characters", ErrInvalid, minSecretLength)
+	}
+
+	if taken, err := s.store.NameTaken(ctx, name); err != nil {
+		return nil, err
+	} else if taken {
+		return nil, fmt.Errorf("%w: bot %q already exists", ErrConflict, name)
+	}
+	taken, err := s.store.TakenCommands(ctx, commands)
+	if err != nil {
+		return nil, err
+	}
+	if len(taken) > 0 {
+		return nil, fmt.Errorf("%w: commands already taken: /%s", ErrConflict, strings.Join(taken, ", /"))
+	}
+
+	bot := &models.Bot{
+		Name:   name,
+		URL:    target.String(),
+		Secret: secret,
+	}
+	for _, command := range commands {
+		bot.Commands = append(bot.Commands, models.BotCommand{Name: command})
+	}
+	if err := s.store.Create(ctx, bot); err != nil {
+		return nil, err
+	}
+
+	response := toResponse(*bot)
+	response.Secret = bot.Secret
+	return &response, nil
+}
+
+func (s *Service) List(ctx context.Context) ([]models.BotResponse, error) {
+	bots, err := s.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]models.BotResponse, len(bots))
+	for i, bot := range bots {
+		responses[i] = toResponse(bot)
+	}
+	return responses, nil
+}
+
+// Delete - удаляет бота; его команды сразу перестают обрабатываться
+func (s *Service) Delete(ctx context.Context, id uint) error {
+	return s.store.Delete(ctx, id)
+}
+
+// commandNames - имена команд без "/" в нижнем регистре, без повторов и встроенных
+func (s *Service) commandNames(commands []string) ([]string, error) {
+	if len(commands) == 0 || len(commands) > maxCommands {
+		return nil, fmt.Errorf("%w: commands must list from 1 to %d names", ErrInvalid, maxCommands)
+	}
+
+	names := make([]string, 0, len(commands))
+	for _, command := range commands {
+		name := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(command), "/"))
+		if !validCommandName(name) {
+			return nil, fmt.Errorf("%w: command %q must be up to %d latin letters, digits, '-' or '_'", ErrInvalid, command, maxCommandLength)
+		}
+		if s.registry.isBuiltin(name) {
+			return nil, fmt.Errorf("%w: /%s is a built-in command", ErrConflict, name)
+		}
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+	return slices.Compact(names), nil
+}
+
+// validCommandName - те же правила, что при разборе команды в service.SendMessage
+func validCommandName(name string) bool {
+	if name == "" || len(name) > maxCommandLength {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+func newSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed generate bot secret: %w", err)
+	}
+	return secretPrefix + hex.EncodeToString(buf), nil
+}
+
+func toResponse(bot models.Bot) models.BotResponse {
+	commands := make([]string, len(bot.Commands))
+	for i, command := range bot.Commands {
+		commands[i] = command.Name
+	}
+	return models.BotResponse{
+		ID:        bot.ID,
+		Name:      bot.Name,
+		URL:       bot.URL,
+		Commands:  commands,
+		CreatedAt: bot.CreatedAt,
+	}
+}
@@ -0,0 +1,86 @@
+package bots
+
+import (
+	"context"
+	"fmt"
+
+	"chat-api/models"
+	"chat-api/repository"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotFound - бот не найден; совпадает с repository.ErrNotFound
+var ErrNotFound = repository.ErrNotFound
+
+// Store - боты и их команды в базе
+type Store struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+// Create - сохраняет бота вместе с командами
+func (s *Store) Create(ctx context.Context, bot *models.Bot) error {
+	if err := s.db.WithContext(ctx).Create(bot).Error; err != nil {
+		return fmt.Errorf("failed create bot: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) List(ctx context.Context) ([]models.Bot, error) {
+	var bots []models.Bot
+	err := s.db.WithContext(ctx).
+		Preload("Commands", func(db *gorm.DB) *gorm.DB { return db.Order("name") }).
+		Order("id").Find(&bots).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed list bots: %w", err)
+	}
+	return bots, nil
+}
+
+// Delete - удаляет бота вместе с командами
+func (s *Store) Delete(ctx context.Context, id uint) error {
+	result := s.db.WithContext(ctx).Delete(&models.Bot{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed delete bot: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("failed delete bot: %w", ErrNotFound)
+	}
+	return nil
+}
+
+// NameTaken - есть ли бот с таким именем
+func (s *Store) NameTaken(ctx context.Context, name string) (bool, error) {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.Bot{}).Where("name = ?", name).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed check bot name: %w", err)
+	}
+	return count > 0, nil
+}
+
+// TakenCommands - какие из names уже заняты другими ботами
+func (s *Store) TakenCommands(ctx context.Context, names []string) ([]string, error) {
+	var taken []string
+	err := s.db.WithContext(ctx).Model(&models.BotCommand{}).Where("name IN ?", names).Order("name").Pluck("name", &taken).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed check bot commands: %w", err)
+	}
+	return taken, nil
+}
+
+// FindByCommand - бот, которому принадлежит команда
+func (s *Store) FindByCommand(ctx context.Context, name string) (*models.Bot, error) {
+	var bot models.Bot
+	err := s.db.WithContext(ctx).
+		Joins("JOIN bot_commands ON bot_commands.bot_id = bots.id").
+		Where("bot_commands.name = ?", name).
+		First(&bot).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed find bot command: %w", err)
+	}
+	return &bot, nil
+}
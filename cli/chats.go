@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"chat-api/logger"
+	"chat-api/repository"
+)
+
+func runChats(ctx context.Context, app *App, args []string) error {
+	if len(args) == 0 || args[0] != "purge" {
+		fmt.Fprintln(app.Stderr, "Usage: chat-api chats purge -older-than DURATION [-dry-run]")
+		return ErrUsage
+	}
+
+	fs := app.flagSet("chats purge")
+	olderThan := fs.Duration("older-than", 0, "удалить чаты без активности дольше этого срока, например 720h")
+	dryRun := fs.Bool("dry-run", false, "только посчитать чаты, ничего не удаляя")
+	if err := app.parse(fs, args[1:]); err != nil {
+		return err
+	}
+	if *olderThan <= 0 {
+		fmt.Fprintln(app.Stderr, "chats purge: -older-than must be positive")
+		return ErrUsage
+	}
+
+	db, closeDB, err := app.openDB(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	before := time.Now().Add(-*olderThan)
+	purged, err := repository.NewAdmin(db.DB, logger.NewDatabaseLogger()).PurgeChats(ctx, before, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		fmt.Fprintf(app.Stdout, "%d chats inactive since %s would be purged\n", purged, before.Format(time.RFC3339))
+	} else {
+		fmt.Fprintf(app.Stdout, "purged %d chats inactive since %s\n", purged, before.Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+// Package cli - подкоманды бинарника chat-api: сервер, миграции и обслуживание данных
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"chat-api/config"
+	"chat-api/database"
+	"chat-api/logger"
+)
+
+// ErrUsage - неверные аргументы командной строки; справка уже выведена
+var ErrUsage = errors.New("invalid usage")
+
+// App - общее окружение подкоманд
+type App struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	// LookupEnv - источник переменных окружения, по умолчанию os.LookupEnv
+	LookupEnv func(key string) (string, bool)
+
+	// Config и Log заполняются в Run до запуска подкоманды
+	Config *config.Config
+	Log    *logger.BaseLogger
+}
+
+type command struct {
+	usage string
+	help  string
+	run   func(ctx context.Context, app *App, args []string) error
+}
+
+var commands = map[string]command{
+	"serve":   {"serve", "запустить HTTP сервер (по умолчанию)", runServe},
+	"migrate": {"migrate up|down|status|version", "управление миграциями схемы", runMigrate},
+	"seed":    {"seed [-chats N] [-messages N]", "наполнить базу тестовыми чатами", runSeed},
+	"export":  {"export [-o FILE]", "выгрузить чаты с сообщениями в NDJSON", runExport},
+	"import":  {"import [-i FILE]", "загрузить чаты из NDJSON выгрузки", runImport},
+	"users":   {"users create -name NAME", "создать пользователя и выдать API ключ", runUsers},
+	"chats":   {"chats purge -older-than DURATION [-dry-run]", "удалить неактивные чаты", runChats},
+	"config":  {"config [-format yaml|toml|env]", "показать действующие настройки (секреты скрыты)", runConfig},
+}
+
+// Run - разбирает глобальные флаги настроек и выполняет подкоманду из args (без имени программы)
+func (app *App) Run(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("chat-api", flag.ContinueOnError)
+	fs.SetOutput(app.Stderr)
+	fs.Usage = app.usage
+
+	loader := config.NewLoader(fs)
+	if app.LookupEnv != nil {
+		loader.LookupEnv = app.LookupEnv
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return ErrUsage
+	}
+	args = fs.Args()
+
+	name := "serve"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		app.usage()
+		return nil
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(app.Stderr, "unknown command %q\n\n", name)
+		app.usage()
+		return ErrUsage
+	}
+
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintln(app.Stderr, err)
+		return ErrUsage
+	}
+	app.Config = cfg
+
+	logger.Configure(cfg.Log.ToFile, cfg.Log.Dir)
+	app.Log = logger.CreateBaseLogger("logs.log")
+
+	err = cmd.run(ctx, app, args)
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return nil
+	case err != nil && !errors.Is(err, ErrUsage):
+		app.Log.LogError(context.Background(), "Application stopped:", err)
+	}
+	return err
+}
+
+func (app *App) usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(app.Stderr, "Usage: chat-api [-config FILE] [-section.key VALUE ...] <command> [flags]")
+	fmt.Fprintln(app.Stderr)
+	fmt.Fprintln(app.Stderr, "Commands:")
+	for _, name := range names {
+		fmt.Fprintf(app.Stderr, "  %-48s %s\n", commands[name].usage, commands[name].help)
+	}
+	fmt.Fprintln(app.Stderr)
+	fmt.Fprintln(app.Stderr, "Settings are read from defaults, the -config file, environment variables and flags, in that order.")
+	fmt.Fprintln(app.Stderr, "Run \"chat-api config\" to print the effective configuration.")
+}
+
+// flagSet - набор флагов подкоманды, ошибки разбора печатаются в Stderr
+func (app *App) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(app.Stderr)
+	return fs
+}
+
+// parse - разбирает флаги и запрещает лишние позиционные аргументы
+func (app *App) parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return ErrUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(app.Stderr, "%s: unexpected arguments: %s\n", fs.Name(), strings.Join(fs.Args(), " "))
+		return ErrUsage
+	}
+	return nil
+}
+
+// openDB - подключение к базе, общее для всех подкоманд
+func (app *App) openDB(ctx context.Context) (*database.ClientDB, func(), error) {
+	if app.Config.Storage.Backend == config.BackendMemory {
+		err := fmt.Errorf("this command needs a database, but storage.backend is %q", app.Config.Storage.Backend)
+		fmt.Fprintln(app.Stderr, err)
+		return nil, nil, ErrUsage
+	}
+
+	cfg := app.Config.Database
+
+	gormLogger, err := logger.NewGormLogger(cfg.LogLevel, cfg.SlowQueryThreshold)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db, err := database.NewDB(ctx, database.Config{
+		URL:      cfg.URL,
+		Host:     cfg.Host,
+		Port:     cfg.Port,
+		User:     cfg.User,
+		Password: cfg.Password,
+		DBName:   cfg.Name,
+		SSLMode:  cfg.SSLMode,
+
+		MaxOpenConns:    cfg.MaxOpenConns,
+		MaxIdleConns:    cfg.MaxIdleConns,
+		ConnMaxLifetime: cfg.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.ConnMaxIdleTime,
+
+		StatementTimeout: cfg.StatementTimeout,
+		ConnectTimeout:   cfg.ConnectTimeout,
+		ConnectRetries:   cfg.ConnectRetries,
+		ConnectBackoff:   cfg.ConnectBackoff,
+
+		Replicas:             cfg.Replicas,
+		ReplicaCheckInterval: cfg.ReplicaCheckInterval,
+
+		Logger: gormLogger,
+	})
+	if err != nil {
+		app.Log.LogError(ctx, "Start database:", err)
+		return nil, nil, err
+	}
+
+	closeDB := func() {
+		if err := db.Close(); err != nil {
+			app.Log.LogError(context.Background(), "Close database:", err)
+		}
+	}
+	return db, closeDB, nil
+}
+
+func runConfig(ctx context.Context, app *App, args []string) error {
+	fs := app.flagSet("config")
+	format := fs.String("format", "yaml", "формат вывода: yaml, toml или env")
+	if err := app.parse(fs, args); err != nil {
+		return err
+	}
+
+	return app.Config.Dump(app.Stdout, *format)
+}
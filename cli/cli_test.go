@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"chat-api/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestApp() (*App, *bytes.Buffer, *bytes.Buffer) {
+	var stdout, stderr bytes.Buffer
+	app := &App{
+		Stdout:    &stdout,
+		Stderr:    &stderr,
+		LookupEnv: testEnv(map[string]string{"DB_PASSWORD": "s3cret", "LOG_TO_FILE": "off"}),
+	}
+	return app, &stdout, &stderr
+}
+
+func testEnv(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+// TestRun_Help - справка перечисляет все подкоманды
+func TestRun_Help(t *testing.T) {
+	app, _, stderr := newTestApp()
+
+	require.NoError(t, app.Run(context.Background(), []string{"help"}))
+
+	for name := range commands {
+		assert.Contains(t, stderr.String(), "  "+name)
+	}
+}
+
+// TestRun_InvalidUsage - ошибки аргументов обнаруживаются до подключения к базе
+func TestRun_InvalidUsage(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"unknown command", []string{"frobnicate"}},
+		{"migrate without subcommand", []string{"migrate"}},
+		{"unknown migrate subcommand", []string{"migrate", "sideways"}},
+		{"migrate extra arguments", []string{"migrate", "up", "now"}},
+		{"users without create", []string{"users"}},
+		{"users create without name", []string{"users", "create"}},
+		{"chats without purge", []string{"chats", "list"}},
+		{"chats purge without age", []string{"chats", "purge"}},
+		{"seed with zero chats", []string{"seed", "-chats", "0"}},
+		{"unknown flag", []string{"export", "-format", "csv"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, _, stderr := newTestApp()
+
+			err := app.Run(context.Background(), tt.args)
+
+			assert.ErrorIs(t, err, ErrUsage)
+			assert.NotEmpty(t, stderr.String())
+		})
+	}
+}
+
+// TestRun_FlagHelp - -h у подкоманды печатает флаги и ничего не выполняет
+func TestRun_FlagHelp(t *testing.T) {
+	app, _, stderr := newTestApp()
+
+	require.NoError(t, app.Run(context.Background(), []string{"chats", "purge", "-h"}))
+	assert.Contains(t, stderr.String(), "-older-than")
+}
+
+// TestImportChats - разбор NDJSON выгрузки
+func TestImportChats(t *testing.T) {
+	input := strings.Join([]string{
+		`{"id":7,"title":"First","messages":[{"id":1,"chat_id":7,"text":"hi"},{"id":2,"chat_id":7,"text":"there"}]}`,
+		``,
+		`{"id":9,"title":"Second"}`,
+	}, "\n")
+
+	var saved []models.Chat
+	imported, err := importChats(strings.NewReader(input), func(chat *models.Chat) error {
+		saved = append(saved, *chat)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, imported)
+	require.Len(t, saved, 2)
+	assert.Equal(t, "First", saved[0].Title)
+	assert.Len(t, saved[0].Messages, 2)
+	assert.Equal(t, "there", saved[0].Messages[1].Text)
+	assert.Empty(t, saved[1].Messages)
+}
+
+// TestImportChats_Errors - номер строки в ошибке и остановка на первой ошибке
+func TestImportChats_Errors(t *testing.T) {
+	_, err := importChats(strings.NewReader("{\"title\":\"ok\"}\n{broken"), func(chat *models.Chat) error { return nil })
+	assert.ErrorContains(t, err, "line 2")
+
+	_, err = importChats(strings.NewReader(`{"title":""}`), func(chat *models.Chat) error { return nil })
+	assert.ErrorContains(t, err, "title is empty")
+
+	saveErr := errors.New("duplicate")
+	imported, err := importChats(strings.NewReader("{\"title\":\"a\"}\n{\"title\":\"b\"}"), func(chat *models.Chat) error {
+		if chat.Title == "b" {
+			return saveErr
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, saveErr)
+	assert.Equal(t, 1, imported)
+}
+
+// TestRun_Config - глобальные флаги переопределяют окружение, пароль скрыт
+func TestRun_Config(t *testing.T) {
+	app, stdout, _ := newTestApp()
+
+	err := app.Run(context.Background(), []string{"-database.host", "db.internal", "-server.port=9090", "config", "-format", "env"})
+
+	require.NoError(t, err)
+	assert.Contains(t, stdout.String(), "DB_HOST=db.internal\n")
+	assert.Contains(t, stdout.String(), "PORT=9090\n")
+	assert.Contains(t, stdout.String(), "DB_PASSWORD=******\n")
+	assert.NotContains(t, stdout.String(), "s3cret")
+}
+
+// TestRun_InvalidConfig - ошибки настроек выводятся до запуска подкоманды
+func TestRun_InvalidConfig(t *testing.T) {
+	app, _, stderr := newTestApp()
+	app.LookupEnv = testEnv(map[string]string{"DB_PORT": "54x2"})
+
+	err := app.Run(context.Background(), []string{"migrate", "up"})
+
+	assert.ErrorIs(t, err, ErrUsage)
+	assert.Contains(t, stderr.String(), `DB_PORT: invalid integer "54x2"`)
+}
+
+// TestRun_MemoryBackend - команды обслуживания требуют базу
+func TestRun_MemoryBackend(t *testing.T) {
+	app, _, stderr := newTestApp()
+
+	err := app.Run(context.Background(), []string{"-storage.backend", "memory", "migrate", "status"})
+
+	assert.ErrorIs(t, err, ErrUsage)
+	assert.Contains(t, stderr.String(), "needs a database")
+}
+
+// TestRun_SQLite - подкоманды обслуживания работают с файлом SQLite без PostgreSQL
+func TestRun_SQLite(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	global := []string{"-storage.backend", "sqlite", "-database.url", "sqlite://" + filepath.Join(dir, "chat.db")}
+
+	run := func(args ...string) string {
+		t.Helper()
+		app, stdout, stderr := newTestApp()
+		require.NoError(t, app.Run(ctx, append(slices.Clone(global), args...)), stderr.String())
+		return stdout.String()
+	}
+
+	run("migrate", "up")
+	var version, latest int64
+	_, err := fmt.Sscanf(run("migrate", "version"), "%d (latest %d)", &version, &latest)
+	require.NoError(t, err)
+	assert.Equal(t, latest, version)
+
+	run("seed", "-chats", "2", "-messages", "3")
+	assert.Contains(t, run("users", "create", "-name", "ci-bot"), "chk_")
+
+	exported := filepath.Join(dir, "chats.ndjson")
+	run("export", "-o", exported)
+	data, err := os.ReadFile(exported)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(data), "\n"))
+}
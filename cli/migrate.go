@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"chat-api/database"
+)
+
+func runMigrate(ctx context.Context, app *App, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(app.Stderr, "Usage: chat-api migrate up|down|status|version")
+		return ErrUsage
+	}
+
+	sub := args[0]
+	if err := app.parse(app.flagSet("migrate "+sub), args[1:]); err != nil {
+		return err
+	}
+
+	switch sub {
+	case "up", "down", "status", "version":
+	default:
+		fmt.Fprintf(app.Stderr, "unknown migrate command %q\n", sub)
+		return ErrUsage
+	}
+
+	db, closeDB, err := app.openDB(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	migrator, err := db.Migrator()
+	if err != nil {
+		return err
+	}
+
+	switch sub {
+	case "up":
+		return app.migrateUp(ctx, db)
+
+	case "down":
+		migration, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		app.Log.LogInfo(ctx, "Migration rolled back", fmt.Sprintf("%d_%s", migration.Version, migration.Name))
+		return nil
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(app.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.Applied {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+
+	default:
+		version, err := migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(app.Stdout, "%d (latest %d)\n", version, migrator.Latest())
+		return nil
+	}
+}
+
+func (app *App) migrateUp(ctx context.Context, db *database.ClientDB) error {
+	migrator, err := db.Migrator()
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.Up(ctx)
+	for _, migration := range applied {
+		app.Log.LogInfo(ctx, "Migration applied", fmt.Sprintf("%d_%s", migration.Version, migration.Name))
+	}
+	return err
+}
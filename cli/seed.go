@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"chat-api/logger"
+	"chat-api/repository"
+	"chat-api/service"
+)
+
+func runSeed(ctx context.Context, app *App, args []string) error {
+	fs := app.flagSet("seed")
+	chats := fs.Int("chats", 10, "сколько чатов создать")
+	messages := fs.Int("messages", 20, "сколько сообщений создать в каждом чате")
+	if err := app.parse(fs, args); err != nil {
+		return err
+	}
+	if *chats < 1 || *messages < 0 {
+		fmt.Fprintln(app.Stderr, "seed: -chats must be positive and -messages non-negative")
+		return ErrUsage
+	}
+
+	db, closeDB, err := app.openDB(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	// через сервис, чтобы тестовые данные проходили ту же валидацию, что и запросы API
+	chatService := service.NewChatService(repository.NewRepository(db.DB, logger.NewDatabaseLogger()))
+
+	for i := 1; i <= *chats; i++ {
+		chat, err := chatService.CreateChat(ctx, fmt.Sprintf("Seed chat #%d", i))
+		if err != nil {
+			return err
+		}
+		for j := 1; j <= *messages; j++ {
+			if _, err := chatService.SendMessage(ctx, chat.ID, fmt.Sprintf("Seed message #%d in chat #%d", j, i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	fmt.Fprintf(app.Stdout, "seeded %d chats with %d messages each\n", *chats, *messages)
+	return nil
+}
@@ -0,0 +1,334 @@
+package cli
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"chat-api/assistant"
+	"chat-api/bots"
+	"chat-api/config"
+	"chat-api/handlers"
+	"chat-api/idempotency"
+	"chat-api/incoming"
+	"chat-api/logger"
+	"chat-api/metrics"
+	"chat-api/outbox"
+	"chat-api/ratelimit"
+	"chat-api/repository"
+	"chat-api/server"
+	"chat-api/service"
+	"chat-api/tracing"
+	"chat-api/webhooks"
+
+	"gorm.io/gorm"
+)
+
+func runServe(ctx context.Context, app *App, args []string) error {
+	if err := app.parse(app.flagSet("serve"), args); err != nil {
+		return err
+	}
+
+	log := app.Log
+	log.LogInfo(ctx, "Application starting", "version=1.0.0")
+
+	cfg := app.Config
+	log.LogInfo(ctx, "Configuration", cfg.String())
+
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		ServiceName: cfg.Tracing.ServiceName,
+		FilePath:    cfg.Tracing.File,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		log.LogError(ctx, "Start tracing:", err)
+		return err
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			log.LogError(flushCtx, "Flush traces:", err)
+		}
+	}()
+
+	requestLogger := logger.NewRequestLogger()
+	databaseLogger := logger.NewDatabaseLogger()
+
+	appMetrics := metrics.New()
+	appMetrics.Registry.NewCounterFunc("chat_http_panics_recovered_total", "Handler panics recovered by the router.",
+		func() float64 { return float64(handlers.RecoveredPanics()) })
+
+	store, err := app.openStorage(ctx, appMetrics, databaseLogger)
+	if err != nil {
+		return err
+	}
+	defer store.close()
+
+	serviceOpts := []service.Option{service.WithMetrics(appMetrics)}
+	var (
+		botStore    *bots.Store
+		botRegistry *bots.Registry
+	)
+	if cfg.Bots.Enabled {
+		botStore = bots.NewStore(store.db)
+		botRegistry = bots.NewRegistry(botStore, cfg.Bots.Timeout, cfg.Bots.AllowPrivateURLs)
+		serviceOpts = append(serviceOpts, service.WithCommands(botRegistry))
+	}
+
+	var chatAssistant *service.Assistant
+	if cfg.Assistant.Enabled {
+		chatAssistant = service.NewAssistant(store.repo, newResponder(cfg.Assistant), service.AssistantConfig{
+			Name:         cfg.Assistant.Name,
+			HistoryLimit: cfg.Assistant.HistoryLimit,
+			Timeout:      cfg.Assistant.Timeout,
+			Budget:       cfg.Assistant.Budget,
+			BudgetWindow: cfg.Assistant.BudgetWindow,
+		}, log)
+		defer chatAssistant.Wait()
+		serviceOpts = append(serviceOpts, service.WithAssistant(chatAssistant))
+	}
+
+	chatService := service.NewChatService(store.repo, serviceOpts...)
+	if cfg.Bots.Enabled {
+		reminders := bots.NewReminders(chatService, log)
+		defer reminders.Stop()
+		botRegistry.Register("remind", reminders)
+		botRegistry.Register("poll", bots.Poll())
+		store.modules = append(store.modules, bots.NewHandler(bots.NewService(botStore, botRegistry)))
+	}
+	if cfg.Incoming.Enabled {
+		incomingService := incoming.NewService(incoming.NewStore(store.db), chatService, cfg.Incoming.RateLimit)
+		store.modules = append(store.modules, incoming.NewHandler(incomingService))
+	}
+
+	health := handlers.NewHealthHandler(log, store.checks...)
+
+	modules := append([]handlers.Module{health, handlers.ModuleFunc(func(g *handlers.Group) {
+		g.Get("/metrics", appMetrics.Handler())
+	})}, store.modules...)
+	router := handlers.New(chatService, requestLogger, modules...)
+	router.Use(handlers.MetricsMiddleware(appMetrics), handlers.TracingMiddleware())
+	var users ratelimit.Users
+	if store.db != nil {
+		users = repository.NewAdmin(store.db, appMetrics.DatabaseLogger(databaseLogger))
+	}
+	router.Use(ratelimit.NewIdentifier(users, cfg.RateLimit.TrustForwardedFor).Identify())
+	if cfg.RateLimit.Enabled {
+		router.Use(ratelimit.Middleware(ratelimit.NewLimiter(), ratelimit.Config{
+			Read:              cfg.RateLimit.Read,
+			Write:             cfg.RateLimit.Write,
+			Messages:          cfg.RateLimit.Messages,
+			TrustForwardedFor: cfg.RateLimit.TrustForwardedFor,
+			Exempt:            []string{"/livez", "/readyz", "/health", "/metrics"},
+		}, appMetrics))
+	}
+	if cfg.Idempotency.Enabled {
+		var keys idempotency.Store = idempotency.NewMemoryStore()
+		if store.db != nil {
+			keys = idempotency.NewStore(store.db)
+		}
+		router.Use(idempotency.Middleware(keys, cfg.Idempotency.TTL, cfg.Idempotency.Lease, log))
+	}
+
+	srv := server.New(server.Config{
+		Addr:              ":" + strconv.Itoa(cfg.Server.Port),
+		ShutdownTimeout:   cfg.Server.ShutdownTimeout,
+		ShutdownDelay:     cfg.Server.ShutdownDelay,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}, router)
+	// потоки ответов ассистента завершаются в начале остановки, и сервер их дожидается
+	if chatAssistant != nil {
+		router.Mount("", assistant.NewHandler(chatAssistant, srv))
+	}
+	srv.BeforeShutdown(health.SetShuttingDown)
+	srv.OnShutdown(func() {
+		log.LogInfo(context.Background(), "Shutdown", "draining in-flight requests")
+	})
+
+	if err := srv.Run(ctx); err != nil {
+		log.LogError(context.Background(), "Start http server:", err)
+		return err
+	}
+
+	log.LogInfo(context.Background(), "Shutdown", "http server stopped")
+	return nil
+}
+
+// newResponder - провайдер ответов ассистента по настройкам
+func newResponder(cfg config.Assistant) service.Responder {
+	if cfg.Provider == config.ProviderOpenAI {
+		return assistant.NewOpenAI(cfg.Endpoint, cfg.APIKey, cfg.Model)
+	}
+	return assistant.Fake{}
+}
+
+// storage - открытое хранилище и всё, что работает поверх него
+type storage struct {
+	repo repository.ChatRepository
+	// db - nil для хранилища в памяти
+	db *gorm.DB
+	// checks - проверки готовности для /readyz
+	checks []handlers.HealthCheck
+	// modules - маршруты, которым нужна база, например API вебхуков
+	modules []handlers.Module
+	// close - останавливает фоновые задачи и закрывает базу
+	close func()
+}
+
+// openStorage - хранилище чатов по storage.backend
+func (app *App) openStorage(ctx context.Context, appMetrics *metrics.Metrics, databaseLogger metrics.Logger) (*storage, error) {
+	cfg := app.Config
+
+	if cfg.Storage.Backend == config.BackendMemory {
+		app.Log.LogWarn(ctx, "Storage", "in-memory backend: data is lost on restart")
+		repo := repository.NewMemoryRepository(appMetrics.DatabaseLogger(databaseLogger))
+		return &storage{repo: repo, close: func() {}}, nil
+	}
+
+	db, closeDB, err := app.openDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Database.MigrateOnStart {
+		if err := app.migrateUp(ctx, db); err != nil {
+			closeDB()
+			app.Log.LogError(ctx, "Migrate database:", err)
+			return nil, err
+		}
+	}
+
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		closeDB()
+		app.Log.LogError(ctx, "Start database:", err)
+		return nil, err
+	}
+	appMetrics.RegisterDBStats(sqlDB)
+	appMetrics.Registry.NewGaugeFunc("chat_db_replicas_healthy", "Read replicas currently passing health checks.",
+		func() float64 { _, healthy := db.ReplicaStatus(); return float64(healthy) })
+
+	opts := []repository.Option{
+		repository.WithReader(db.Reader),
+		repository.WithReadYourWrites(cfg.Database.ReadYourWritesWindow),
+	}
+
+	store := &storage{
+		db: db.DB,
+		checks: []handlers.HealthCheck{
+			{Name: "database", Check: db.Ping},
+			{Name: "migrations", Check: db.CheckSchemaVersion},
+		},
+		close: closeDB,
+	}
+
+	if cfg.Outbox.Enabled {
+		var sinks []outbox.Sink
+		if cfg.Webhooks.Enabled {
+			webhookStore := webhooks.NewStore(db.DB)
+			stopDispatcher := app.startDispatcher(ctx, webhookStore, appMetrics)
+			store.close = func() {
+				stopDispatcher()
+				closeDB()
+			}
+			sinks = append(sinks, webhooks.NewSink(webhookStore))
+			store.modules = append(store.modules, webhooks.NewHandler(webhooks.NewService(webhookStore)))
+		}
+
+		stopRelay, err := app.startRelay(ctx, db.DB, appMetrics, sinks...)
+		if err != nil {
+			store.close()
+			app.Log.LogError(ctx, "Start outbox relay:", err)
+			return nil, err
+		}
+		closeRest := store.close
+		store.close = func() {
+			stopRelay()
+			closeRest()
+		}
+		opts = append(opts, repository.WithOutbox())
+	}
+
+	store.repo = repository.NewRepository(db.DB, appMetrics.DatabaseLogger(databaseLogger), opts...)
+
+	return store, nil
+}
+
+// startRelay - запускает доставку событий outbox в фоне; sinks добавляются к настроенным
+// в outbox.sinks. Возвращённая функция останавливает доставку и ждёт завершения текущего прохода
+func (app *App) startRelay(ctx context.Context, db *gorm.DB, appMetrics *metrics.Metrics, sinks ...outbox.Sink) (func(), error) {
+	cfg := app.Config.Outbox
+
+	closer := func() {}
+	for _, name := range cfg.Sinks {
+		switch name {
+		case config.SinkBus:
+			sinks = append(sinks, outbox.NewBus())
+		case config.SinkWebhook:
+			sinks = append(sinks, outbox.NewWebhookSink(cfg.WebhookURL, cfg.WebhookTimeout))
+		case config.SinkFile:
+			fileSink, err := outbox.NewFileSink(cfg.File)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, fileSink)
+			closer = func() { fileSink.Close() }
+		}
+	}
+
+	relay := outbox.NewRelay(db, sinks,
+		outbox.WithBatchSize(cfg.BatchSize),
+		outbox.WithInterval(cfg.PollInterval),
+		outbox.WithRetry(cfg.MaxAttempts, cfg.Backoff, cfg.MaxBackoff),
+		outbox.WithLogger(app.Log),
+		outbox.WithMetrics(appMetrics),
+	)
+
+	// доставка переживает отмену ctx при остановке и завершается после HTTP сервера
+	relayCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		relay.Run(relayCtx)
+	}()
+	app.Log.LogInfo(ctx, "Outbox", "relay started, sinks: "+strings.Join(cfg.Sinks, ", "))
+
+	return func() {
+		cancel()
+		<-done
+		closer()
+	}, nil
+}
+
+// startDispatcher - запускает отправку исходящих вебхуков в фоне; возвращённая функция
+// останавливает её и ждёт завершения текущей пачки
+func (app *App) startDispatcher(ctx context.Context, store *webhooks.Store, appMetrics *metrics.Metrics) func() {
+	cfg := app.Config.Webhooks
+
+	dispatcher := webhooks.NewDispatcher(store, webhooks.Config{
+		Timeout:      cfg.Timeout,
+		MaxAttempts:  cfg.MaxAttempts,
+		Backoff:      cfg.Backoff,
+		MaxBackoff:   cfg.MaxBackoff,
+		Retention:    cfg.Retention,
+		BatchSize:    cfg.BatchSize,
+		PollInterval: cfg.PollInterval,
+	}, app.Log, appMetrics)
+
+	dispatchCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		dispatcher.Run(dispatchCtx)
+	}()
+	app.Log.LogInfo(ctx, "Webhooks", "dispatcher started")
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
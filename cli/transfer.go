@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"chat-api/logger"
+	"chat-api/models"
+	"chat-api/repository"
+)
+
+// maxImportLine - предел длины одной строки выгрузки (чат со всеми сообщениями)
+const maxImportLine = 64 << 20
+
+// Формат выгрузки - NDJSON, одна строка на чат:
+// {"id":1,"title":"...","created_at":"...","updated_at":"...","messages":[...]}
+
+func runExport(ctx context.Context, app *App, args []string) error {
+	fs := app.flagSet("export")
+	output := fs.String("o", "chats.ndjson", "файл выгрузки")
+	if err := app.parse(fs, args); err != nil {
+		return err
+	}
+
+	db, closeDB, err := app.openDB(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	// только в файл: в stdout пишут логи приложения и GORM
+	file, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer file.Close()
+
+	bw := bufio.NewWriter(file)
+	encoder := json.NewEncoder(bw)
+
+	var exported int
+	err = repository.NewAdmin(db.DB, logger.NewDatabaseLogger()).ExportChats(ctx, func(chat *models.Chat) error {
+		exported++
+		return encoder.Encode(chat)
+	})
+	if err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	fmt.Fprintf(app.Stdout, "exported %d chats to %s\n", exported, *output)
+	return nil
+}
+
+func runImport(ctx context.Context, app *App, args []string) error {
+	fs := app.flagSet("import")
+	input := fs.String("i", "chats.ndjson", "файл выгрузки, - для stdin")
+	if err := app.parse(fs, args); err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if *input != "-" {
+		file, err := os.Open(*input)
+		if err != nil {
+			return fmt.Errorf("failed to open import file: %w", err)
+		}
+		defer file.Close()
+		r = file
+	}
+
+	db, closeDB, err := app.openDB(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	admin := repository.NewAdmin(db.DB, logger.NewDatabaseLogger())
+
+	imported, err := importChats(r, func(chat *models.Chat) error {
+		return admin.ImportChat(ctx, chat)
+	})
+	fmt.Fprintf(app.Stdout, "imported %d chats\n", imported)
+	return err
+}
+
+// importChats - читает выгрузку построчно и передаёт каждый чат в save
+func importChats(r io.Reader, save func(chat *models.Chat) error) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxImportLine)
+
+	var imported, line int
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var chat models.Chat
+		if err := json.Unmarshal(scanner.Bytes(), &chat); err != nil {
+			return imported, fmt.Errorf("line %d: invalid chat: %w", line, err)
+		}
+		if chat.Title == "" {
+			return imported, fmt.Errorf("line %d: chat title is empty", line)
+		}
+
+		if err := save(&chat); err != nil {
+			return imported, fmt.Errorf("line %d: %w", line, err)
+		}
+		imported++
+	}
+	if err := scanner.Err(); err != nil {
+		return imported, fmt.Errorf("failed to read import file: %w", err)
+	}
+
+	return imported, nil
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"chat-api/logger"
+	"chat-api/repository"
+)
+
+func runUsers(ctx context.Context, app *App, args []string) error {
+	if len(args) == 0 || args[0] != "create" {
+		fmt.Fprintln(app.Stderr, "Usage: chat-api users create -name NAME")
+		return ErrUsage
+	}
+
+	fs := app.flagSet("users create")
+	name := fs.String("name", "", "имя пользователя (до 100 символов)")
+	if err := app.parse(fs, args[1:]); err != nil {
+		return err
+	}
+
+	*name = strings.TrimSpace(*name)
+	if *name == "" || len(*name) > 100 {
+		fmt.Fprintln(app.Stderr, "users create: -name is required and must not exceed 100 characters")
+		return ErrUsage
+	}
+
+	db, closeDB, err := app.openDB(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	user, apiKey, err := repository.NewAdmin(db.DB, logger.NewDatabaseLogger()).CreateUser(ctx, *name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(app.Stdout, "user:    %d %s\napi key: %s\n", user.ID, user.Name, apiKey)
+	fmt.Fprintln(app.Stderr, "The API key is shown only once, store it now.")
+	return nil
+}
@@ -0,0 +1,265 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"chat-api/models"
+)
+
+const (
+	defaultTimeout    = 30 * time.Second
+	defaultMaxRetries = 3
+	defaultBackoff    = 100 * time.Millisecond
+	defaultMaxBackoff = 2 * time.Second
+)
+
+// Client - типизированный клиент Chat API
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	maxRetries int
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+type Option func(*Client)
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetries - количество повторов запроса после ответа 5xx или сетевой ошибки.
+// POST повторяется с тем же Idempotency-Key, поэтому сервер не создаст дубликат
+func WithRetries(maxRetries int) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+	}
+}
+
+// WithBackoff - начальная и максимальная задержка экспоненциального backoff между повторами
+func WithBackoff(base, max time.Duration) Option {
+	return func(c *Client) {
+		c.backoff = base
+		c.maxBackoff = max
+	}
+}
+
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		maxRetries: defaultMaxRetries,
+		backoff:    defaultBackoff,
+		maxBackoff: defaultMaxBackoff,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+func (c *Client) CreateChat(ctx context.Context, title string) (*models.Chat, error) {
+	var chat models.Chat
+	if err := c.do(ctx, http.MethodPost, "/chats", nil, models.CreateChatRequest{Title: title}, &chat); err != nil {
+		return nil, err
+	}
+	return &chat, nil
+}
+
+func (c *Client) GetChat(ctx context.Context, id uint, limit int) (*models.Chat, error) {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var chat models.Chat
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/chats/%d", id), query, nil, &chat); err != nil {
+		return nil, err
+	}
+	return &chat, nil
+}
+
+func (c *Client) DeleteChat(ctx context.Context, id uint) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/chats/%d", id), nil, nil, nil)
+}
+
+func (c *Client) SendMessage(ctx context.Context, chatID uint, text string) (*models.Message, error) {
+	var message models.Message
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/chats/%d/messages", chatID), nil, models.CreateMessageRequest{Text: text}, &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// ListMessages - одна страница сообщений чата старше before (0 - с самого нового)
+func (c *Client) ListMessages(ctx context.Context, chatID uint, before uint, limit int) ([]models.Message, error) {
+	query := url.Values{}
+	if before > 0 {
+		query.Set("before", strconv.FormatUint(uint64(before), 10))
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var messages []models.Message
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/chats/%d/messages", chatID), query, nil, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// SetSlowMode - медленный режим чата; interval - целое число секунд, 0 - выключить
+func (c *Client) SetSlowMode(ctx context.Context, chatID uint, interval time.Duration) error {
+	if interval%time.Second != 0 {
+		return fmt.Errorf("slow mode interval must be a whole number of seconds, got %s", interval)
+	}
+	seconds := int(interval / time.Second)
+	return c.do(ctx, http.MethodPut, fmt.Sprintf("/chats/%d/slow-mode", chatID), nil, models.SlowModeRequest{Seconds: &seconds}, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	endpoint := c.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	// один ключ на все попытки: повтор POST после обрыва связи не создаст дубликат
+	var idempotencyKey string
+	if method == http.MethodPost {
+		idempotencyKey = newIdempotencyKey()
+	}
+
+	// без ключа повторяются только идемпотентные методы
+	retries := c.maxRetries
+	if !idempotent(method) && idempotencyKey == "" {
+		retries = 0
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			if err := c.sleep(ctx, attempt); err != nil {
+				return err
+			}
+		}
+
+		resp, err := c.send(ctx, method, endpoint, payload, idempotencyKey)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			lastErr = err
+			continue
+		}
+
+		if resp.StatusCode >= http.StatusInternalServerError {
+			lastErr = decodeError(resp)
+			continue
+		}
+
+		if resp.StatusCode >= http.StatusBadRequest {
+			return decodeError(resp)
+		}
+
+		return decodeResponse(resp, out)
+	}
+
+	return lastErr
+}
+
+func (c *Client) send(ctx context.Context, method, endpoint string, payload []byte, idempotencyKey string) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", method, endpoint, err)
+	}
+	return resp, nil
+}
+
+func newIdempotencyKey() string {
+	return fmt.Sprintf("%016x%016x", rand.Uint64(), rand.Uint64())
+}
+
+// sleep - экспоненциальная задержка со случайным разбросом перед очередной попыткой
+func (c *Client) sleep(ctx context.Context, attempt int) error {
+	delay := c.backoff << (attempt - 1)
+	if delay <= 0 || delay > c.maxBackoff {
+		delay = c.maxBackoff
+	}
+	if delay > 0 {
+		delay = rand.N(delay) + delay/2
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// idempotent - методы, повтор которых не меняет результат
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func decodeResponse(resp *http.Response, out any) error {
+	defer resp.Body.Close()
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
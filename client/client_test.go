@@ -0,0 +1,254 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"chat-api/handlers"
+	"chat-api/ratelimit"
+	"chat-api/repository"
+	"chat-api/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ handlers.ChatService = (*Client)(nil)
+
+type nopLogger struct{}
+
+func (nopLogger) Log(ctx context.Context, method, path, remoteAddr string, statusCode int, durationMs float64) {
+}
+
+func (nopLogger) LogPanic(ctx context.Context, method, route string, recovered any, stack []byte) {}
+
+func newTestServer(t *testing.T, wrap func(http.Handler) http.Handler) *httptest.Server {
+	t.Helper()
+
+	var handler http.Handler = handlers.New(service.NewChatService(repository.NewMemoryRepository(nil)), nopLogger{})
+	if wrap != nil {
+		handler = wrap(handler)
+	}
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestClient_ChatLifecycle - тест полного цикла работы с чатом через клиент
+func TestClient_ChatLifecycle(t *testing.T) {
+	server := newTestServer(t, nil)
+	c := New(server.URL)
+	ctx := context.Background()
+
+	chat, err := c.CreateChat(ctx, "  SDK chat  ")
+	require.NoError(t, err)
+	assert.NotZero(t, chat.ID)
+	assert.Equal(t, "SDK chat", chat.Title)
+
+	message, err := c.SendMessage(ctx, chat.ID, "hello")
+	require.NoError(t, err)
+	assert.Equal(t, chat.ID, message.ChatID)
+	assert.Equal(t, "hello", message.Text)
+
+	got, err := c.GetChat(ctx, chat.ID, 10)
+	require.NoError(t, err)
+	assert.Equal(t, "SDK chat", got.Title)
+	require.Len(t, got.Messages, 1)
+	assert.Equal(t, "hello", got.Messages[0].Text)
+
+	require.NoError(t, c.DeleteChat(ctx, chat.ID))
+
+	_, err = c.GetChat(ctx, chat.ID, 10)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestClient_TypedErrors - тест декодирования ошибок сервера в типизированные ошибки
+func TestClient_TypedErrors(t *testing.T) {
+	server := newTestServer(t, nil)
+	c := New(server.URL)
+
+	_, err := c.CreateChat(context.Background(), "   ")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.Contains(t, apiErr.Message, "chat title cannot be empty")
+
+	// ошибка проверки запроса - 400, а не 404
+	chat, err := c.CreateChat(context.Background(), "limits")
+	require.NoError(t, err)
+	_, err = c.ListMessages(context.Background(), chat.ID, 0, 500)
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = c.GetChat(context.Background(), chat.ID, 500)
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+// TestClient_SlowMode - тест медленного режима: второе сообщение участника отклоняется с 429
+func TestClient_SlowMode(t *testing.T) {
+	server := newTestServer(t, func(next http.Handler) http.Handler {
+		return ratelimit.NewIdentifier(nil, false).Identify()(next.ServeHTTP)
+	})
+	c := New(server.URL)
+	ctx := context.Background()
+
+	chat, err := c.CreateChat(ctx, "slow")
+	require.NoError(t, err)
+	require.NoError(t, c.SetSlowMode(ctx, chat.ID, 30*time.Second))
+
+	got, err := c.GetChat(ctx, chat.ID, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 30, got.SlowModeSeconds)
+
+	_, err = c.SendMessage(ctx, chat.ID, "first")
+	require.NoError(t, err)
+
+	_, err = c.SendMessage(ctx, chat.ID, "second")
+	assert.ErrorIs(t, err, ErrRateLimited)
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, 30*time.Second, apiErr.RetryAfter)
+
+	assert.ErrorIs(t, c.SetSlowMode(ctx, 404, time.Second), ErrNotFound)
+	assert.ErrorIs(t, c.SetSlowMode(ctx, chat.ID, 7*time.Hour), ErrBadRequest)
+	assert.Error(t, c.SetSlowMode(ctx, chat.ID, 1500*time.Millisecond))
+}
+
+// TestClient_RetriesOn5xx - тест повторов с backoff при ответах 5xx
+func TestClient_RetriesOn5xx(t *testing.T) {
+	var calls atomic.Int32
+	keys := make(chan string, 3)
+	server := newTestServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			keys <- r.Header.Get("Idempotency-Key")
+			if calls.Add(1) <= 2 {
+				http.Error(w, "temporarily unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	c := New(server.URL, WithBackoff(time.Millisecond, 5*time.Millisecond))
+
+	chat, err := c.CreateChat(context.Background(), "retry")
+	require.NoError(t, err)
+	assert.Equal(t, "retry", chat.Title)
+	assert.Equal(t, int32(3), calls.Load())
+
+	// все попытки POST идут с одним ключом идемпотентности
+	first := <-keys
+	assert.NotEmpty(t, first)
+	assert.Equal(t, first, <-keys)
+	assert.Equal(t, first, <-keys)
+}
+
+// TestClient_RetriesExhausted - тест возврата последней ошибки после исчерпания повторов
+func TestClient_RetriesExhausted(t *testing.T) {
+	var calls atomic.Int32
+	server := newTestServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			http.Error(w, "boom", http.StatusInternalServerError)
+		})
+	})
+
+	c := New(server.URL, WithRetries(2), WithBackoff(time.Millisecond, time.Millisecond))
+
+	err := c.DeleteChat(context.Background(), 1)
+	assert.ErrorIs(t, err, ErrServer)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+// TestClient_ContextCancel - тест прерывания ожидания повтора отменой контекста
+func TestClient_ContextCancel(t *testing.T) {
+	server := newTestServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "boom", http.StatusBadGateway)
+		})
+	})
+
+	c := New(server.URL, WithBackoff(time.Hour, time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := c.DeleteChat(ctx, 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// TestClient_MessagesIterator - тест итератора по всем страницам сообщений
+func TestClient_MessagesIterator(t *testing.T) {
+	server := newTestServer(t, nil)
+	c := New(server.URL)
+	ctx := context.Background()
+
+	chat, err := c.CreateChat(ctx, "paged")
+	require.NoError(t, err)
+
+	for i := 0; i < 7; i++ {
+		_, err := c.SendMessage(ctx, chat.ID, fmt.Sprintf("message %d", i))
+		require.NoError(t, err)
+	}
+
+	var texts []string
+	for msg, err := range c.Messages(ctx, chat.ID, 3) {
+		require.NoError(t, err)
+		texts = append(texts, msg.Text)
+	}
+
+	require.Len(t, texts, 7)
+	assert.Equal(t, "message 6", texts[0])
+	assert.Equal(t, "message 0", texts[6])
+
+	var first []string
+	for msg, err := range c.Messages(ctx, chat.ID, 3) {
+		require.NoError(t, err)
+		first = append(first, msg.Text)
+		if len(first) == 4 {
+			break
+		}
+	}
+	assert.Len(t, first, 4)
+}
+
+// TestClient_MessagesIteratorError - тест передачи ошибки через итератор
+func TestClient_MessagesIteratorError(t *testing.T) {
+	server := newTestServer(t, nil)
+	c := New(server.URL)
+
+	var errs int
+	for _, err := range c.Messages(context.Background(), 42, 10) {
+		assert.ErrorIs(t, err, ErrNotFound)
+		errs++
+	}
+	assert.Equal(t, 1, errs)
+}
+
+// TestClient_ProblemErrors - тест декодирования ответа application/problem+json
+func TestClient_ProblemErrors(t *testing.T) {
+	server := newTestServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","request_id":"req-1"}`))
+		})
+	})
+
+	c := New(server.URL, WithRetries(0))
+
+	err := c.DeleteChat(context.Background(), 1)
+
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "internal server error", apiErr.Message)
+	assert.Equal(t, "req-1", apiErr.RequestID)
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxErrorBody = 64 << 10
+
+var (
+	ErrBadRequest       = errors.New("bad request")
+	ErrNotFound         = errors.New("not found")
+	ErrMethodNotAllowed = errors.New("method not allowed")
+	ErrRateLimited      = errors.New("rate limited")
+	ErrServer           = errors.New("server error")
+)
+
+// APIError - ошибка, возвращённая сервером в ответе
+type APIError struct {
+	StatusCode int
+	Message    string
+	// RequestID - идентификатор запроса из ответа сервера, если он был передан
+	RequestID string
+	// RetryAfter - через сколько можно повторить запрос после ответа 429
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("chat api: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("chat api: %d %s", e.StatusCode, e.Message)
+}
+
+// Is - позволяет проверять класс ошибки через errors.Is(err, client.ErrNotFound)
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrMethodNotAllowed:
+		return e.StatusCode == http.StatusMethodNotAllowed
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServer:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+	return false
+}
+
+func decodeError(resp *http.Response) error {
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    strings.TrimSpace(string(body)),
+		RequestID:  resp.Header.Get("X-Request-ID"),
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/problem+json") {
+		var problem problemBody
+		if err := json.Unmarshal(body, &problem); err == nil {
+			apiErr.Message = problem.Detail
+			if apiErr.Message == "" {
+				apiErr.Message = problem.Title
+			}
+			if problem.RequestID != "" {
+				apiErr.RequestID = problem.RequestID
+			}
+		}
+	}
+
+	return apiErr
+}
+
+// problemBody - ответ об ошибке в формате RFC 7807
+type problemBody struct {
+	Title     string `json:"title"`
+	Detail    string `json:"detail"`
+	RequestID string `json:"request_id"`
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"iter"
+
+	"chat-api/models"
+)
+
+// Messages - итератор по всем сообщениям чата от новых к старым, страницами по pageSize
+//
+//	for msg, err := range c.Messages(ctx, chatID, 50) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func (c *Client) Messages(ctx context.Context, chatID uint, pageSize int) iter.Seq2[models.Message, error] {
+	return func(yield func(models.Message, error) bool) {
+		var before uint
+		for {
+			page, err := c.ListMessages(ctx, chatID, before, pageSize)
+			if err != nil {
+				yield(models.Message{}, err)
+				return
+			}
+
+			for _, msg := range page {
+				if !yield(msg, nil) {
+					return
+				}
+			}
+
+			if len(page) == 0 || (pageSize > 0 && len(page) < pageSize) {
+				return
+			}
+			before = page[len(page)-1].ID
+		}
+	}
+}
@@ -0,0 +1,459 @@
+// Package config - настройки приложения из файла (YAML/TOML), переменных окружения и флагов
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Config - все настройки приложения.
+// Теги: yaml/toml - ключ в файле (и имя флага section.key), env - переменная окружения,
+// secret - значение скрывается при выводе
+type Config struct {
+	Server      Server      `yaml:"server" toml:"server"`
+	Storage     Storage     `yaml:"storage" toml:"storage"`
+	Database    Database    `yaml:"database" toml:"database"`
+	Outbox      Outbox      `yaml:"outbox" toml:"outbox"`
+	Idempotency Idempotency `yaml:"idempotency" toml:"idempotency"`
+	RateLimit   RateLimit   `yaml:"rate_limit" toml:"rate_limit"`
+	Webhooks    Webhooks    `yaml:"webhooks" toml:"webhooks"`
+	Incoming    Incoming    `yaml:"incoming_webhooks" toml:"incoming_webhooks"`
+	Bots        Bots        `yaml:"bots" toml:"bots"`
+	Assistant   Assistant   `yaml:"assistant" toml:"assistant"`
+	Log         Log         `yaml:"log" toml:"log"`
+	Tracing     Tracing     `yaml:"tracing" toml:"tracing"`
+}
+
+type Server struct {
+	Port              int           `yaml:"port" toml:"port" env:"PORT"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	ShutdownDelay     time.Duration `yaml:"shutdown_delay" toml:"shutdown_delay" env:"SHUTDOWN_DELAY"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout" env:"READ_HEADER_TIMEOUT"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"IDLE_TIMEOUT"`
+}
+
+// Хранилища чатов
+const (
+	BackendPostgres = "postgres"
+	// BackendSQLite - файл SQLite по database.url вида sqlite://path; для небольших установок без PostgreSQL
+	BackendSQLite = "sqlite"
+	// BackendMemory - в памяти процесса, без зависимостей; данные теряются при перезапуске
+	BackendMemory = "memory"
+)
+
+type Storage struct {
+	Backend string `yaml:"backend" toml:"backend" env:"STORAGE_BACKEND"`
+}
+
+// sqliteScheme - схема database.url для SQLite, см. database.IsSQLite
+const sqliteScheme = "sqlite://"
+
+type Database struct {
+	// URL - DSN целиком (postgres://... или sqlite://path), заменяет host, port, user, password, name и sslmode
+	URL            string `yaml:"url,omitempty" toml:"url,omitempty" env:"DATABASE_URL" secret:"true"`
+	Host           string `yaml:"host" toml:"host" env:"DB_HOST"`
+	Port           int    `yaml:"port" toml:"port" env:"DB_PORT"`
+	User           string `yaml:"user" toml:"user" env:"DB_USER"`
+	Password       string `yaml:"password" toml:"password" env:"DB_PASSWORD" secret:"true"`
+	Name           string `yaml:"name" toml:"name" env:"DB_NAME"`
+	SSLMode        string `yaml:"sslmode" toml:"sslmode" env:"DB_SSLMODE"`
+	MigrateOnStart bool   `yaml:"migrate_on_start" toml:"migrate_on_start" env:"MIGRATE_ON_START"`
+
+	MaxOpenConns    int           `yaml:"max_open_conns" toml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" toml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+
+	StatementTimeout time.Duration `yaml:"statement_timeout" toml:"statement_timeout" env:"DB_STATEMENT_TIMEOUT"`
+	ConnectTimeout   time.Duration `yaml:"connect_timeout" toml:"connect_timeout" env:"DB_CONNECT_TIMEOUT"`
+	ConnectRetries   int           `yaml:"connect_retries" toml:"connect_retries" env:"DB_CONNECT_RETRIES"`
+	ConnectBackoff   time.Duration `yaml:"connect_backoff" toml:"connect_backoff" env:"DB_CONNECT_BACKOFF"`
+
+	// Replicas - DSN реплик для чтения истории чатов; содержат пароли, поэтому скрываются
+	Replicas             []string      `yaml:"replicas,omitempty" toml:"replicas,omitempty" env:"DB_REPLICAS" secret:"true"`
+	ReplicaCheckInterval time.Duration `yaml:"replica_check_interval" toml:"replica_check_interval" env:"DB_REPLICA_CHECK_INTERVAL"`
+	// ReadYourWritesWindow - сколько после записи в чат читать его из основной базы, 0 - всегда из реплик.
+	// Записи учитываются в памяти процесса: другой экземпляр о них не знает и читает из реплик,
+	// поэтому при нескольких экземплярах гарантия есть только со sticky-сессиями на балансировщике
+	ReadYourWritesWindow time.Duration `yaml:"read_your_writes_window" toml:"read_your_writes_window" env:"DB_READ_YOUR_WRITES_WINDOW"`
+
+	// LogLevel - уровень логов GORM: silent, error, warn или info (каждый SQL запрос)
+	LogLevel           string        `yaml:"log_level" toml:"log_level" env:"DB_LOG_LEVEL"`
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold" toml:"slow_query_threshold" env:"DB_SLOW_QUERY_THRESHOLD"`
+}
+
+// Приёмники событий outbox
+const (
+	SinkBus     = "bus"
+	SinkWebhook = "webhook"
+	SinkFile    = "file"
+)
+
+// Провайдеры ассистента
+const (
+	ProviderFake   = "fake"
+	ProviderOpenAI = "openai"
+)
+
+type Outbox struct {
+	// Enabled - писать события изменений чатов в таблицу outbox и доставлять их в Sinks
+	Enabled bool     `yaml:"enabled" toml:"enabled" env:"OUTBOX_ENABLED"`
+	Sinks   []string `yaml:"sinks,omitempty" toml:"sinks,omitempty" env:"OUTBOX_SINKS"`
+
+	PollInterval time.Duration `yaml:"poll_interval" toml:"poll_interval" env:"OUTBOX_POLL_INTERVAL"`
+	BatchSize    int           `yaml:"batch_size" toml:"batch_size" env:"OUTBOX_BATCH_SIZE"`
+
+	// MaxAttempts - после стольких неудачных попыток событие переносится в outbox_dead_letters
+	MaxAttempts int `yaml:"max_attempts" toml:"max_attempts" env:"OUTBOX_MAX_ATTEMPTS"`
+	// Backoff - пауза перед первым повтором, каждая следующая вдвое больше, но не больше MaxBackoff
+	Backoff    time.Duration `yaml:"backoff" toml:"backoff" env:"OUTBOX_BACKOFF"`
+	MaxBackoff time.Duration `yaml:"max_backoff" toml:"max_backoff" env:"OUTBOX_MAX_BACKOFF"`
+
+	// WebhookURL - может содержать токен получателя, поэтому скрывается
+	WebhookURL     string        `yaml:"webhook_url" toml:"webhook_url" env:"OUTBOX_WEBHOOK_URL" secret:"true"`
+	WebhookTimeout time.Duration `yaml:"webhook_timeout" toml:"webhook_timeout" env:"OUTBOX_WEBHOOK_TIMEOUT"`
+	File           string        `yaml:"file" toml:"file" env:"OUTBOX_FILE"`
+}
+
+// Idempotency - повторы POST с тем же заголовком Idempotency-Key получают сохранённый первый ответ
+type Idempotency struct {
+	Enabled bool `yaml:"enabled" toml:"enabled" env:"IDEMPOTENCY_ENABLED"`
+	// TTL - сколько хранится ответ; повтор после этого выполняется как новый запрос
+	TTL time.Duration `yaml:"ttl" toml:"ttl" env:"IDEMPOTENCY_TTL"`
+	// Lease - сколько ключ занят выполняющимся запросом; должен быть больше самого долгого запроса.
+	// Если экземпляр упал, не ответив, повтор с этим ключом выполнится после Lease
+	Lease time.Duration `yaml:"lease" toml:"lease" env:"IDEMPOTENCY_LEASE"`
+}
+
+// RateLimit - token bucket на клиента (ключ API, иначе IP адрес) с отдельным бюджетом для классов
+// маршрутов: чтение, отправка сообщений и прочие изменения. Бюджеты в запросах в минуту, 0 - без ограничения
+type RateLimit struct {
+	Enabled  bool `yaml:"enabled" toml:"enabled" env:"RATE_LIMIT_ENABLED"`
+	Read     int  `yaml:"read" toml:"read" env:"RATE_LIMIT_READ"`
+	Write    int  `yaml:"write" toml:"write" env:"RATE_LIMIT_WRITE"`
+	Messages int  `yaml:"messages" toml:"messages" env:"RATE_LIMIT_MESSAGES"`
+	// TrustForwardedFor - брать адрес клиента из X-Forwarded-For (последний, добавленный прокси); только за доверенным прокси
+	TrustForwardedFor bool `yaml:"trust_forwarded_for" toml:"trust_forwarded_for" env:"RATE_LIMIT_TRUST_FORWARDED_FOR"`
+}
+
+// Webhooks - исходящие вебхуки по подпискам из API; события берутся из outbox
+type Webhooks struct {
+	Enabled bool `yaml:"enabled" toml:"enabled" env:"WEBHOOKS_ENABLED"`
+
+	Timeout time.Duration `yaml:"timeout" toml:"timeout" env:"WEBHOOKS_TIMEOUT"`
+	// MaxAttempts - после стольких неудачных попыток доставка попадает в мёртвые
+	MaxAttempts int `yaml:"max_attempts" toml:"max_attempts" env:"WEBHOOKS_MAX_ATTEMPTS"`
+	// Backoff - пауза перед первым повтором, каждая следующая вдвое больше, но не больше MaxBackoff
+	Backoff    time.Duration `yaml:"backoff" toml:"backoff" env:"WEBHOOKS_BACKOFF"`
+	MaxBackoff time.Duration `yaml:"max_backoff" toml:"max_backoff" env:"WEBHOOKS_MAX_BACKOFF"`
+	// Retention - сколько хранить успешные доставки; 0 - хранить всегда
+	Retention time.Duration `yaml:"retention" toml:"retention" env:"WEBHOOKS_RETENTION"`
+
+	PollInterval time.Duration `yaml:"poll_interval" toml:"poll_interval" env:"WEBHOOKS_POLL_INTERVAL"`
+	BatchSize    int           `yaml:"batch_size" toml:"batch_size" env:"WEBHOOKS_BATCH_SIZE"`
+}
+
+// Incoming - входящие вебхуки: внешние системы пишут в чаты по адресу с токеном
+type Incoming struct {
+	Enabled bool `yaml:"enabled" toml:"enabled" env:"INCOMING_WEBHOOKS_ENABLED"`
+	// RateLimit - сообщений в минуту на вебхук, если у него нет собственного лимита
+	RateLimit int `yaml:"rate_limit" toml:"rate_limit" env:"INCOMING_WEBHOOKS_RATE_LIMIT"`
+}
+
+// Bots - слэш-команды в сообщениях: встроенные /remind и /poll и команды ботов из API
+type Bots struct {
+	Enabled bool `yaml:"enabled" toml:"enabled" env:"BOTS_ENABLED"`
+	// Timeout - сколько ждать ответа бота на команду; запрос отправителя ждёт столько же
+	Timeout time.Duration `yaml:"timeout" toml:"timeout" env:"BOTS_TIMEOUT"`
+	// AllowPrivateURLs - разрешить ботов на loopback, link-local и частных адресах, например для локальной разработки
+	AllowPrivateURLs bool `yaml:"allow_private_urls" toml:"allow_private_urls" env:"BOTS_ALLOW_PRIVATE_URLS"`
+}
+
+// Assistant - ответы ИИ-ассистента на сообщения с упоминанием @name в чатах, где он включён
+type Assistant struct {
+	Enabled bool `yaml:"enabled" toml:"enabled" env:"ASSISTANT_ENABLED"`
+	// Provider - fake (детерминированные ответы без сети) или openai (любой OpenAI-совместимый API)
+	Provider string `yaml:"provider" toml:"provider" env:"ASSISTANT_PROVIDER"`
+	Endpoint string `yaml:"endpoint" toml:"endpoint" env:"ASSISTANT_ENDPOINT"`
+	APIKey   string `yaml:"api_key" toml:"api_key" env:"ASSISTANT_API_KEY" secret:"true"`
+	Model    string `yaml:"model" toml:"model" env:"ASSISTANT_MODEL"`
+
+	// Name - имя для упоминания и подписи ответов
+	Name string `yaml:"name" toml:"name" env:"ASSISTANT_NAME"`
+	// HistoryLimit - сколько последних сообщений чата видит модель
+	HistoryLimit int `yaml:"history_limit" toml:"history_limit" env:"ASSISTANT_HISTORY_LIMIT"`
+	// Timeout - на один ответ целиком
+	Timeout time.Duration `yaml:"timeout" toml:"timeout" env:"ASSISTANT_TIMEOUT"`
+	// Budget - сколько ответов в одном чате допускается за BudgetWindow
+	Budget       int           `yaml:"budget" toml:"budget" env:"ASSISTANT_BUDGET"`
+	BudgetWindow time.Duration `yaml:"budget_window" toml:"budget_window" env:"ASSISTANT_BUDGET_WINDOW"`
+}
+
+type Log struct {
+	ToFile bool   `yaml:"to_file" toml:"to_file" env:"LOG_TO_FILE"`
+	Dir    string `yaml:"dir" toml:"dir" env:"LOG_DIR"`
+}
+
+type Tracing struct {
+	Exporter    string  `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER"`
+	ServiceName string  `yaml:"service_name" toml:"service_name" env:"OTEL_SERVICE_NAME"`
+	File        string  `yaml:"file" toml:"file" env:"TRACING_FILE"`
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+// Default - значения по умолчанию. Пароля к базе по умолчанию нет: его нужно задать явно
+func Default() *Config {
+	return &Config{
+		Server: Server{
+			Port:              8080,
+			ShutdownTimeout:   30 * time.Second,
+			ReadHeaderTimeout: 10 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+		},
+		Storage: Storage{
+			Backend: BackendPostgres,
+		},
+		Database: Database{
+			Host:           "localhost",
+			Port:           5432,
+			User:           "postgres",
+			Name:           "chat_api",
+			SSLMode:        "disable",
+			MigrateOnStart: true,
+
+			MaxOpenConns:    100,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: time.Hour,
+			ConnMaxIdleTime: 10 * time.Minute,
+
+			StatementTimeout: 30 * time.Second,
+			ConnectTimeout:   5 * time.Second,
+			ConnectRetries:   5,
+			ConnectBackoff:   time.Second,
+
+			ReplicaCheckInterval: 5 * time.Second,
+			ReadYourWritesWindow: 5 * time.Second,
+
+			LogLevel:           "warn",
+			SlowQueryThreshold: 200 * time.Millisecond,
+		},
+		Outbox: Outbox{
+			PollInterval:   time.Second,
+			BatchSize:      100,
+			MaxAttempts:    20,
+			Backoff:        time.Second,
+			MaxBackoff:     10 * time.Minute,
+			WebhookTimeout: 5 * time.Second,
+			File:           "logs/events.ndjson",
+		},
+		Webhooks: Webhooks{
+			Timeout:      10 * time.Second,
+			MaxAttempts:  8,
+			Backoff:      10 * time.Second,
+			MaxBackoff:   time.Hour,
+			Retention:    7 * 24 * time.Hour,
+			PollInterval: time.Second,
+			BatchSize:    50,
+		},
+		Idempotency: Idempotency{
+			Enabled: true,
+			TTL:     24 * time.Hour,
+			Lease:   time.Minute,
+		},
+		RateLimit: RateLimit{
+			Enabled:  true,
+			Read:     600,
+			Write:    120,
+			Messages: 60,
+		},
+		Incoming: Incoming{
+			RateLimit: 60,
+		},
+		Bots: Bots{
+			Timeout: 5 * time.Second,
+		},
+		Assistant: Assistant{
+			Provider:     ProviderFake,
+			Endpoint:     "https://api.openai.com/v1",
+			Model:        "gpt-4o-mini",
+			Name:         "assistant",
+			HistoryLimit: 20,
+			Timeout:      30 * time.Second,
+			Budget:       20,
+			BudgetWindow: time.Hour,
+		},
+		Log: Log{
+			ToFile: true,
+			Dir:    "logs",
+		},
+		Tracing: Tracing{
+			Exporter:    "none",
+			ServiceName: "chat-api",
+			File:        "logs/traces.json",
+			SampleRatio: 1,
+		},
+	}
+}
+
+var (
+	backends  = []string{BackendPostgres, BackendSQLite, BackendMemory}
+	sslModes  = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	exporters = []string{"none", "otlp", "stdout", "file"}
+	logLevels = []string{"silent", "error", "warn", "info"}
+	sinks     = []string{SinkBus, SinkWebhook, SinkFile}
+	providers = []string{ProviderFake, ProviderOpenAI}
+)
+
+// Validate - проверяет все настройки и возвращает все найденные ошибки сразу
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(validPort(c.Server.Port), "server.port", "must be between 1 and 65535, got %d", c.Server.Port)
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout", "must be positive, got %s", c.Server.ShutdownTimeout)
+	check(c.Server.ShutdownDelay >= 0, "server.shutdown_delay", "must not be negative, got %s", c.Server.ShutdownDelay)
+	check(c.Server.ReadHeaderTimeout > 0, "server.read_header_timeout", "must be positive, got %s", c.Server.ReadHeaderTimeout)
+	check(c.Server.IdleTimeout > 0, "server.idle_timeout", "must be positive, got %s", c.Server.IdleTimeout)
+
+	check(slices.Contains(backends, c.Storage.Backend), "storage.backend", "must be one of %s, got %q", strings.Join(backends, ", "), c.Storage.Backend)
+	if c.Storage.Backend == BackendPostgres || c.Storage.Backend == BackendSQLite {
+		c.validateDatabase(check)
+	}
+
+	if c.Outbox.Enabled {
+		c.validateOutbox(check)
+	}
+	if c.Idempotency.Enabled {
+		check(c.Idempotency.TTL > 0, "idempotency.ttl", "must be positive, got %s", c.Idempotency.TTL)
+		check(c.Idempotency.Lease > 0 && c.Idempotency.Lease <= c.Idempotency.TTL, "idempotency.lease",
+			"must be positive and not exceed idempotency.ttl, got %s", c.Idempotency.Lease)
+	}
+	if c.RateLimit.Enabled {
+		check(c.RateLimit.Read >= 0, "rate_limit.read", "must not be negative, got %d", c.RateLimit.Read)
+		check(c.RateLimit.Write >= 0, "rate_limit.write", "must not be negative, got %d", c.RateLimit.Write)
+		check(c.RateLimit.Messages >= 0, "rate_limit.messages", "must not be negative, got %d", c.RateLimit.Messages)
+	}
+	if c.Webhooks.Enabled {
+		c.validateWebhooks(check)
+	}
+	if c.Incoming.Enabled {
+		check(c.Storage.Backend != BackendMemory, "incoming_webhooks.enabled", "requires storage.backend %s or %s", BackendPostgres, BackendSQLite)
+		check(c.Incoming.RateLimit > 0, "incoming_webhooks.rate_limit", "must be positive, got %d", c.Incoming.RateLimit)
+	}
+	if c.Bots.Enabled {
+		check(c.Storage.Backend != BackendMemory, "bots.enabled", "requires storage.backend %s or %s", BackendPostgres, BackendSQLite)
+		check(c.Bots.Timeout > 0, "bots.timeout", "must be positive, got %s", c.Bots.Timeout)
+	}
+
+	if c.Assistant.Enabled {
+		c.validateAssistant(check)
+	}
+
+	check(!c.Log.ToFile || c.Log.Dir != "", "log.dir", "is required when log.to_file is enabled")
+
+	check(slices.Contains(exporters, c.Tracing.Exporter), "tracing.exporter", "must be one of %s, got %q", strings.Join(exporters, ", "), c.Tracing.Exporter)
+	check(c.Tracing.ServiceName != "", "tracing.service_name", "is required")
+	check(c.Tracing.Exporter != "file" || c.Tracing.File != "", "tracing.file", "is required for the file exporter")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio", "must be between 0 and 1, got %g", c.Tracing.SampleRatio)
+
+	return errors.Join(errs...)
+}
+
+// validateDatabase - настройки базы проверяются, только если она используется
+func (c *Config) validateDatabase(check func(ok bool, key, format string, args ...any)) {
+	isSQLite := strings.HasPrefix(c.Database.URL, sqliteScheme)
+
+	switch {
+	case c.Storage.Backend == BackendSQLite:
+		check(isSQLite && len(c.Database.URL) > len(sqliteScheme), "database.url", "must be sqlite://path for the sqlite backend (DATABASE_URL)")
+		check(len(c.Database.Replicas) == 0, "database.replicas", "are supported only for postgres")
+	case isSQLite:
+		check(false, "database.url", "sqlite:// requires storage.backend %q", BackendSQLite)
+	case c.Database.URL == "":
+		check(c.Database.Host != "", "database.host", "is required (DB_HOST)")
+		check(validPort(c.Database.Port), "database.port", "must be between 1 and 65535, got %d", c.Database.Port)
+		check(c.Database.User != "", "database.user", "is required (DB_USER)")
+		check(c.Database.Password != "", "database.password", "is required (DB_PASSWORD)")
+		check(c.Database.Name != "", "database.name", "is required (DB_NAME)")
+		check(slices.Contains(sslModes, c.Database.SSLMode), "database.sslmode", "must be one of %s, got %q", strings.Join(sslModes, ", "), c.Database.SSLMode)
+	}
+	check(c.Database.MaxOpenConns > 0, "database.max_open_conns", "must be positive, got %d", c.Database.MaxOpenConns)
+	check(c.Database.MaxIdleConns >= 0 && c.Database.MaxIdleConns <= c.Database.MaxOpenConns, "database.max_idle_conns",
+		"must be between 0 and max_open_conns (%d), got %d", c.Database.MaxOpenConns, c.Database.MaxIdleConns)
+	check(c.Database.ConnMaxLifetime >= 0, "database.conn_max_lifetime", "must not be negative, got %s", c.Database.ConnMaxLifetime)
+	check(c.Database.ConnMaxIdleTime >= 0, "database.conn_max_idle_time", "must not be negative, got %s", c.Database.ConnMaxIdleTime)
+	check(c.Database.StatementTimeout >= 0, "database.statement_timeout", "must not be negative, got %s", c.Database.StatementTimeout)
+	check(c.Database.ConnectTimeout == 0 || c.Database.ConnectTimeout >= time.Second, "database.connect_timeout",
+		"must be 0 or at least 1s, got %s", c.Database.ConnectTimeout)
+	check(c.Database.ConnectRetries >= 0, "database.connect_retries", "must not be negative, got %d", c.Database.ConnectRetries)
+	check(c.Database.ConnectRetries == 0 || c.Database.ConnectBackoff > 0, "database.connect_backoff",
+		"must be positive when connect_retries is set, got %s", c.Database.ConnectBackoff)
+	check(len(c.Database.Replicas) == 0 || c.Database.ReplicaCheckInterval > 0, "database.replica_check_interval",
+		"must be positive when replicas are set, got %s", c.Database.ReplicaCheckInterval)
+	check(c.Database.ReadYourWritesWindow >= 0, "database.read_your_writes_window", "must not be negative, got %s", c.Database.ReadYourWritesWindow)
+	check(slices.Contains(logLevels, c.Database.LogLevel), "database.log_level", "must be one of %s, got %q", strings.Join(logLevels, ", "), c.Database.LogLevel)
+	check(c.Database.SlowQueryThreshold >= 0, "database.slow_query_threshold", "must not be negative, got %s", c.Database.SlowQueryThreshold)
+}
+
+func (c *Config) validateOutbox(check func(ok bool, key, format string, args ...any)) {
+	check(c.Storage.Backend != BackendMemory, "outbox.enabled", "requires storage.backend %s or %s", BackendPostgres, BackendSQLite)
+	// с вебхуками outbox нужен и без своих приёмников
+	check(len(c.Outbox.Sinks) > 0 || c.Webhooks.Enabled, "outbox.sinks", "at least one of %s is required", strings.Join(sinks, ", "))
+	for _, sink := range c.Outbox.Sinks {
+		check(slices.Contains(sinks, sink), "outbox.sinks", "must be one of %s, got %q", strings.Join(sinks, ", "), sink)
+	}
+	if slices.Contains(c.Outbox.Sinks, SinkWebhook) {
+		check(strings.HasPrefix(c.Outbox.WebhookURL, "http://") || strings.HasPrefix(c.Outbox.WebhookURL, "https://"),
+			"outbox.webhook_url", "must be an http(s) URL for the webhook sink")
+		check(c.Outbox.WebhookTimeout > 0, "outbox.webhook_timeout", "must be positive, got %s", c.Outbox.WebhookTimeout)
+	}
+	check(!slices.Contains(c.Outbox.Sinks, SinkFile) || c.Outbox.File != "", "outbox.file", "is required for the file sink")
+	check(c.Outbox.PollInterval > 0, "outbox.poll_interval", "must be positive, got %s", c.Outbox.PollInterval)
+	check(c.Outbox.BatchSize > 0, "outbox.batch_size", "must be positive, got %d", c.Outbox.BatchSize)
+	check(c.Outbox.MaxAttempts > 0, "outbox.max_attempts", "must be positive, got %d", c.Outbox.MaxAttempts)
+	check(c.Outbox.Backoff > 0, "outbox.backoff", "must be positive, got %s", c.Outbox.Backoff)
+	check(c.Outbox.MaxBackoff >= c.Outbox.Backoff, "outbox.max_backoff", "must be at least backoff (%s), got %s", c.Outbox.Backoff, c.Outbox.MaxBackoff)
+}
+
+func (c *Config) validateWebhooks(check func(ok bool, key, format string, args ...any)) {
+	check(c.Outbox.Enabled, "webhooks.enabled", "requires outbox.enabled")
+	check(c.Webhooks.Timeout > 0, "webhooks.timeout", "must be positive, got %s", c.Webhooks.Timeout)
+	check(c.Webhooks.MaxAttempts > 0, "webhooks.max_attempts", "must be positive, got %d", c.Webhooks.MaxAttempts)
+	check(c.Webhooks.Backoff > 0, "webhooks.backoff", "must be positive, got %s", c.Webhooks.Backoff)
+	check(c.Webhooks.MaxBackoff >= c.Webhooks.Backoff, "webhooks.max_backoff", "must be at least backoff (%s), got %s", c.Webhooks.Backoff, c.Webhooks.MaxBackoff)
+	check(c.Webhooks.Retention >= 0, "webhooks.retention", "must not be negative, got %s", c.Webhooks.Retention)
+	check(c.Webhooks.PollInterval > 0, "webhooks.poll_interval", "must be positive, got %s", c.Webhooks.PollInterval)
+	check(c.Webhooks.BatchSize > 0, "webhooks.batch_size", "must be positive, got %d", c.Webhooks.BatchSize)
+}
+
+func (c *Config) validateAssistant(check func(ok bool, key, format string, args ...any)) {
+	check(slices.Contains(providers, c.Assistant.Provider), "assistant.provider", "must be one of %s, got %q", strings.Join(providers, ", "), c.Assistant.Provider)
+	if c.Assistant.Provider == ProviderOpenAI {
+		check(strings.HasPrefix(c.Assistant.Endpoint, "http://") || strings.HasPrefix(c.Assistant.Endpoint, "https://"),
+			"assistant.endpoint", "must be an http(s) URL, got %q", c.Assistant.Endpoint)
+		check(c.Assistant.Model != "", "assistant.model", "is required for the openai provider")
+	}
+	check(validMention(c.Assistant.Name), "assistant.name", "must be 1-32 letters, digits or underscores, got %q", c.Assistant.Name)
+	check(c.Assistant.HistoryLimit > 0, "assistant.history_limit", "must be positive, got %d", c.Assistant.HistoryLimit)
+	check(c.Assistant.Timeout > 0, "assistant.timeout", "must be positive, got %s", c.Assistant.Timeout)
+	check(c.Assistant.Budget > 0, "assistant.budget", "must be positive, got %d", c.Assistant.Budget)
+	check(c.Assistant.BudgetWindow > 0, "assistant.budget_window", "must be positive, got %s", c.Assistant.BudgetWindow)
+}
+
+// validMention - имя, которое можно упомянуть как @name
+func validMention(name string) bool {
+	return len(name) > 0 && len(name) <= 32 && !strings.ContainsFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_')
+	})
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
@@ -0,0 +1,315 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLoader(t *testing.T, env map[string]string, args ...string) *Loader {
+	t.Helper()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	loader := NewLoader(fs)
+	loader.LookupEnv = func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+
+	require.NoError(t, fs.Parse(args))
+	return loader
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// TestLoad_Precedence - значения по умолчанию < файл < окружение < флаги
+func TestLoad_Precedence(t *testing.T) {
+	file := writeFile(t, "chat.yaml", `
+server:
+  port: 8081
+  shutdown_timeout: 45s
+database:
+  host: file-host
+  user: file-user
+  password: file-pass
+log:
+  to_file: false
+`)
+
+	cfg, err := newLoader(t,
+		map[string]string{"DB_HOST": "env-host", "DB_USER": "env-user", "SHUTDOWN_TIMEOUT": "1m"},
+		"-config", file, "-database.host", "flag-host",
+	).Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, 8081, cfg.Server.Port)
+	assert.Equal(t, time.Minute, cfg.Server.ShutdownTimeout)
+	assert.Equal(t, "flag-host", cfg.Database.Host)
+	assert.Equal(t, "env-user", cfg.Database.User)
+	assert.Equal(t, "file-pass", cfg.Database.Password)
+	assert.Equal(t, "chat_api", cfg.Database.Name)
+	assert.False(t, cfg.Log.ToFile)
+	assert.Equal(t, 10*time.Second, cfg.Server.ReadHeaderTimeout)
+}
+
+// TestLoad_TOML - файл TOML, путь из CONFIG_FILE
+func TestLoad_TOML(t *testing.T) {
+	file := writeFile(t, "chat.toml", `
+[database]
+password = "toml-pass"
+sslmode = "require"
+
+[tracing]
+exporter = "stdout"
+sample_ratio = 0.25
+`)
+
+	cfg, err := newLoader(t, map[string]string{FileEnv: file}).Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, "toml-pass", cfg.Database.Password)
+	assert.Equal(t, "require", cfg.Database.SSLMode)
+	assert.Equal(t, "stdout", cfg.Tracing.Exporter)
+	assert.Equal(t, 0.25, cfg.Tracing.SampleRatio)
+}
+
+// TestLoad_UnknownKeys - опечатка в ключе файла не проходит молча
+func TestLoad_UnknownKeys(t *testing.T) {
+	yamlFile := writeFile(t, "chat.yaml", "database:\n  pasword: x\n")
+	_, err := newLoader(t, nil, "-config", yamlFile).Load()
+	assert.ErrorContains(t, err, "pasword")
+
+	tomlFile := writeFile(t, "chat.toml", "[database]\npasword = \"x\"\n")
+	_, err = newLoader(t, nil, "-config", tomlFile).Load()
+	assert.ErrorContains(t, err, "database.pasword")
+
+	_, err = newLoader(t, nil, "-config", writeFile(t, "chat.json", "{}")).Load()
+	assert.ErrorContains(t, err, "unsupported config file")
+}
+
+// TestLoad_InvalidEnv - ошибки разбора переменных окружения не подменяются значениями по умолчанию
+func TestLoad_InvalidEnv(t *testing.T) {
+	_, err := newLoader(t, map[string]string{
+		"DB_PASSWORD":      "x",
+		"DB_PORT":          "five",
+		"SHUTDOWN_TIMEOUT": "30",
+		"LOG_TO_FILE":      "maybe",
+	}).Load()
+
+	require.Error(t, err)
+	assert.ErrorContains(t, err, `DB_PORT: invalid integer "five"`)
+	assert.ErrorContains(t, err, `SHUTDOWN_TIMEOUT: invalid duration "30"`)
+	assert.ErrorContains(t, err, `LOG_TO_FILE: invalid boolean "maybe"`)
+}
+
+// TestLoad_BoolValues - on/off для совместимости с LOG_TO_FILE и булевы флаги без значения
+func TestLoad_BoolValues(t *testing.T) {
+	cfg, err := newLoader(t, map[string]string{"DB_PASSWORD": "x", "LOG_TO_FILE": "off", "MIGRATE_ON_START": "false"},
+		"-log.to_file").Load()
+	require.NoError(t, err)
+
+	assert.True(t, cfg.Log.ToFile)
+	assert.False(t, cfg.Database.MigrateOnStart)
+}
+
+// TestValidate - все ошибки перечисляются сразу
+func TestValidate(t *testing.T) {
+	cfg := Default()
+	require.ErrorContains(t, cfg.Validate(), "database.password: is required")
+
+	cfg.Database.Password = "x"
+	require.NoError(t, cfg.Validate())
+
+	cfg.Server.Port = 70000
+	cfg.Database.SSLMode = "sometimes"
+	cfg.Tracing.SampleRatio = 2
+	cfg.Tracing.Exporter = "jaeger"
+	cfg.Database.MaxIdleConns = cfg.Database.MaxOpenConns + 1
+	cfg.Database.LogLevel = "debug"
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "server.port")
+	assert.ErrorContains(t, err, "database.sslmode")
+	assert.ErrorContains(t, err, "tracing.sample_ratio")
+	assert.ErrorContains(t, err, "tracing.exporter")
+	assert.ErrorContains(t, err, "database.max_idle_conns")
+	assert.ErrorContains(t, err, "database.log_level")
+}
+
+// TestDump - секреты скрыты во всех форматах, исходный конфиг не меняется
+func TestDump(t *testing.T) {
+	cfg := Default()
+	cfg.Database.Password = "hunter2"
+
+	for _, format := range []string{"yaml", "toml", "env"} {
+		var buf bytes.Buffer
+		require.NoError(t, cfg.Dump(&buf, format), format)
+
+		assert.NotContains(t, buf.String(), "hunter2", format)
+		assert.Contains(t, buf.String(), redacted, format)
+	}
+
+	assert.NotContains(t, cfg.String(), "hunter2")
+	assert.Equal(t, "hunter2", cfg.Database.Password)
+
+	assert.Error(t, cfg.Dump(io.Discard, "xml"))
+}
+
+// TestDump_RoundTrip - выгруженный YAML читается обратно
+func TestDump_RoundTrip(t *testing.T) {
+	cfg := Default()
+	cfg.Database.Password = "x"
+	cfg.Server.ShutdownDelay = 5 * time.Second
+
+	var buf bytes.Buffer
+	require.NoError(t, cfg.Dump(&buf, "yaml"))
+
+	loaded, err := newLoader(t, nil, "-config", writeFile(t, "dump.yaml", buf.String())).Load()
+	require.NoError(t, err)
+
+	expected := Default()
+	expected.Database.Password = redacted
+	expected.Server.ShutdownDelay = 5 * time.Second
+	assert.Equal(t, expected, loaded)
+}
+
+// TestLoad_Replicas - список реплик из окружения и его скрытие при выводе
+func TestLoad_Replicas(t *testing.T) {
+	cfg, err := newLoader(t, map[string]string{
+		"DB_PASSWORD": "x",
+		"DB_REPLICAS": "host=r1 password=p1, postgres://u:p2@r2/chat_api ,",
+	}).Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"host=r1 password=p1", "postgres://u:p2@r2/chat_api"}, cfg.Database.Replicas)
+
+	var buf bytes.Buffer
+	require.NoError(t, cfg.Dump(&buf, "yaml"))
+	assert.NotContains(t, buf.String(), "p1")
+	assert.NotContains(t, buf.String(), "p2")
+	assert.NotContains(t, cfg.String(), "p2")
+	assert.Equal(t, "host=r1 password=p1", cfg.Database.Replicas[0])
+}
+
+// TestValidate_MemoryBackend - без PostgreSQL его настройки не требуются
+func TestValidate_MemoryBackend(t *testing.T) {
+	cfg, err := newLoader(t, map[string]string{"STORAGE_BACKEND": "memory"}).Load()
+	require.NoError(t, err)
+	assert.Equal(t, BackendMemory, cfg.Storage.Backend)
+
+	_, err = newLoader(t, map[string]string{"STORAGE_BACKEND": "mongo"}).Load()
+	assert.ErrorContains(t, err, "storage.backend")
+}
+
+// TestValidate_SQLiteBackend - SQLite задаётся через database.url без настроек PostgreSQL
+func TestValidate_SQLiteBackend(t *testing.T) {
+	cfg, err := newLoader(t, map[string]string{"STORAGE_BACKEND": "sqlite", "DATABASE_URL": "sqlite://data/chat.db"}).Load()
+	require.NoError(t, err)
+	assert.Equal(t, "sqlite://data/chat.db", cfg.Database.URL)
+
+	_, err = newLoader(t, map[string]string{"STORAGE_BACKEND": "sqlite"}).Load()
+	assert.ErrorContains(t, err, "database.url")
+
+	_, err = newLoader(t, map[string]string{"DB_PASSWORD": "s3cret", "DATABASE_URL": "sqlite://data/chat.db"}).Load()
+	assert.ErrorContains(t, err, "requires storage.backend")
+}
+
+// TestValidate_Outbox - приёмники и их настройки проверяются только при включённом outbox
+func TestValidate_Outbox(t *testing.T) {
+	cfg, err := newLoader(t, map[string]string{"DB_PASSWORD": "s3cret", "OUTBOX_ENABLED": "on", "OUTBOX_SINKS": "bus,file"}).Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{SinkBus, SinkFile}, cfg.Outbox.Sinks)
+
+	_, err = newLoader(t, map[string]string{"DB_PASSWORD": "s3cret", "OUTBOX_ENABLED": "on", "OUTBOX_SINKS": "webhook,kafka"}).Load()
+	assert.ErrorContains(t, err, "outbox.webhook_url")
+	assert.ErrorContains(t, err, `"kafka"`)
+
+	_, err = newLoader(t, map[string]string{"STORAGE_BACKEND": "memory", "OUTBOX_ENABLED": "on", "OUTBOX_SINKS": "bus"}).Load()
+	assert.ErrorContains(t, err, "outbox.enabled")
+
+	_, err = newLoader(t, map[string]string{"DB_PASSWORD": "s3cret", "OUTBOX_ENABLED": "on", "OUTBOX_SINKS": "bus",
+		"OUTBOX_BACKOFF": "1m", "OUTBOX_MAX_BACKOFF": "30s"}).Load()
+	assert.ErrorContains(t, err, "outbox.max_backoff")
+}
+
+// TestValidate_Webhooks - вебхуки требуют outbox, но не требуют его приёмников
+func TestValidate_Webhooks(t *testing.T) {
+	cfg, err := newLoader(t, map[string]string{"DB_PASSWORD": "s3cret", "OUTBOX_ENABLED": "on", "WEBHOOKS_ENABLED": "on"}).Load()
+	require.NoError(t, err)
+	assert.Equal(t, 8, cfg.Webhooks.MaxAttempts)
+
+	_, err = newLoader(t, map[string]string{"DB_PASSWORD": "s3cret", "WEBHOOKS_ENABLED": "on"}).Load()
+	assert.ErrorContains(t, err, "webhooks.enabled: requires outbox.enabled")
+
+	_, err = newLoader(t, map[string]string{"DB_PASSWORD": "s3cret", "OUTBOX_ENABLED": "on", "WEBHOOKS_ENABLED": "on",
+		"WEBHOOKS_BACKOFF": "1m", "WEBHOOKS_MAX_BACKOFF": "30s"}).Load()
+	assert.ErrorContains(t, err, "webhooks.max_backoff")
+}
+
+func TestValidate_Idempotency(t *testing.T) {
+	cfg, err := newLoader(t, map[string]string{"STORAGE_BACKEND": "memory"}).Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.Idempotency.Enabled)
+	assert.Equal(t, 24*time.Hour, cfg.Idempotency.TTL)
+	assert.Equal(t, time.Minute, cfg.Idempotency.Lease)
+
+	_, err = newLoader(t, map[string]string{"STORAGE_BACKEND": "memory", "IDEMPOTENCY_TTL": "0s"}).Load()
+	assert.ErrorContains(t, err, "idempotency.ttl")
+
+	_, err = newLoader(t, map[string]string{"STORAGE_BACKEND": "memory", "IDEMPOTENCY_LEASE": "48h"}).Load()
+	assert.ErrorContains(t, err, "idempotency.lease")
+
+	_, err = newLoader(t, map[string]string{"STORAGE_BACKEND": "memory", "IDEMPOTENCY_ENABLED": "off", "IDEMPOTENCY_TTL": "0s"}).Load()
+	assert.NoError(t, err)
+}
+
+func TestValidate_RateLimit(t *testing.T) {
+	cfg, err := newLoader(t, map[string]string{"STORAGE_BACKEND": "memory", "RATE_LIMIT_MESSAGES": "0"}).Load()
+	require.NoError(t, err)
+	assert.True(t, cfg.RateLimit.Enabled)
+	assert.Equal(t, 600, cfg.RateLimit.Read)
+	assert.Zero(t, cfg.RateLimit.Messages)
+
+	_, err = newLoader(t, map[string]string{"STORAGE_BACKEND": "memory", "RATE_LIMIT_WRITE": "-1"}).Load()
+	assert.ErrorContains(t, err, "rate_limit.write")
+}
+
+func TestValidate_Incoming(t *testing.T) {
+	_, err := newLoader(t, map[string]string{"STORAGE_BACKEND": "memory", "INCOMING_WEBHOOKS_ENABLED": "on", "INCOMING_WEBHOOKS_RATE_LIMIT": "0"}).Load()
+	assert.ErrorContains(t, err, "incoming_webhooks.enabled")
+	assert.ErrorContains(t, err, "incoming_webhooks.rate_limit")
+}
+
+func TestValidate_Bots(t *testing.T) {
+	_, err := newLoader(t, map[string]string{"STORAGE_BACKEND": "memory", "BOTS_ENABLED": "on", "BOTS_TIMEOUT": "0s"}).Load()
+	assert.ErrorContains(t, err, "bots.enabled")
+	assert.ErrorContains(t, err, "bots.timeout")
+}
+
+func TestValidate_Assistant(t *testing.T) {
+	cfg, err := newLoader(t, map[string]string{"STORAGE_BACKEND": "memory", "ASSISTANT_ENABLED": "on"}).Load()
+	require.NoError(t, err)
+	assert.Equal(t, "fake", cfg.Assistant.Provider)
+
+	_, err = newLoader(t, map[string]string{"STORAGE_BACKEND": "memory", "ASSISTANT_ENABLED": "on",
+		"ASSISTANT_PROVIDER": "openai", "ASSISTANT_ENDPOINT": "api.example.com", "ASSISTANT_NAME": "ai bot", "ASSISTANT_BUDGET": "0"}).Load()
+	assert.ErrorContains(t, err, "assistant.endpoint")
+	assert.ErrorContains(t, err, "assistant.name")
+	assert.ErrorContains(t, err, "assistant.budget")
+}
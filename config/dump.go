@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Redacted - копия настроек со скрытыми секретами
+func (c *Config) Redacted() *Config {
+	copied := *c
+	for _, s := range copied.settings() {
+		if !s.secret || s.field.IsZero() {
+			continue
+		}
+		if values, ok := s.field.Interface().([]string); ok {
+			masked := make([]string, len(values))
+			for i := range masked {
+				masked[i] = redacted
+			}
+			s.field.Set(reflect.ValueOf(masked))
+		} else {
+			s.field.SetString(redacted)
+		}
+	}
+	return &copied
+}
+
+// String - настройки в одну строку для логов, секреты скрыты
+func (c *Config) String() string {
+	settings := c.settings()
+	parts := make([]string, len(settings))
+	for i, s := range settings {
+		parts[i] = s.key + "=" + s.String()
+	}
+	return strings.Join(parts, " ")
+}
+
+// Dump - печатает действующие настройки в формате yaml, toml или env, секреты скрыты
+func (c *Config) Dump(w io.Writer, format string) error {
+	redactedCfg := c.Redacted()
+
+	switch format {
+	case "yaml", "yml":
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(redactedCfg); err != nil {
+			return err
+		}
+		return encoder.Close()
+
+	case "toml":
+		return toml.NewEncoder(w).Encode(redactedCfg)
+
+	case "env":
+		for _, s := range c.settings() {
+			if s.env != "" {
+				if _, err := fmt.Fprintf(w, "%s=%s\n", s.env, s); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown format %q, expected yaml, toml or env", format)
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv - переменная окружения с путём к файлу настроек, если не задан флаг -config
+const FileEnv = "CONFIG_FILE"
+
+// Loader - разбирает глобальные флаги и собирает настройки в порядке
+// значения по умолчанию < файл < переменные окружения < флаги
+type Loader struct {
+	flags     *flag.FlagSet
+	file      string
+	overrides []override
+
+	// LookupEnv - источник переменных окружения, по умолчанию os.LookupEnv
+	LookupEnv func(key string) (string, bool)
+}
+
+type override struct {
+	key   string
+	value string
+}
+
+// NewLoader - регистрирует в fs флаг -config и по флагу -section.key на каждую настройку
+func NewLoader(fs *flag.FlagSet) *Loader {
+	l := &Loader{flags: fs, LookupEnv: os.LookupEnv}
+
+	fs.StringVar(&l.file, "config", "", "файл настроек .yaml, .yml или .toml (или "+FileEnv+")")
+
+	for _, s := range Default().settings() {
+		usage := "настройка " + s.key
+		if s.env != "" {
+			usage += " (" + s.env + ")"
+		}
+		fs.Var(&overrideFlag{loader: l, key: s.key, isBool: s.field.Kind() == reflect.Bool}, s.key, usage)
+	}
+
+	return l
+}
+
+// Load - собирает и проверяет настройки; вызывается после fs.Parse
+func (l *Loader) Load() (*Config, error) {
+	cfg := Default()
+	settings := cfg.settings()
+
+	file := l.file
+	if file == "" {
+		file, _ = l.LookupEnv(FileEnv)
+	}
+	if file != "" {
+		if err := loadFile(cfg, file); err != nil {
+			return nil, err
+		}
+	}
+
+	var errs []error
+	for _, s := range settings {
+		if s.env == "" {
+			continue
+		}
+		if raw, ok := l.LookupEnv(s.env); ok && raw != "" {
+			if err := s.set(raw); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", s.env, err))
+			}
+		}
+	}
+
+	byKey := make(map[string]setting, len(settings))
+	for _, s := range settings {
+		byKey[s.key] = s
+	}
+	for _, o := range l.overrides {
+		if err := byKey[o.key].set(o.value); err != nil {
+			errs = append(errs, fmt.Errorf("-%s: %w", o.key, err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	return cfg, nil
+}
+
+// loadFile - читает файл настроек; неизвестные ключи считаются ошибкой, чтобы опечатки не терялись
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+
+	case ".toml":
+		meta, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			keys := make([]string, len(undecoded))
+			for i, key := range undecoded {
+				keys[i] = key.String()
+			}
+			sort.Strings(keys)
+			return fmt.Errorf("failed to parse %s: unknown keys %s", path, strings.Join(keys, ", "))
+		}
+
+	default:
+		return fmt.Errorf("unsupported config file %s: expected .yaml, .yml or .toml", path)
+	}
+
+	return nil
+}
+
+// overrideFlag - запоминает значение флага; применяется в Load поверх файла и окружения
+type overrideFlag struct {
+	loader *Loader
+	key    string
+	value  string
+	isBool bool
+}
+
+func (f *overrideFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.value
+}
+
+func (f *overrideFlag) Set(value string) error {
+	f.value = value
+	f.loader.overrides = append(f.loader.overrides, override{key: f.key, value: value})
+	return nil
+}
+
+func (f *overrideFlag) IsBoolFlag() bool {
+	return f.isBool
+}
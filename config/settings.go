@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const redacted = "******"
+
+// setting - одна настройка: ключ section.key, переменная окружения и поле в Config
+type setting struct {
+	key    string
+	env    string
+	secret bool
+	field  reflect.Value
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// settings - плоский список настроек Config в порядке объявления полей
+func (c *Config) settings() []setting {
+	var settings []setting
+
+	root := reflect.ValueOf(c).Elem()
+	for i := 0; i < root.NumField(); i++ {
+		section := root.Field(i)
+		sectionKey := root.Type().Field(i).Tag.Get("yaml")
+
+		for j := 0; j < section.NumField(); j++ {
+			field := section.Type().Field(j)
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			settings = append(settings, setting{
+				key:    sectionKey + "." + name,
+				env:    field.Tag.Get("env"),
+				secret: field.Tag.Get("secret") == "true",
+				field:  section.Field(j),
+			})
+		}
+	}
+
+	return settings
+}
+
+// set - разбирает строковое значение из окружения или флага в тип поля
+func (s setting) set(raw string) error {
+	raw = strings.TrimSpace(raw)
+
+	switch {
+	case s.field.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q, expected e.g. 30s or 5m", raw)
+		}
+		s.field.SetInt(int64(d))
+
+	case s.field.Kind() == reflect.String:
+		s.field.SetString(raw)
+
+	case s.field.Kind() == reflect.Slice && s.field.Type().Elem().Kind() == reflect.String:
+		// список через запятую: DB_REPLICAS=host=r1 ...,host=r2 ...
+		var values []string
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+		s.field.Set(reflect.ValueOf(values))
+
+	case s.field.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		s.field.SetInt(int64(n))
+
+	case s.field.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		s.field.SetFloat(f)
+
+	case s.field.Kind() == reflect.Bool:
+		b, err := parseBool(raw)
+		if err != nil {
+			return err
+		}
+		s.field.SetBool(b)
+
+	default:
+		panic(fmt.Sprintf("config: unsupported type %s for %s", s.field.Type(), s.key))
+	}
+
+	return nil
+}
+
+// parseBool - кроме true/false принимает on/off и yes/no (LOG_TO_FILE=on)
+func parseBool(raw string) (bool, error) {
+	switch strings.ToLower(raw) {
+	case "on", "yes":
+		return true, nil
+	case "off", "no":
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("invalid boolean %q, expected true/false or on/off", raw)
+	}
+	return b, nil
+}
+
+// String - значение для вывода; секреты скрываются
+func (s setting) String() string {
+	if s.secret && !s.field.IsZero() {
+		return redacted
+	}
+	if s.field.Type() == durationType {
+		return time.Duration(s.field.Int()).String()
+	}
+	if values, ok := s.field.Interface().([]string); ok {
+		return strings.Join(values, ",")
+	}
+	return fmt.Sprint(s.field.Interface())
+}
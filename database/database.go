@@ -1,68 +1,199 @@
 package database
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
-	"chat-api/utils"
-
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"gorm.io/plugin/opentelemetry/tracing"
 )
 
+// maxConnectBackoff - предел паузы между попытками подключения при старте
+const maxConnectBackoff = 30 * time.Second
+
 type ClientDB struct {
 	DB *gorm.DB
+
+	replicas *replicaSet
 }
 
 type Config struct {
+	// URL - DSN целиком: postgres://... или sqlite://path/to/chat.db.
+	// Если задан, Host, Port, User, Password, DBName и SSLMode не используются
+	URL string
+
 	Host     string
 	Port     int
 	User     string
 	Password string
 	DBName   string
 	SSLMode  string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// StatementTimeout - statement_timeout сессии PostgreSQL, 0 - без ограничения
+	StatementTimeout time.Duration
+	// ConnectTimeout - таймаут одной попытки подключения
+	ConnectTimeout time.Duration
+	// ConnectRetries - сколько раз повторить подключение при старте, пока база поднимается
+	ConnectRetries int
+	// ConnectBackoff - пауза перед первым повтором, дальше удваивается
+	ConnectBackoff time.Duration
+
+	// Replicas - DSN реплик для чтения (key=value или postgres:// URL)
+	Replicas []string
+	// ReplicaCheckInterval - как часто проверять доступность реплик
+	ReplicaCheckInterval time.Duration
+
+	// Logger - логгер GORM; nil - логи GORM отключены
+	Logger logger.Interface
 }
 
-func NewDB() (*ClientDB, error) {
-	config := Config{
-		Host:     utils.GetEnv("DB_HOST", "localhost"),
-		Port:     utils.GetEnvAsInt("DB_PORT", 5432),
-		User:     utils.GetEnv("DB_USER", "postgres"),
-		Password: utils.GetEnv("DB_PASSWORD", "password"),
-		DBName:   utils.GetEnv("DB_NAME", "chat_api"),
-		SSLMode:  utils.GetEnv("DB_SSLMODE", "disable"),
+// NewDB - подключается к базе, драйвер выбирается по схеме Config.URL:
+// sqlite:// - SQLite, иначе PostgreSQL
+func NewDB(ctx context.Context, config Config) (*ClientDB, error) {
+	var (
+		open   func() gorm.Dialector
+		dbName = config.DBName
+	)
+
+	switch {
+	case IsSQLite(config.URL):
+		if len(config.Replicas) > 0 {
+			return nil, errors.New("read replicas are supported only for PostgreSQL")
+		}
+		path, dsn := sqliteDSN(config.URL)
+		open = func() gorm.Dialector { return sqlite.Open(dsn) }
+		dbName = path
+		config = sqlitePool(path, config)
+	default:
+		dsn := config.URL
+		if dsn == "" {
+			dsn = fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+				config.Host, config.Port, config.User, config.Password, config.DBName, config.SSLMode)
+		}
+		dsn = withOptions(dsn, config)
+		open = func() gorm.Dialector { return postgres.Open(dsn) }
 	}
 
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		config.Host, config.Port, config.User, config.Password, config.DBName, config.SSLMode)
+	gormLogger := config.Logger
+	if gormLogger == nil {
+		gormLogger = logger.Discard
+	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
-	})
+	db, err := connect(ctx, open, gormLogger, config.ConnectRetries, config.ConnectBackoff)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	if err := db.Use(tracing.NewPlugin(tracing.WithoutMetrics(), tracing.WithoutQueryVariables(), tracing.WithDBName(dbName))); err != nil {
+		return nil, fmt.Errorf("failed to enable database tracing: %w", err)
+	}
+
 	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get database instance: %w", err)
 	}
 
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetConnMaxLifetime(time.Hour)
+	configurePool(sqlDB, config)
+
+	client := &ClientDB{DB: db}
+
+	if len(config.Replicas) > 0 {
+		replicas, err := openReplicas(config, &gorm.Config{Logger: gormLogger})
+		if err != nil {
+			sqlDB.Close()
+			return nil, fmt.Errorf("failed to open read replica: %w", err)
+		}
+		replicas.run(config.ReplicaCheckInterval)
+		client.replicas = replicas
+	}
+
+	return client, nil
+}
+
+// withOptions - добавляет к DSN параметры подключения, общие для основной базы и реплик.
+// DSN может быть в виде key=value или postgres:// URL
+func withOptions(dsn string, config Config) string {
+	var options []string
+	if config.ConnectTimeout > 0 {
+		options = append(options, fmt.Sprintf("connect_timeout=%d", int(config.ConnectTimeout.Round(time.Second).Seconds())))
+	}
+	if config.StatementTimeout > 0 {
+		options = append(options, fmt.Sprintf("statement_timeout=%d", config.StatementTimeout.Milliseconds()))
+	}
+	if len(options) == 0 {
+		return dsn
+	}
+
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		return dsn + separator + strings.Join(options, "&")
+	}
+	return dsn + " " + strings.Join(options, " ")
+}
 
-	return &ClientDB{DB: db}, nil
+func configurePool(sqlDB *sql.DB, config Config) {
+	sqlDB.SetMaxIdleConns(config.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(config.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+}
+
+// connect - открывает соединение, повторяя попытки с экспоненциальной паузой
+func connect(ctx context.Context, open func() gorm.Dialector, gormLogger logger.Interface, retries int, backoff time.Duration) (*gorm.DB, error) {
+	for attempt := 0; ; attempt++ {
+		db, err := gorm.Open(open(), &gorm.Config{Logger: gormLogger})
+		if err == nil {
+			return db, nil
+		}
+		if db != nil {
+			if sqlDB, dbErr := db.DB(); dbErr == nil {
+				sqlDB.Close()
+			}
+		}
+		if attempt >= retries {
+			return nil, err
+		}
+
+		gormLogger.Warn(ctx, "database is not available (attempt %d of %d), retrying in %s: %v", attempt+1, retries+1, backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
 }
 
 func (c *ClientDB) Close() error {
+	var replicasErr error
+	if c.replicas != nil {
+		replicasErr = c.replicas.close()
+	}
+
 	if c.DB != nil {
 		sqlDB, err := c.DB.DB()
 		if err != nil {
 			return err
 		}
-		return sqlDB.Close()
+		if err := sqlDB.Close(); err != nil {
+			return err
+		}
 	}
-	return nil
+	return replicasErr
 }
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// closedPort - порт, на котором гарантированно никто не слушает
+func closedPort(t *testing.T) int {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+	return port
+}
+
+func unreachableConfig(t *testing.T) Config {
+	return Config{
+		Host:           "127.0.0.1",
+		Port:           closedPort(t),
+		User:           "postgres",
+		Password:       "password",
+		DBName:         "chat_api",
+		SSLMode:        "disable",
+		MaxOpenConns:   1,
+		ConnectTimeout: time.Second,
+		ConnectBackoff: 20 * time.Millisecond,
+	}
+}
+
+// TestNewDB_Retries - при недоступной базе подключение повторяется с растущей паузой
+func TestNewDB_Retries(t *testing.T) {
+	cfg := unreachableConfig(t)
+	cfg.ConnectRetries = 2
+
+	start := time.Now()
+	_, err := NewDB(context.Background(), cfg)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to connect to database")
+	// 20ms + 40ms паузы между тремя попытками
+	assert.GreaterOrEqual(t, time.Since(start), 60*time.Millisecond)
+}
+
+// TestNewDB_RetriesCancelled - остановка процесса прерывает ожидание базы
+func TestNewDB_RetriesCancelled(t *testing.T) {
+	cfg := unreachableConfig(t)
+	cfg.ConnectRetries = 100
+	cfg.ConnectBackoff = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := NewDB(ctx, cfg)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+// TestCheckSchemaVersion - схема старше бинарника не готова, а более новая после миграций следующей версии допустима
+func TestCheckSchemaVersion(t *testing.T) {
+	ctx := context.Background()
+	db, err := NewDB(ctx, Config{URL: "sqlite://" + filepath.Join(t.TempDir(), "chat.db")})
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrator, err := db.Migrator()
+	require.NoError(t, err)
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.NoError(t, db.CheckSchemaVersion(ctx))
+
+	_, err = migrator.Down(ctx)
+	require.NoError(t, err)
+	assert.ErrorContains(t, db.CheckSchemaVersion(ctx), "expected at least")
+
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	require.NoError(t, db.DB.Exec("INSERT INTO goose_db_version (version_id, is_applied) VALUES (?, 1)", migrator.Latest()+1).Error)
+	assert.NoError(t, db.CheckSchemaVersion(ctx))
+}
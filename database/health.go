@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"chat-api/migrate"
+	"chat-api/migrations"
+)
+
+func (c *ClientDB) Ping(ctx context.Context) error {
+	sqlDB, err := c.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// Migrator - движок миграций, встроенных в бинарник, для диалекта подключённой базы
+func (c *ClientDB) Migrator() (*migrate.Migrator, error) {
+	sqlDB, err := c.DB.DB()
+	if err != nil {
+		return nil, err
+	}
+	if c.DB.Dialector.Name() == "sqlite" {
+		return migrate.New(sqlDB, migrations.SQLiteFS, migrate.WithDialect(migrate.SQLite))
+	}
+	return migrate.New(sqlDB, migrations.FS)
+}
+
+// CheckSchemaVersion - ошибка, если схема в БД старше последней встроенной миграции. Более новая схема
+// допустима: при поэтапном выкате миграции новой версии применяются, пока старые экземпляры ещё работают
+func (c *ClientDB) CheckSchemaVersion(ctx context.Context) error {
+	migrator, err := c.Migrator()
+	if err != nil {
+		return err
+	}
+
+	version, err := migrator.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if version < migrator.Latest() {
+		return fmt.Errorf("schema version %d, expected at least %d", version, migrator.Latest())
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// TryLock - сессионная pg_try_advisory_lock на выделенном соединении для фоновых
+// обработчиков, которые должен выполнять только один экземпляр. Занятая блокировка - не ошибка:
+// ok = false, и проход просто пропускается. SQLite обслуживает один процесс, там блокировка не нужна
+func TryLock(ctx context.Context, db *gorm.DB, key int64) (unlock func(), ok bool, err error) {
+	if db.Dialector.Name() != "postgres" {
+		return func() {}, true, nil
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, false, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get database connection: %w", err)
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, false, fmt.Errorf("failed to acquire advisory lock: %w", err)
+	}
+	if !locked {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	return func() {
+		conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key)
+		conn.Close()
+	}, true, nil
+}
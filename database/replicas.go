@@ -0,0 +1,187 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/plugin/opentelemetry/tracing"
+)
+
+// replicaPingTimeout - таймаут проверки доступности одной реплики
+const replicaPingTimeout = 2 * time.Second
+
+type primaryKey struct{}
+
+// WithPrimary - чтения в этом контексте идут в основную базу, минуя реплики
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func usePrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
+
+type replica struct {
+	db      *gorm.DB
+	healthy atomic.Bool
+}
+
+// replicaSet - реплики для чтения и фоновая проверка их доступности
+type replicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// openReplicas - открывает реплики без ожидания: недоступная при старте реплика
+// помечается нездоровой и подключится, когда поднимется
+func openReplicas(config Config, gormConfig *gorm.Config) (*replicaSet, error) {
+	set := &replicaSet{stop: make(chan struct{})}
+
+	for _, dsn := range config.Replicas {
+		replicaConfig := *gormConfig
+		replicaConfig.DisableAutomaticPing = true
+
+		db, err := gorm.Open(postgres.Open(withOptions(dsn, config)), &replicaConfig)
+		if err != nil {
+			set.close()
+			return nil, err
+		}
+		if err := db.Use(tracing.NewPlugin(tracing.WithoutMetrics(), tracing.WithoutQueryVariables(), tracing.WithDBName(config.DBName))); err != nil {
+			set.close()
+			return nil, err
+		}
+
+		sqlDB, err := db.DB()
+		if err != nil {
+			set.close()
+			return nil, err
+		}
+		configurePool(sqlDB, config)
+
+		set.replicas = append(set.replicas, &replica{db: db})
+	}
+
+	set.check(context.Background())
+	return set, nil
+}
+
+// run - периодически проверяет реплики до вызова close
+func (s *replicaSet) run(interval time.Duration) {
+	if len(s.replicas) == 0 || interval <= 0 {
+		return
+	}
+
+	s.done.Add(1)
+	go func() {
+		defer s.done.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				s.check(context.Background())
+			}
+		}
+	}()
+}
+
+func (s *replicaSet) check(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, r := range s.replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			pingCtx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+			defer cancel()
+
+			sqlDB, err := r.db.DB()
+			if err == nil {
+				err = sqlDB.PingContext(pingCtx)
+			}
+			r.healthy.Store(err == nil)
+		}()
+	}
+	wg.Wait()
+}
+
+// pick - следующая здоровая реплика по кругу, nil если здоровых нет
+func (s *replicaSet) pick() *gorm.DB {
+	n := len(s.replicas)
+	if n == 0 {
+		return nil
+	}
+
+	start := s.next.Add(1)
+	for i := range n {
+		r := s.replicas[(start+uint64(i))%uint64(n)]
+		if r.healthy.Load() {
+			return r.db
+		}
+	}
+	return nil
+}
+
+// healthy - количество доступных реплик
+func (s *replicaSet) healthy() int {
+	var count int
+	for _, r := range s.replicas {
+		if r.healthy.Load() {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *replicaSet) close() error {
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+	s.done.Wait()
+
+	var firstErr error
+	for _, r := range s.replicas {
+		sqlDB, err := r.db.DB()
+		if err == nil {
+			err = sqlDB.Close()
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Reader - база для запросов только на чтение: здоровая реплика или основная база,
+// если реплик нет, все недоступны или контекст помечен WithPrimary
+func (c *ClientDB) Reader(ctx context.Context) *gorm.DB {
+	if c.replicas == nil || usePrimary(ctx) {
+		return c.DB
+	}
+	if db := c.replicas.pick(); db != nil {
+		return db
+	}
+	return c.DB
+}
+
+// ReplicaStatus - число настроенных и доступных реплик
+func (c *ClientDB) ReplicaStatus() (total, healthy int) {
+	if c.replicas == nil {
+		return 0, 0
+	}
+	return len(c.replicas.replicas), c.replicas.healthy()
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// lazyDB - *gorm.DB без подключения: pgx подключается только при первом запросе
+func lazyDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.Open("host=127.0.0.1 user=postgres dbname=chat_api"), &gorm.Config{DisableAutomaticPing: true})
+	require.NoError(t, err)
+	return db
+}
+
+func newReplicaClient(t *testing.T, n int) (*ClientDB, []*replica) {
+	t.Helper()
+
+	set := &replicaSet{stop: make(chan struct{})}
+	for range n {
+		r := &replica{db: lazyDB(t)}
+		r.healthy.Store(true)
+		set.replicas = append(set.replicas, r)
+	}
+	return &ClientDB{DB: lazyDB(t), replicas: set}, set.replicas
+}
+
+// TestReader_RoundRobin - чтения распределяются по здоровым репликам
+func TestReader_RoundRobin(t *testing.T) {
+	client, replicas := newReplicaClient(t, 2)
+
+	seen := make(map[*gorm.DB]int)
+	for range 10 {
+		seen[client.Reader(context.Background())]++
+	}
+
+	assert.Equal(t, 5, seen[replicas[0].db])
+	assert.Equal(t, 5, seen[replicas[1].db])
+	assert.Zero(t, seen[client.DB])
+}
+
+// TestReader_Failover - недоступные реплики пропускаются, без реплик чтения идут в основную базу
+func TestReader_Failover(t *testing.T) {
+	client, replicas := newReplicaClient(t, 2)
+
+	replicas[0].healthy.Store(false)
+	for range 4 {
+		assert.Same(t, replicas[1].db, client.Reader(context.Background()))
+	}
+
+	replicas[1].healthy.Store(false)
+	assert.Same(t, client.DB, client.Reader(context.Background()))
+
+	total, healthy := client.ReplicaStatus()
+	assert.Equal(t, 2, total)
+	assert.Zero(t, healthy)
+}
+
+// TestReader_WithPrimary - контекст может явно потребовать основную базу
+func TestReader_WithPrimary(t *testing.T) {
+	client, _ := newReplicaClient(t, 1)
+
+	assert.Same(t, client.DB, client.Reader(WithPrimary(context.Background())))
+	assert.NotSame(t, client.DB, client.Reader(context.Background()))
+
+	withoutReplicas := &ClientDB{DB: lazyDB(t)}
+	assert.Same(t, withoutReplicas.DB, withoutReplicas.Reader(context.Background()))
+}
+
+// TestWithOptions - параметры подключения для DSN в обоих форматах
+func TestWithOptions(t *testing.T) {
+	cfg := Config{ConnectTimeout: 5e9, StatementTimeout: 3e10}
+
+	assert.Equal(t, "host=r1 connect_timeout=5 statement_timeout=30000", withOptions("host=r1", cfg))
+	assert.Equal(t, "postgres://u@r1/db?connect_timeout=5&statement_timeout=30000", withOptions("postgres://u@r1/db", cfg))
+	assert.Equal(t, "postgres://u@r1/db?sslmode=require&connect_timeout=5&statement_timeout=30000", withOptions("postgres://u@r1/db?sslmode=require", cfg))
+	assert.Equal(t, "host=r1", withOptions("host=r1", Config{}))
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"strings"
+)
+
+// sqliteScheme - префикс DSN SQLite: sqlite://data/chat.db, sqlite:///var/lib/chat.db или sqlite://:memory:
+const sqliteScheme = "sqlite://"
+
+// sqliteMemory - база в памяти, своя у каждого соединения
+const sqliteMemory = ":memory:"
+
+// sqlitePragmas - внешние ключи нужны для каскадного удаления сообщений,
+// busy_timeout - чтобы параллельные записи ждали блокировку, а не падали с SQLITE_BUSY,
+// WAL - чтобы чтения не блокировались записью. Пишущие транзакции берут блокировку сразу
+// (_txlock=immediate): иначе при повышении блокировки внутри транзакции busy_timeout не помогает.
+// _time_format не задаётся: драйвер после него не читает остальные параметры
+var sqlitePragmas = []string{
+	"_pragma=foreign_keys(1)",
+	"_pragma=busy_timeout(5000)",
+	"_pragma=journal_mode(WAL)",
+	"_txlock=immediate",
+}
+
+// IsSQLite - DSN указывает на SQLite
+func IsSQLite(dsn string) bool {
+	return strings.HasPrefix(dsn, sqliteScheme)
+}
+
+// sqliteDSN - путь к файлу базы и DSN для драйвера с обязательными параметрами
+func sqliteDSN(dsn string) (path, driverDSN string) {
+	path = strings.TrimPrefix(dsn, sqliteScheme)
+	driverDSN = path
+
+	separator := "?"
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+		separator = "&"
+	}
+	return path, driverDSN + separator + strings.Join(sqlitePragmas, "&")
+}
+
+// sqlitePool - база в памяти живёт, пока открыто её единственное соединение,
+// поэтому пул ограничивается одним соединением без истечения срока
+func sqlitePool(path string, config Config) Config {
+	if path != sqliteMemory {
+		return config
+	}
+	config.MaxOpenConns = 1
+	config.MaxIdleConns = 1
+	config.ConnMaxLifetime = 0
+	config.ConnMaxIdleTime = 0
+	return config
+}
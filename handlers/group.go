@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Module - набор маршрутов, который сам регистрирует себя в группе.
+// Новые фичи подключаются через New(..., modules...) или Router.Mount без правки router.go.
+type Module interface {
+	Register(g *Group)
+}
+
+type ModuleFunc func(g *Group)
+
+func (f ModuleFunc) Register(g *Group) {
+	f(g)
+}
+
+// Group - маршруты с общим префиксом пути и общими middleware
+type Group struct {
+	router     *Router
+	prefix     string
+	middleware []Middleware
+}
+
+// Group - вложенная группа, наследующая префикс и middleware родителя
+func (g *Group) Group(prefix string, middleware ...Middleware) *Group {
+	return &Group{
+		router:     g.router,
+		prefix:     g.prefix + cleanPrefix(prefix),
+		middleware: append(append([]Middleware{}, g.middleware...), middleware...),
+	}
+}
+
+// Use - добавляет middleware группы. Применяется к маршрутам, зарегистрированным после вызова.
+func (g *Group) Use(middleware ...Middleware) {
+	g.middleware = append(g.middleware, middleware...)
+}
+
+// Mount - регистрирует маршруты модуля во вложенной группе
+func (g *Group) Mount(prefix string, module Module, middleware ...Middleware) {
+	module.Register(g.Group(prefix, middleware...))
+}
+
+// Handle - регистрирует обработчик; порядок middleware: группы, затем маршрута
+func (g *Group) Handle(method, pattern string, handler http.HandlerFunc, middleware ...Middleware) {
+	chain := append(append([]Middleware{}, g.middleware...), middleware...)
+	g.router.Handle(method, g.prefix+pattern, handler, chain...)
+}
+
+func (g *Group) Get(pattern string, handler http.HandlerFunc, middleware ...Middleware) {
+	g.Handle(http.MethodGet, pattern, handler, middleware...)
+}
+
+func (g *Group) Post(pattern string, handler http.HandlerFunc, middleware ...Middleware) {
+	g.Handle(http.MethodPost, pattern, handler, middleware...)
+}
+
+func (g *Group) Put(pattern string, handler http.HandlerFunc, middleware ...Middleware) {
+	g.Handle(http.MethodPut, pattern, handler, middleware...)
+}
+
+func (g *Group) Patch(pattern string, handler http.HandlerFunc, middleware ...Middleware) {
+	g.Handle(http.MethodPatch, pattern, handler, middleware...)
+}
+
+func (g *Group) Delete(pattern string, handler http.HandlerFunc, middleware ...Middleware) {
+	g.Handle(http.MethodDelete, pattern, handler, middleware...)
+}
+
+// Routes - регистрирует список маршрутов в стиле RegisterChatRoutes
+func (g *Group) Routes(routes []RouteDefinition) {
+	for _, route := range routes {
+		g.Handle(route.Method, route.Path, route.Handler, route.Middleware...)
+	}
+}
+
+func cleanPrefix(prefix string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return ""
+	}
+	return "/" + prefix
+}
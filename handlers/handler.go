@@ -2,12 +2,14 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
+	"math"
 	"net/http"
 	"strconv"
-	"strings"
+	"time"
 
 	"chat-api/models"
+	"chat-api/repository"
 )
 
 type ChatHandler struct {
@@ -20,32 +22,7 @@ func NewChatHandler(service ChatService) *ChatHandler {
 	}
 }
 
-// HealthCheck - проверка работоспособности сервиса
-func (h *ChatHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("OK"))
-}
-
-func extractIDFromPath(path string) (uint, error) {
-	parts := strings.Split(strings.Trim(path, "/"), "/")
-	if len(parts) < 2 {
-		return 0, fmt.Errorf("invalid path format")
-	}
-
-	id, err := strconv.ParseUint(parts[1], 10, 32)
-	if err != nil {
-		return 0, fmt.Errorf("invalid ID format")
-	}
-
-	return uint(id), nil
-}
-
 func (h *ChatHandler) CreateChat(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	var req models.CreateChatRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
@@ -64,12 +41,7 @@ func (h *ChatHandler) CreateChat(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ChatHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	chatID, err := extractIDFromPath(r.URL.Path)
+	chatID, err := PathParamUint(r, "id")
 	if err != nil {
 		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
 		return
@@ -83,22 +55,34 @@ func (h *ChatHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 
 	message, err := h.service.SendMessage(r.Context(), chatID, req.Text)
 	if err != nil {
+		var limited retryAfter
+		if errors.As(err, &limited) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter().Seconds()))))
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+		var failed upstream
+		if errors.As(err, &failed) {
+			http.Error(w, failed.Upstream(), http.StatusBadGateway)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	// эфемерный ответ бота на команду ничего не создаёт
+	status := http.StatusCreated
+	if message.Ephemeral {
+		status = http.StatusOK
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(message)
 }
 
 func (h *ChatHandler) GetMessages(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	chatID, err := extractIDFromPath(r.URL.Path)
+	chatID, err := PathParamUint(r, "id")
 	if err != nil {
 		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
 		return
@@ -114,39 +98,70 @@ func (h *ChatHandler) GetMessages(w http.ResponseWriter, r *http.Request) {
 
 	chat, err := h.service.GetChat(r.Context(), chatID, limit)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
-		return
-	}
-
-	// Convert to response format
-	messages := make([]models.MessageResponse, len(chat.Messages))
-	for i, msg := range chat.Messages {
-		messages[i] = models.MessageResponse{
-			ID:        msg.ID,
-			ChatID:    msg.ChatID,
-			Text:      msg.Text,
-			CreatedAt: msg.CreatedAt,
+		if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
 		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	chatResponse := &models.ChatResponse{
-		ID:        chat.ID,
-		Title:     chat.Title,
-		CreatedAt: chat.CreatedAt,
-		Messages:  messages,
+		ID:               chat.ID,
+		Title:            chat.Title,
+		AssistantEnabled: chat.AssistantEnabled,
+		SlowModeSeconds:  chat.SlowModeSeconds,
+		CreatedAt:        chat.CreatedAt,
+		Messages:         toMessageResponses(chat.Messages),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(chatResponse)
 }
 
-func (h *ChatHandler) DeleteChat(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// ListMessages - постраничное получение сообщений чата, от новых к старым
+func (h *ChatHandler) ListMessages(w http.ResponseWriter, r *http.Request) {
+	chatID, err := PathParamUint(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
 		return
 	}
 
-	chatID, err := extractIDFromPath(r.URL.Path)
+	query := r.URL.Query()
+
+	var limit int
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+
+	var before uint
+	if beforeStr := query.Get("before"); beforeStr != "" {
+		parsedBefore, err := strconv.ParseUint(beforeStr, 10, 32)
+		if err != nil {
+			http.Error(w, "Invalid before cursor", http.StatusBadRequest)
+			return
+		}
+		before = uint(parsedBefore)
+	}
+
+	messages, err := h.service.ListMessages(r.Context(), chatID, before, limit)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toMessageResponses(messages))
+}
+
+func (h *ChatHandler) DeleteChat(w http.ResponseWriter, r *http.Request) {
+	chatID, err := PathParamUint(r, "id")
 	if err != nil {
 		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
 		return
@@ -154,9 +169,69 @@ func (h *ChatHandler) DeleteChat(w http.ResponseWriter, r *http.Request) {
 
 	err = h.service.DeleteChat(r.Context(), chatID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// SetSlowMode - медленный режим чата: каждый участник пишет не чаще раза в seconds секунд
+func (h *ChatHandler) SetSlowMode(w http.ResponseWriter, r *http.Request) {
+	chatID, err := PathParamUint(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.SlowModeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Seconds == nil {
+		http.Error(w, "Invalid JSON: seconds is required", http.StatusBadRequest)
+		return
+	}
+	if *req.Seconds < 0 || *req.Seconds > math.MaxInt32 {
+		http.Error(w, "Invalid seconds", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.SetSlowMode(r.Context(), chatID, time.Duration(*req.Seconds)*time.Second); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.SlowModeResponse{ChatID: chatID, SlowModeSeconds: *req.Seconds})
+}
+
+// retryAfter - ошибка ограничения частоты, например медленного режима: запрос можно повторить позже
+type retryAfter interface {
+	RetryAfter() time.Duration
+}
+
+// upstream - сбой внешней системы, например бота: 502 без подробностей, в них бывают внутренние адреса
+type upstream interface {
+	Upstream() string
+}
+
+func toMessageResponses(messages []models.Message) []models.MessageResponse {
+	responses := make([]models.MessageResponse, len(messages))
+	for i, msg := range messages {
+		responses[i] = models.MessageResponse{
+			ID:         msg.ID,
+			ChatID:     msg.ChatID,
+			Text:       msg.Text,
+			AuthorName: msg.AuthorName,
+			Ephemeral:  msg.Ephemeral,
+			CreatedAt:  msg.CreatedAt,
+		}
+	}
+	return responses
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultCheckTimeout = 2 * time.Second
+
+// ErrorLogger - логгер ошибок проверок: в ответ проверки они не попадают
+type ErrorLogger interface {
+	LogError(ctx context.Context, operation string, err error)
+}
+
+// HealthCheck - проверка зависимости для readiness
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type HealthHandler struct {
+	checks       []HealthCheck
+	logger       ErrorLogger
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+type HealthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// CheckResult - результат проверки без текста ошибки: /readyz доступен без авторизации,
+// а ошибки драйверов содержат адреса и имена баз
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+// NewHealthHandler - logger получает ошибки проверок, может быть nil
+func NewHealthHandler(logger ErrorLogger, checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{
+		checks:  checks,
+		logger:  logger,
+		timeout: defaultCheckTimeout,
+	}
+}
+
+// SetShuttingDown - переводит readiness в not ready, чтобы балансировщик перестал слать трафик
+func (h *HealthHandler) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+func (h *HealthHandler) Register(g *Group) {
+	g.Get("/livez", h.Livez)
+	g.Get("/readyz", h.Readyz)
+	g.Get("/health", h.Readyz)
+}
+
+// Livez - процесс жив и обслуживает запросы; зависимости не проверяются
+func (h *HealthHandler) Livez(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, HealthResponse{Status: "ok"})
+}
+
+// Readyz - готовность принимать трафик: все проверки зависимостей прошли и сервер не останавливается
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	if h.shuttingDown.Load() {
+		writeHealth(w, http.StatusServiceUnavailable, HealthResponse{Status: "shutting_down"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+
+	results := make(map[string]CheckResult, len(h.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func(check HealthCheck) {
+			defer wg.Done()
+
+			start := time.Now()
+			err := check.Check(ctx)
+			result := CheckResult{
+				Status:    "ok",
+				LatencyMs: float64(time.Since(start).Nanoseconds()) / 1e6,
+			}
+			if err != nil {
+				result.Status = "fail"
+				if h.logger != nil {
+					h.logger.LogError(ctx, "Readiness check "+check.Name+":", err)
+				}
+			}
+
+			mu.Lock()
+			results[check.Name] = result
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	response := HealthResponse{Status: "ready", Checks: results}
+	statusCode := http.StatusOK
+	for _, result := range results {
+		if result.Status != "ok" {
+			response.Status = "not_ready"
+			statusCode = http.StatusServiceUnavailable
+			break
+		}
+	}
+
+	writeHealth(w, statusCode, response)
+}
+
+func writeHealth(w http.ResponseWriter, statusCode int, response HealthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveHealth(t *testing.T, h *HealthHandler, path string) (int, HealthResponse) {
+	t.Helper()
+
+	router := NewRouter()
+	router.Mount("", h)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var response HealthResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	return rec.Code, response
+}
+
+// TestHealth_Ready - тест readiness при успешных проверках зависимостей
+func TestHealth_Ready(t *testing.T) {
+	h := NewHealthHandler(nil,
+		HealthCheck{Name: "database", Check: func(ctx context.Context) error { return nil }},
+		HealthCheck{Name: "migrations", Check: func(ctx context.Context) error { return nil }},
+	)
+
+	code, response := serveHealth(t, h, "/readyz")
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", response.Status)
+	assert.Equal(t, "ok", response.Checks["database"].Status)
+	assert.Equal(t, "ok", response.Checks["migrations"].Status)
+}
+
+// errorLogger - запоминает ошибки проверок
+type errorLogger struct {
+	mu     sync.Mutex
+	errors []string
+}
+
+func (l *errorLogger) LogError(_ context.Context, _ string, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errors = append(l.errors, err.Error())
+}
+
+// TestHealth_NotReady - тест readiness при недоступной зависимости; liveness при этом не меняется
+func TestHealth_NotReady(t *testing.T) {
+	logger := &errorLogger{}
+	h := NewHealthHandler(logger,
+		HealthCheck{Name: "database", Check: func(ctx context.Context) error { return errors.New("dial tcp 10.0.0.5:5432: connection refused") }},
+		HealthCheck{Name: "migrations", Check: func(ctx context.Context) error { return nil }},
+	)
+
+	code, response := serveHealth(t, h, "/readyz")
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not_ready", response.Status)
+	assert.Equal(t, "fail", response.Checks["database"].Status)
+	// текст ошибки с адресом базы только в логе
+	assert.Equal(t, []string{"dial tcp 10.0.0.5:5432: connection refused"}, logger.errors)
+	assert.Equal(t, "ok", response.Checks["migrations"].Status)
+
+	code, response = serveHealth(t, h, "/livez")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", response.Status)
+}
+
+// TestHealth_ShuttingDown - тест перехода в not ready при остановке
+func TestHealth_ShuttingDown(t *testing.T) {
+	h := NewHealthHandler(nil, HealthCheck{Name: "database", Check: func(ctx context.Context) error { return nil }})
+	h.SetShuttingDown()
+
+	code, response := serveHealth(t, h, "/readyz")
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "shutting_down", response.Status)
+}
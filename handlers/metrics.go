@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+)
+
+type RequestObserver interface {
+	ObserveRequest(method, route string, statusCode int, duration time.Duration)
+}
+
+// MetricsMiddleware - замеряет запросы с меткой шаблона маршрута (/chats/{id}), а не сырого пути.
+// Запрос, завершившийся паникой, учитывается как 500 независимо от положения в цепочке.
+func MetricsMiddleware(observer RequestObserver) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+			completed := false
+			defer func() {
+				statusCode := wrapped.statusCode
+				if !completed {
+					statusCode = http.StatusInternalServerError
+				}
+
+				route := ""
+				if rc := RouteContextFrom(r.Context()); rc != nil {
+					route = rc.Pattern
+				}
+				observer.ObserveRequest(metricMethod(r.Method), route, statusCode, time.Since(start))
+			}()
+
+			next(wrapped, r)
+			completed = true
+		}
+	}
+}
+
+// metricMethod - метод для метки метрик: произвольные методы клиентов сводятся к OTHER,
+// иначе каждый из них заводит новые ряды
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
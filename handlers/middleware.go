@@ -5,27 +5,56 @@ import (
 	"time"
 )
 
-func LoggingMiddleware(next http.HandlerFunc, logger Logger) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
+// MaxBodySize - наибольший размер тела запроса, которое middleware читает целиком
+const MaxBodySize = 1 << 20
 
-		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+// Middleware - обёртка над обработчиком
+type Middleware func(next http.HandlerFunc) http.HandlerFunc
 
-		next(wrapped, r)
+// Chain - оборачивает обработчик в middleware; первый в списке выполняется первым
+func Chain(handler http.HandlerFunc, middleware ...Middleware) http.HandlerFunc {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+func LoggingMiddleware(logger Logger) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+			next(wrapped, r)
 
-		duration := time.Since(start)
-		durationMs := float64(duration.Nanoseconds()) / 1e6
+			duration := time.Since(start)
+			durationMs := float64(duration.Nanoseconds()) / 1e6
 
-		logger.Log(r.Method, r.RequestURI, r.RemoteAddr, wrapped.statusCode, durationMs)
+			logger.Log(r.Context(), r.Method, redact(r, r.RequestURI), r.RemoteAddr, wrapped.statusCode, durationMs)
+		}
 	}
 }
 
 type responseWriter struct {
 	http.ResponseWriter
-	statusCode int
+	statusCode  int
+	wroteHeader bool
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
-	rw.statusCode = code
+	if !rw.wroteHeader {
+		rw.statusCode = code
+		rw.wroteHeader = true
+	}
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	return rw.ResponseWriter.Write(b)
+}
+
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
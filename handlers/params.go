@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type param struct {
+	name  string
+	value string
+}
+
+// Params - значения параметров пути, извлечённые роутером
+type Params []param
+
+func (p *Params) add(name, value string) {
+	*p = append(*p, param{name: name, value: value})
+}
+
+func (p Params) Get(name string) (string, bool) {
+	for _, param := range p {
+		if param.name == name {
+			return param.value, true
+		}
+	}
+	return "", false
+}
+
+// RouteContext - информация о найденном маршруте, доступная обработчикам и middleware
+type RouteContext struct {
+	Pattern string
+	Params  Params
+	// Secret - параметры пути, значения которых не попадают в логи и трассы
+	Secret []string
+}
+
+type routeContextKey struct{}
+
+func withRouteContext(ctx context.Context, rc *RouteContext) context.Context {
+	return context.WithValue(ctx, routeContextKey{}, rc)
+}
+
+func RouteContextFrom(ctx context.Context) *RouteContext {
+	rc, _ := ctx.Value(routeContextKey{}).(*RouteContext)
+	return rc
+}
+
+// SecretParam - middleware маршрута с секретом в пути, например токеном: в логах и трассах
+// значение параметра name заменяется на "***"
+func SecretParam(name string) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if rc := RouteContextFrom(r.Context()); rc != nil {
+				rc.Secret = append(rc.Secret, name)
+			}
+			next(w, r)
+		}
+	}
+}
+
+// redact - path без значений секретных параметров маршрута
+func redact(r *http.Request, path string) string {
+	rc := RouteContextFrom(r.Context())
+	if rc == nil {
+		return path
+	}
+	for _, name := range rc.Secret {
+		if value, ok := rc.Params.Get(name); ok && value != "" {
+			path = strings.ReplaceAll(path, value, "***")
+		}
+	}
+	return path
+}
+
+// PathParam - значение параметра пути, например PathParam(r, "id") для /chats/{id}
+func PathParam(r *http.Request, name string) string {
+	rc := RouteContextFrom(r.Context())
+	if rc == nil {
+		return ""
+	}
+	value, _ := rc.Params.Get(name)
+	return value
+}
+
+// PathParamUint - параметр пути как положительный идентификатор
+func PathParamUint(r *http.Request, name string) (uint, error) {
+	value := PathParam(r, name)
+	if value == "" {
+		return 0, fmt.Errorf("missing path parameter %q", name)
+	}
+
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s format", name)
+	}
+
+	return uint(id), nil
+}
+
+// PathParamInt - параметр пути как целое число
+func PathParamInt(r *http.Request, name string) (int, error) {
+	value := PathParam(r, name)
+	if value == "" {
+		return 0, fmt.Errorf("missing path parameter %q", name)
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s format", name)
+	}
+
+	return n, nil
+}
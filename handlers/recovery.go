@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+var recoveredPanics atomic.Int64
+
+// RecoveredPanics - количество паник, перехваченных RecoveryMiddleware с момента запуска
+func RecoveredPanics() int64 {
+	return recoveredPanics.Load()
+}
+
+// RecoveryMiddleware - превращает панику обработчика в ответ 500 и пишет отчёт со стеком в лог
+func RecoveryMiddleware(logger Logger) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				if err, ok := recovered.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(recovered)
+				}
+
+				recoveredPanics.Add(1)
+
+				route := ""
+				if rc := RouteContextFrom(r.Context()); rc != nil {
+					route = rc.Pattern
+				}
+				logger.LogPanic(r.Context(), r.Method, route, recovered, debug.Stack())
+
+				if !wrapped.wroteHeader {
+					writeProblem(w, r, http.StatusInternalServerError, "internal server error")
+				}
+			}()
+
+			next(wrapped, r)
+		}
+	}
+}
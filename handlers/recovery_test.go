@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"chat-api/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type panicRecord struct {
+	method, route, requestID string
+	recovered                any
+	stack                    []byte
+}
+
+type recordingLogger struct {
+	nopLogger
+	panics []panicRecord
+}
+
+func (l *recordingLogger) LogPanic(ctx context.Context, method, route string, recovered any, stack []byte) {
+	l.panics = append(l.panics, panicRecord{method, route, logger.RequestIDFromContext(ctx), recovered, stack})
+}
+
+// TestRecoveryMiddleware - тест перехвата паники и ответа 500 в формате problem+json
+func TestRecoveryMiddleware(t *testing.T) {
+	rl := &recordingLogger{}
+	router := NewRouter()
+	router.Use(RequestIDMiddleware(), RecoveryMiddleware(rl))
+	router.Handle(http.MethodGet, "/chats/{id}", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	before := RecoveredPanics()
+
+	req := httptest.NewRequest(http.MethodGet, "/chats/1", nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+
+	var problem Problem
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+	assert.Equal(t, http.StatusInternalServerError, problem.Status)
+	assert.Equal(t, "/chats/1", problem.Instance)
+	assert.Equal(t, "req-42", problem.RequestID)
+
+	require.Len(t, rl.panics, 1)
+	assert.Equal(t, http.MethodGet, rl.panics[0].method)
+	assert.Equal(t, "/chats/{id}", rl.panics[0].route)
+	assert.Equal(t, "req-42", rl.panics[0].requestID)
+	assert.Equal(t, "boom", rl.panics[0].recovered)
+	assert.Contains(t, string(rl.panics[0].stack), "recovery_test.go")
+
+	assert.Equal(t, before+1, RecoveredPanics())
+}
+
+// TestRecoveryMiddleware_HeadersWritten - тест паники после начала ответа: статус не переписывается
+func TestRecoveryMiddleware_HeadersWritten(t *testing.T) {
+	rl := &recordingLogger{}
+	router := NewRouter()
+	router.Use(RecoveryMiddleware(rl))
+	router.Handle(http.MethodGet, "/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		panic("late")
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream", nil))
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Len(t, rl.panics, 1)
+}
+
+// TestRecoveryMiddleware_AbortHandler - тест проброса http.ErrAbortHandler
+func TestRecoveryMiddleware_AbortHandler(t *testing.T) {
+	router := NewRouter()
+	router.Use(RecoveryMiddleware(&recordingLogger{}))
+	router.Handle(http.MethodGet, "/abort", func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/abort", nil))
+	})
+}
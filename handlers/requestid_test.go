@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"chat-api/logger"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRequestIDMiddleware - тест приёма, генерации и возврата X-Request-ID
+func TestRequestIDMiddleware(t *testing.T) {
+	var fromContext string
+	router := NewRouter()
+	router.Use(RequestIDMiddleware())
+	router.Handle(http.MethodGet, "/health", func(w http.ResponseWriter, r *http.Request) {
+		fromContext = logger.RequestIDFromContext(r.Context())
+	})
+
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{"accepted", "client-req-1", true},
+		{"generated", "", false},
+		{"too long", strings.Repeat("a", 200), false},
+		{"unsafe characters", "bad id\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/health", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			returned := rec.Header().Get(RequestIDHeader)
+			assert.NotEmpty(t, returned)
+			assert.Equal(t, returned, fromContext)
+			if tt.keep {
+				assert.Equal(t, tt.incoming, returned)
+			} else {
+				assert.NotEqual(t, tt.incoming, returned)
+				assert.Len(t, returned, 32)
+			}
+		})
+	}
+}
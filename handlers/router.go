@@ -4,11 +4,12 @@ import (
 	"chat-api/models"
 	"context"
 	"net/http"
-	"strings"
+	"time"
 )
 
 type Logger interface {
-	Log(method, path, remoteAddr string, statusCode int, durationMs float64)
+	Log(ctx context.Context, method, path, remoteAddr string, statusCode int, durationMs float64)
+	LogPanic(ctx context.Context, method, route string, recovered any, stack []byte)
 }
 
 type ChatService interface {
@@ -16,97 +17,91 @@ type ChatService interface {
 	GetChat(ctx context.Context, id uint, limit int) (*models.Chat, error)
 	DeleteChat(ctx context.Context, id uint) error
 	SendMessage(ctx context.Context, chatID uint, text string) (*models.Message, error)
+	ListMessages(ctx context.Context, chatID uint, before uint, limit int) ([]models.Message, error)
+	SetSlowMode(ctx context.Context, chatID uint, interval time.Duration) error
 }
 
 type Router struct {
-	routes map[string]http.HandlerFunc
-	logger Logger
+	tree       *node
+	middleware []Middleware
+	handler    http.HandlerFunc
 }
 
-func New(service ChatService, logger Logger) *Router {
-	router := &Router{
-		routes: make(map[string]http.HandlerFunc),
-		logger: logger,
-	}
+func New(service ChatService, logger Logger, modules ...Module) *Router {
+	router := NewRouter()
+	router.Use(RequestIDMiddleware(), LoggingMiddleware(logger), RecoveryMiddleware(logger))
 
-	handler := NewChatHandler(service)
-	routes := RegisterChatRoutes(handler)
-
-	for _, route := range routes {
-		key := route.Method + " " + route.Path
-		router.routes[key] = route.Handler
+	router.Mount("", ChatModule(NewChatHandler(service)))
+	for _, module := range modules {
+		router.Mount("", module)
 	}
 
 	return router
 }
 
-func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	handler := rt.findHandler(r)
-	if handler != nil {
-		handler = rt.withMiddleware(handler)
-		handler(w, r)
-		return
+// NewRouter - пустой роутер без маршрутов и middleware
+func NewRouter() *Router {
+	router := &Router{
+		tree: newNode(),
 	}
+	router.handler = router.dispatch
 
-	http.NotFound(w, r)
+	return router
 }
 
-func (rt *Router) findHandler(r *http.Request) http.HandlerFunc {
-	method := r.Method
-	path := r.URL.Path
-
-	key := method + " " + path
-	if handler, exists := rt.routes[key]; exists {
-		return handler
-	}
-
-	for route, handler := range rt.routes {
-		if rt.matchRoute(route, method, path) {
-			return handler
-		}
-	}
-
-	return nil
+// Use - добавляет middleware, оборачивающие каждый запрос, включая ответы 404 и 405.
+// Цепочка собирается один раз при вызове Use, а не на каждый запрос.
+func (rt *Router) Use(middleware ...Middleware) {
+	rt.middleware = append(rt.middleware, middleware...)
+	rt.handler = Chain(rt.dispatch, rt.middleware...)
 }
 
-func (rt *Router) matchRoute(route, method, path string) bool {
-	routeParts := strings.Split(route, " ")
-	if len(routeParts) != 2 || routeParts[0] != method {
-		return false
+// Group - группа маршрутов с общим префиксом и middleware
+func (rt *Router) Group(prefix string, middleware ...Middleware) *Group {
+	return &Group{
+		router:     rt,
+		prefix:     cleanPrefix(prefix),
+		middleware: middleware,
 	}
+}
 
-	routePath := routeParts[1]
+// Mount - регистрирует маршруты модуля в группе с префиксом
+func (rt *Router) Mount(prefix string, module Module, middleware ...Middleware) {
+	module.Register(rt.Group(prefix, middleware...))
+}
 
-	if routePath == path {
-		return true
+// Handle - регистрирует обработчик. Конфликтующие или повторные маршруты - ошибка программиста, поэтому panic.
+func (rt *Router) Handle(method, pattern string, handler http.HandlerFunc, middleware ...Middleware) {
+	if err := rt.tree.insert(method, pattern, Chain(handler, middleware...)); err != nil {
+		panic(err)
 	}
-
-	return rt.matchDynamicRoute(routePath, path)
 }
 
-func (rt *Router) matchDynamicRoute(routePath, actualPath string) bool {
-	routeSegments := strings.Split(routePath, "/")
-	actualSegments := strings.Split(actualPath, "/")
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc := &RouteContext{}
+	r = r.WithContext(withRouteContext(r.Context(), rc))
+	rt.handler(w, r)
+}
 
-	if len(routeSegments) != len(actualSegments) {
-		return false
+func (rt *Router) dispatch(w http.ResponseWriter, r *http.Request) {
+	rc := RouteContextFrom(r.Context())
+	n := rt.tree.lookup(r.URL.Path, &rc.Params)
+	if n == nil {
+		http.NotFound(w, r)
+		return
 	}
 
-	for i, routeSeg := range routeSegments {
-		actualSeg := actualSegments[i]
-
-		if strings.HasPrefix(routeSeg, "{") && strings.HasSuffix(routeSeg, "}") {
-			continue
-		}
-
-		if routeSeg != actualSeg {
-			return false
+	handler := n.handler(r.Method)
+	if handler == nil {
+		w.Header().Set("Allow", n.allow)
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
 		}
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	return true
-}
-
-func (rt *Router) withMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	return LoggingMiddleware(next, rt.logger)
+	rc.Pattern = n.pattern
+	handler(w, r)
 }
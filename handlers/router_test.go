@@ -0,0 +1,376 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Log(ctx context.Context, method, path, remoteAddr string, statusCode int, durationMs float64) {
+}
+
+func (nopLogger) LogPanic(ctx context.Context, method, route string, recovered any, stack []byte) {}
+
+func newTestRouter() *Router {
+	return NewRouter()
+}
+
+func echoPattern(w http.ResponseWriter, r *http.Request) {
+	rc := RouteContextFrom(r.Context())
+	w.Write([]byte(rc.Pattern))
+}
+
+// TestRouter_Params - тест извлечения параметров пути в контекст запроса
+func TestRouter_Params(t *testing.T) {
+	router := newTestRouter()
+
+	var chatID, messageID uint
+	router.Handle(http.MethodGet, "/chats/{id}/messages/{messageId}", func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		chatID, err = PathParamUint(r, "id")
+		require.NoError(t, err)
+		messageID, err = PathParamUint(r, "messageId")
+		require.NoError(t, err)
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/chats/12/messages/345", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, uint(12), chatID)
+	assert.Equal(t, uint(345), messageID)
+}
+
+// TestRouter_Matching - тест сопоставления путей с шаблонами и приоритета статических сегментов
+func TestRouter_Matching(t *testing.T) {
+	router := newTestRouter()
+	router.Handle(http.MethodGet, "/health", echoPattern)
+	router.Handle(http.MethodGet, "/chats/{id}", echoPattern)
+	router.Handle(http.MethodGet, "/chats/search", echoPattern)
+	router.Handle(http.MethodGet, "/chats/{id}/messages", echoPattern)
+	router.Handle(http.MethodGet, "/static/{path...}", echoPattern)
+	router.Handle(http.MethodGet, "/chats/{id}/files/{path...}", echoPattern)
+
+	tests := []struct {
+		path    string
+		code    int
+		pattern string
+	}{
+		{"/health", http.StatusOK, "/health"},
+		{"/chats/1", http.StatusOK, "/chats/{id}"},
+		{"/chats/search", http.StatusOK, "/chats/search"},
+		{"/chats/1/messages", http.StatusOK, "/chats/{id}/messages"},
+		{"/chats/search/messages", http.StatusOK, "/chats/{id}/messages"},
+		{"/static/css/app.css", http.StatusOK, "/static/{path...}"},
+		{"/static/", http.StatusOK, "/static/{path...}"},
+		{"/chats/7/files/a/b.txt", http.StatusOK, "/chats/{id}/files/{path...}"},
+		{"/chats", http.StatusNotFound, ""},
+		{"/chats/1/unknown", http.StatusNotFound, ""},
+		{"/unknown", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.code, rec.Code)
+			if tt.code == http.StatusOK {
+				assert.Equal(t, tt.pattern, rec.Body.String())
+			}
+		})
+	}
+}
+
+// TestRouter_Wildcard - тест захвата остатка пути wildcard-параметром
+func TestRouter_Wildcard(t *testing.T) {
+	router := newTestRouter()
+
+	var path string
+	router.Handle(http.MethodGet, "/static/{path...}", func(w http.ResponseWriter, r *http.Request) {
+		path = PathParam(r, "path")
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/static/css/app.css", nil))
+	assert.Equal(t, "css/app.css", path)
+}
+
+// TestRouter_InvalidParam - тест ошибки типизированного доступа к параметру
+func TestRouter_InvalidParam(t *testing.T) {
+	router := newTestRouter()
+
+	var err error
+	router.Handle(http.MethodGet, "/chats/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, err = PathParamUint(r, "id")
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/chats/abc", nil))
+	assert.EqualError(t, err, "invalid id format")
+}
+
+// TestRouter_Conflicts - тест отказа регистрировать конфликтующие маршруты
+func TestRouter_Conflicts(t *testing.T) {
+	router := newTestRouter()
+	router.Handle(http.MethodGet, "/chats/{id}", echoPattern)
+
+	assert.Panics(t, func() { router.Handle(http.MethodGet, "/chats/{id}", echoPattern) })
+	assert.Panics(t, func() { router.Handle(http.MethodGet, "/chats/{chatId}/messages", echoPattern) })
+	assert.Panics(t, func() { router.Handle(http.MethodGet, "/files/{path...}/tail", echoPattern) })
+	assert.NotPanics(t, func() { router.Handle(http.MethodDelete, "/chats/{id}", echoPattern) })
+}
+
+// TestRouter_MethodNotAllowed - тест ответа 405 с заголовком Allow
+func TestRouter_MethodNotAllowed(t *testing.T) {
+	router := New(nil, nopLogger{})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/chats/1", nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "DELETE, GET, HEAD, OPTIONS", rec.Header().Get("Allow"))
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/chats", nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "OPTIONS, POST", rec.Header().Get("Allow"))
+}
+
+// TestRouter_Options - тест автоматического ответа на OPTIONS
+func TestRouter_Options(t *testing.T) {
+	router := New(nil, nopLogger{})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, "/chats/1/messages", nil))
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS, POST", rec.Header().Get("Allow"))
+}
+
+// TestRouter_Head - тест обслуживания HEAD обработчиком GET
+func TestRouter_Head(t *testing.T) {
+	router := New(nil, nopLogger{}, NewHealthHandler(nil))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/livez", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/chats", nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func recordMiddleware(name string, calls *[]string) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			*calls = append(*calls, name)
+			next(w, r)
+		}
+	}
+}
+
+// TestRouter_MiddlewareOrder - тест порядка глобальных, групповых и маршрутных middleware
+func TestRouter_MiddlewareOrder(t *testing.T) {
+	var calls []string
+	router := NewRouter()
+	router.Use(recordMiddleware("global", &calls))
+
+	v1 := router.Group("/v1", recordMiddleware("v1", &calls))
+	admin := v1.Group("admin", recordMiddleware("admin", &calls))
+	admin.Get("/chats/{id}", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler:"+PathParam(r, "id"))
+	}, recordMiddleware("route", &calls))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/admin/chats/5", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{"global", "v1", "admin", "route", "handler:5"}, calls)
+
+	calls = nil
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/missing", nil))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, []string{"global"}, calls)
+}
+
+// TestRouter_Modules - тест подключения модулей с собственными маршрутами и middleware
+func TestRouter_Modules(t *testing.T) {
+	var calls []string
+	module := ModuleFunc(func(g *Group) {
+		g.Use(recordMiddleware("module", &calls))
+		g.Routes([]RouteDefinition{
+			{Method: http.MethodGet, Path: "/status", Handler: echoPattern},
+		})
+	})
+
+	router := New(nil, nopLogger{}, module)
+	router.Mount("/admin", module)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	assert.Equal(t, "/status", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/status", nil))
+	assert.Equal(t, "/admin/status", rec.Body.String())
+
+	assert.Equal(t, []string{"module", "module"}, calls)
+}
+
+// TestRouter_RoutePatternAfterNext - тест доступа middleware к шаблону маршрута после обработки
+func TestRouter_RoutePatternAfterNext(t *testing.T) {
+	var pattern string
+	router := NewRouter()
+	router.Use(func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			next(w, r)
+			pattern = RouteContextFrom(r.Context()).Pattern
+		}
+	})
+	router.Handle(http.MethodGet, "/chats/{id}", echoPattern)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/chats/9", nil))
+	assert.Equal(t, "/chats/{id}", pattern)
+}
+
+// legacyRouter - прежняя реализация поиска маршрута, оставлена для сравнения в бенчмарках
+type legacyRouter struct {
+	routes map[string]http.HandlerFunc
+}
+
+func (rt *legacyRouter) findHandler(r *http.Request) http.HandlerFunc {
+	key := r.Method + " " + r.URL.Path
+	if handler, exists := rt.routes[key]; exists {
+		return handler
+	}
+
+	for route, handler := range rt.routes {
+		routeParts := strings.Split(route, " ")
+		if len(routeParts) != 2 || routeParts[0] != r.Method {
+			continue
+		}
+		if routeParts[1] == r.URL.Path || legacyMatchDynamicRoute(routeParts[1], r.URL.Path) {
+			return handler
+		}
+	}
+
+	return nil
+}
+
+func legacyMatchDynamicRoute(routePath, actualPath string) bool {
+	routeSegments := strings.Split(routePath, "/")
+	actualSegments := strings.Split(actualPath, "/")
+
+	if len(routeSegments) != len(actualSegments) {
+		return false
+	}
+
+	for i, routeSeg := range routeSegments {
+		if strings.HasPrefix(routeSeg, "{") && strings.HasSuffix(routeSeg, "}") {
+			continue
+		}
+		if routeSeg != actualSegments[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func legacyExtractIDFromPath(path string) (uint, error) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 2 {
+		return 0, fmt.Errorf("invalid path format")
+	}
+
+	id, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ID format")
+	}
+
+	return uint(id), nil
+}
+
+// benchRoutes - маршруты API плюс дополнительные ресурсы, чтобы таблица была ближе к реальной
+func benchRoutes() []RouteDefinition {
+	routes := RegisterChatRoutes(NewChatHandler(nil))
+	for i := 0; i < 20; i++ {
+		routes = append(routes,
+			RouteDefinition{Method: http.MethodGet, Path: fmt.Sprintf("/resource%d/{id}", i), Handler: echoPattern},
+			RouteDefinition{Method: http.MethodPost, Path: fmt.Sprintf("/resource%d/{id}/items", i), Handler: echoPattern},
+		)
+	}
+	return routes
+}
+
+func benchmarkTree(b *testing.B, method, path string) {
+	router := newTestRouter()
+	for _, route := range benchRoutes() {
+		router.Handle(route.Method, route.Path, route.Handler)
+	}
+
+	req := httptest.NewRequest(method, path, nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		rc := &RouteContext{}
+		n := router.tree.lookup(req.URL.Path, &rc.Params)
+		if n == nil || n.handler(req.Method) == nil {
+			b.Fatal("route not found")
+		}
+		if value, ok := rc.Params.Get("id"); ok {
+			if _, err := strconv.ParseUint(value, 10, 32); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func benchmarkLegacy(b *testing.B, method, path string) {
+	router := &legacyRouter{routes: make(map[string]http.HandlerFunc)}
+	for _, route := range benchRoutes() {
+		router.routes[route.Method+" "+route.Path] = route.Handler
+	}
+
+	req := httptest.NewRequest(method, path, nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if router.findHandler(req) == nil {
+			b.Fatal("route not found")
+		}
+		if strings.Count(path, "/") > 1 {
+			if _, err := legacyExtractIDFromPath(path); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkRouter_Static_Tree(b *testing.B)   { benchmarkTree(b, http.MethodPost, "/chats") }
+func BenchmarkRouter_Static_Legacy(b *testing.B) { benchmarkLegacy(b, http.MethodPost, "/chats") }
+
+func BenchmarkRouter_Param_Tree(b *testing.B)   { benchmarkTree(b, http.MethodGet, "/chats/42") }
+func BenchmarkRouter_Param_Legacy(b *testing.B) { benchmarkLegacy(b, http.MethodGet, "/chats/42") }
+
+func BenchmarkRouter_Nested_Tree(b *testing.B) {
+	benchmarkTree(b, http.MethodPost, "/chats/42/messages")
+}
+func BenchmarkRouter_Nested_Legacy(b *testing.B) {
+	benchmarkLegacy(b, http.MethodPost, "/chats/42/messages")
+}
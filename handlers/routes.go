@@ -5,26 +5,30 @@ import (
 )
 
 type RouteDefinition struct {
-	Method  string
-	Path    string
-	Handler http.HandlerFunc
+	Method     string
+	Path       string
+	Handler    http.HandlerFunc
+	Middleware []Middleware
 }
 
 type IChatHandler interface {
-	HealthCheck(w http.ResponseWriter, r *http.Request)
 	CreateChat(w http.ResponseWriter, r *http.Request)
 	SendMessage(w http.ResponseWriter, r *http.Request)
 	GetMessages(w http.ResponseWriter, r *http.Request)
+	ListMessages(w http.ResponseWriter, r *http.Request)
 	DeleteChat(w http.ResponseWriter, r *http.Request)
+	SetSlowMode(w http.ResponseWriter, r *http.Request)
+}
+
+// ChatModule - маршруты чатов как модуль роутера
+func ChatModule(h IChatHandler) Module {
+	return ModuleFunc(func(g *Group) {
+		g.Routes(RegisterChatRoutes(h))
+	})
 }
 
 func RegisterChatRoutes(h IChatHandler) []RouteDefinition {
 	return []RouteDefinition{
-		{
-			Method:  "GET",
-			Path:    "/health",
-			Handler: h.HealthCheck,
-		},
 		{
 			Method:  "POST",
 			Path:    "/chats",
@@ -35,6 +39,11 @@ func RegisterChatRoutes(h IChatHandler) []RouteDefinition {
 			Path:    "/chats/{id}/messages",
 			Handler: h.SendMessage,
 		},
+		{
+			Method:  "GET",
+			Path:    "/chats/{id}/messages",
+			Handler: h.ListMessages,
+		},
 		{
 			Method:  "GET",
 			Path:    "/chats/{id}",
@@ -45,5 +54,10 @@ func RegisterChatRoutes(h IChatHandler) []RouteDefinition {
 			Path:    "/chats/{id}",
 			Handler: h.DeleteChat,
 		},
+		{
+			Method:  "PUT",
+			Path:    "/chats/{id}/slow-mode",
+			Handler: h.SetSlowMode,
+		},
 	}
 }
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+
+	"chat-api/logger"
+	"chat-api/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingMiddleware - спан на каждый запрос, продолжающий трассу из входящего traceparent
+func TracingMiddleware() Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx := tracing.Extract(r.Context(), r.Header)
+			ctx, span := tracing.Tracer().Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", r.Method),
+					attribute.String("url.path", r.URL.Path),
+					attribute.String("client.address", r.RemoteAddr),
+				),
+			)
+			defer span.End()
+
+			if requestID := logger.RequestIDFromContext(ctx); requestID != "" {
+				span.SetAttributes(attribute.String("request.id", requestID))
+			}
+
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+			completed := false
+			defer func() {
+				statusCode := wrapped.statusCode
+				if !completed {
+					statusCode = http.StatusInternalServerError
+				}
+
+				if rc := RouteContextFrom(r.Context()); rc != nil && rc.Pattern != "" {
+					span.SetName(r.Method + " " + rc.Pattern)
+					span.SetAttributes(attribute.String("http.route", rc.Pattern))
+					if len(rc.Secret) > 0 {
+						span.SetAttributes(attribute.String("url.path", redact(r, r.URL.Path)))
+					}
+				}
+				span.SetAttributes(attribute.Int("http.response.status_code", statusCode))
+				if statusCode >= http.StatusInternalServerError {
+					span.SetStatus(codes.Error, http.StatusText(statusCode))
+				}
+			}()
+
+			next(wrapped, r.WithContext(ctx))
+			completed = true
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"chat-api/tracing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestTracingMiddleware - тест спана запроса с продолжением трассы из traceparent
+func TestTracingMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { provider.Shutdown(t.Context()) })
+
+	var innerTraceID trace.TraceID
+	router := NewRouter()
+	router.Use(TracingMiddleware())
+	router.Handle(http.MethodGet, "/chats/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, span := tracing.Tracer().Start(r.Context(), "Repository.Get")
+		innerTraceID = span.SpanContext().TraceID()
+		span.End()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/chats/7", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	server := spans[1]
+	assert.Equal(t, "GET /chats/{id}", server.Name())
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+	assert.Equal(t, server.SpanContext().TraceID(), innerTraceID)
+	assert.Equal(t, server.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, "Error", server.Status().Code.String())
+
+	header := http.Header{}
+	ctx := trace.ContextWithSpanContext(t.Context(), server.SpanContext())
+	tracing.Inject(ctx, header)
+	assert.Contains(t, header.Get("traceparent"), "4bf92f3577b34da6a3ce929d0e0e4736")
+}
+
+type pathLogger struct {
+	nopLogger
+	paths []string
+}
+
+func (l *pathLogger) Log(ctx context.Context, method, path, remoteAddr string, statusCode int, durationMs float64) {
+	l.paths = append(l.paths, path)
+}
+
+// TestSecretParam - токен из пути не попадает ни в лог запросов, ни в спан
+func TestSecretParam(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { provider.Shutdown(t.Context()) })
+
+	logger := &pathLogger{}
+	router := NewRouter()
+	router.Use(LoggingMiddleware(logger), TracingMiddleware())
+	router.Handle(http.MethodPost, "/hooks/{token}", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "whin_secret", PathParam(r, "token"))
+	}, SecretParam("token"))
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/hooks/whin_secret?x=1", nil))
+
+	require.Equal(t, []string{"/hooks/***?x=1"}, logger.paths)
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	for _, attr := range spans[0].Attributes() {
+		assert.NotContains(t, attr.Value.Emit(), "whin_secret", string(attr.Key))
+	}
+}
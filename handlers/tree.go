@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// node - узел дерева маршрутов. Каждый уровень дерева соответствует одному сегменту пути,
+// поэтому поиск идёт за O(длины пути) и не зависит от количества зарегистрированных маршрутов.
+type node struct {
+	static   map[string]*node
+	param    *node
+	wildcard *node
+
+	// name - имя параметра для узлов {id} и {path...}
+	name string
+
+	pattern  string
+	handlers map[string]http.HandlerFunc
+	// allow - значение заголовка Allow для пути, пересчитывается при регистрации
+	allow string
+}
+
+func newNode() *node {
+	return &node{static: make(map[string]*node)}
+}
+
+// insert - добавляет маршрут. Поддерживаются статические сегменты, параметры {name}
+// и завершающий wildcard {name...}, захватывающий остаток пути.
+func (n *node) insert(method, pattern string, handler http.HandlerFunc) error {
+	current := n
+	segments := splitPath(pattern)
+
+	for i, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "...}"):
+			if i != len(segments)-1 {
+				return fmt.Errorf("route %q: wildcard must be the last segment", pattern)
+			}
+			name := segment[1 : len(segment)-4]
+			if current.wildcard == nil {
+				current.wildcard = newNode()
+				current.wildcard.name = name
+			} else if current.wildcard.name != name {
+				return fmt.Errorf("route %q: wildcard {%s...} conflicts with {%s...}", pattern, name, current.wildcard.name)
+			}
+			current = current.wildcard
+
+		case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+			name := segment[1 : len(segment)-1]
+			if name == "" {
+				return fmt.Errorf("route %q: empty parameter name", pattern)
+			}
+			if current.param == nil {
+				current.param = newNode()
+				current.param.name = name
+			} else if current.param.name != name {
+				return fmt.Errorf("route %q: parameter {%s} conflicts with {%s}", pattern, name, current.param.name)
+			}
+			current = current.param
+
+		default:
+			child, ok := current.static[segment]
+			if !ok {
+				child = newNode()
+				current.static[segment] = child
+			}
+			current = child
+		}
+	}
+
+	if current.handlers == nil {
+		current.handlers = make(map[string]http.HandlerFunc)
+	}
+	if _, exists := current.handlers[method]; exists {
+		return fmt.Errorf("route %s %s already registered", method, pattern)
+	}
+
+	current.pattern = pattern
+	current.handlers[method] = handler
+	current.allow = allowedMethods(current.handlers)
+	return nil
+}
+
+// handler - обработчик для метода; HEAD обслуживается GET-обработчиком, если отдельного нет
+func (n *node) handler(method string) http.HandlerFunc {
+	if handler, ok := n.handlers[method]; ok {
+		return handler
+	}
+	if method == http.MethodHead {
+		return n.handlers[http.MethodGet]
+	}
+	return nil
+}
+
+func allowedMethods(handlers map[string]http.HandlerFunc) string {
+	methods := make([]string, 0, len(handlers)+2)
+	for method := range handlers {
+		methods = append(methods, method)
+	}
+	if _, ok := handlers[http.MethodGet]; ok {
+		if _, ok := handlers[http.MethodHead]; !ok {
+			methods = append(methods, http.MethodHead)
+		}
+	}
+	if _, ok := handlers[http.MethodOptions]; !ok {
+		methods = append(methods, http.MethodOptions)
+	}
+	sort.Strings(methods)
+	return strings.Join(methods, ", ")
+}
+
+// lookup - ищет узел для пути. Статические сегменты имеют приоритет над параметрами,
+// параметры - над wildcard; при неудаче поиск откатывается к менее точному варианту.
+func (n *node) lookup(path string, params *Params) *node {
+	path = strings.TrimPrefix(path, "/")
+
+	if path == "" {
+		if n.handlers != nil {
+			return n
+		}
+		if n.wildcard != nil && n.wildcard.handlers != nil {
+			params.add(n.wildcard.name, "")
+			return n.wildcard
+		}
+		return nil
+	}
+
+	segment, rest := path, ""
+	if i := strings.IndexByte(path, '/'); i >= 0 {
+		segment, rest = path[:i], path[i:]
+	}
+
+	if child, ok := n.static[segment]; ok {
+		if found := child.lookup(rest, params); found != nil {
+			return found
+		}
+	}
+
+	if n.param != nil && segment != "" {
+		mark := len(*params)
+		params.add(n.param.name, segment)
+		if found := n.param.lookup(rest, params); found != nil {
+			return found
+		}
+		*params = (*params)[:mark]
+	}
+
+	if n.wildcard != nil && n.wildcard.handlers != nil {
+		params.add(n.wildcard.name, path)
+		return n.wildcard
+	}
+
+	return nil
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
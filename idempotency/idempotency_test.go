@@ -0,0 +1,224 @@
+package idempotency
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"chat-api/handlers"
+	"chat-api/internal/testutil"
+	"chat-api/models"
+	"chat-api/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newDBStore(t *testing.T) *DBStore {
+	t.Helper()
+	return NewStore(testutil.SQLite(t))
+}
+
+func stores(t *testing.T) map[string]Store {
+	return map[string]Store{
+		"memory": NewMemoryStore(),
+		"sqlite": newDBStore(t),
+	}
+}
+
+func TestStore(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+			key := func(hash string) *models.IdempotencyKey {
+				return &models.IdempotencyKey{Key: "k1", RequestHash: hash, ExpiresAt: now.Add(time.Hour)}
+			}
+
+			first := key("a")
+			existing, err := store.Reserve(ctx, first, now)
+			require.NoError(t, err)
+			assert.Nil(t, existing)
+			assert.Len(t, first.Token, 32)
+
+			existing, err = store.Reserve(ctx, key("b"), now)
+			require.NoError(t, err)
+			require.NotNil(t, existing)
+			assert.True(t, existing.Pending())
+			assert.Equal(t, "a", existing.RequestHash)
+
+			require.NoError(t, store.Complete(ctx, &models.IdempotencyKey{
+				Key: "k1", Token: first.Token, StatusCode: http.StatusCreated, Header: http.Header{"Location": {"/chats/1"}}, Body: []byte(`{"id":1}`),
+				ExpiresAt: now.Add(time.Hour),
+			}))
+			existing, err = store.Reserve(ctx, key("a"), now)
+			require.NoError(t, err)
+			require.NotNil(t, existing)
+			assert.Equal(t, http.StatusCreated, existing.StatusCode)
+			assert.Equal(t, `{"id":1}`, string(existing.Body))
+			assert.Equal(t, "/chats/1", existing.Header.Get("Location"))
+
+			// истёкший ключ занимается заново, и прежний запрос его больше не трогает
+			retry := key("b")
+			existing, err = store.Reserve(ctx, retry, now.Add(2*time.Hour))
+			require.NoError(t, err)
+			assert.Nil(t, existing)
+			assert.NotEqual(t, first.Token, retry.Token)
+			assert.ErrorIs(t, store.Complete(ctx, &models.IdempotencyKey{Key: "k1", Token: first.Token, StatusCode: http.StatusOK}), ErrLeaseLost)
+			assert.ErrorIs(t, store.Release(ctx, "k1", first.Token), ErrLeaseLost)
+			existing, err = store.Reserve(ctx, key("b"), now)
+			require.NoError(t, err)
+			require.NotNil(t, existing)
+			assert.True(t, existing.Pending())
+
+			require.NoError(t, store.Release(ctx, "k1", retry.Token))
+			existing, err = store.Reserve(ctx, key("c"), now)
+			require.NoError(t, err)
+			assert.Nil(t, existing)
+
+			require.NoError(t, store.Prune(ctx, now.Add(2*time.Hour)))
+			existing, err = store.Reserve(ctx, key("d"), now)
+			require.NoError(t, err)
+			assert.Nil(t, existing)
+		})
+	}
+}
+
+// TestDBStore_ReserveReleased - ключ освободили между неудачной вставкой и чтением: Reserve занимает его снова
+func TestDBStore_ReserveReleased(t *testing.T) {
+	store := newDBStore(t)
+	ctx := context.Background()
+	now := time.Now()
+	key := func() *models.IdempotencyKey {
+		return &models.IdempotencyKey{Key: "k1", RequestHash: "a", ExpiresAt: now.Add(time.Minute)}
+	}
+
+	first := key()
+	_, err := store.Reserve(ctx, first, now)
+	require.NoError(t, err)
+
+	released := false
+	err = store.db.Callback().Create().After("gorm:create").Register("test:release", func(tx *gorm.DB) {
+		if tx.Statement.RowsAffected == 0 && !released {
+			released = true
+			require.NoError(t, store.Release(ctx, "k1", first.Token))
+		}
+	})
+	require.NoError(t, err)
+
+	existing, err := store.Reserve(ctx, key(), now)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+	assert.True(t, released)
+}
+
+func TestMiddleware(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now()
+			k := &keys{store: store, ttl: time.Hour, lease: time.Minute, logger: testutil.DiscardLogger{}, now: func() time.Time { return now }}
+
+			calls := 0
+			status := http.StatusCreated
+			client := ""
+			router := handlers.NewRouter()
+			outer := 0
+			router.Use(func(next http.HandlerFunc) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					outer++
+					w.Header().Set("RateLimit-Remaining", strconv.Itoa(100-outer))
+					next(w, r)
+				}
+			})
+			router.Use(k.wrap)
+			router.Handle(http.MethodPost, "/chats/{id}/messages", func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				body, _ := io.ReadAll(r.Body)
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Location", "/chats/1/messages/"+strconv.Itoa(calls))
+				w.WriteHeader(status)
+				w.Header().Set("X-Too-Late", "1")
+				io.WriteString(w, `{"n":`+strconv.Itoa(calls)+`,"text":`+string(body)+`}`)
+			})
+
+			post := func(key, body string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(http.MethodPost, "/chats/1/messages", strings.NewReader(body))
+				if key != "" {
+					req.Header.Set(Header, key)
+				}
+				if client != "" {
+					req = req.WithContext(service.WithMember(req.Context(), client))
+				}
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, req)
+				return rec
+			}
+
+			first := post("abc", `"hi"`)
+			assert.Equal(t, http.StatusCreated, first.Code)
+			assert.Equal(t, `{"n":1,"text":"hi"}`, first.Body.String())
+
+			retry := post("abc", `"hi"`)
+			assert.Equal(t, http.StatusCreated, retry.Code)
+			assert.Equal(t, first.Body.String(), retry.Body.String())
+			assert.Equal(t, "application/json", retry.Header().Get("Content-Type"))
+			assert.Equal(t, "/chats/1/messages/1", retry.Header().Get("Location"))
+			// заголовки внешних middleware выставляются заново, а не берутся из сохранённого ответа
+			assert.Equal(t, "98", retry.Header().Get("RateLimit-Remaining"))
+			assert.Empty(t, retry.Header().Get("X-Too-Late"))
+			assert.Equal(t, "true", retry.Header().Get(ReplayedHeader))
+			assert.Equal(t, 1, calls)
+
+			assert.Equal(t, http.StatusUnprocessableEntity, post("abc", `"other"`).Code)
+			assert.Equal(t, http.StatusCreated, post("", `"hi"`).Code)
+			assert.Equal(t, 2, calls)
+
+			// ответ 5xx не сохраняется, повтор выполняется заново
+			status = http.StatusInternalServerError
+			assert.Equal(t, http.StatusInternalServerError, post("def", `"hi"`).Code)
+			status = http.StatusCreated
+			assert.Equal(t, http.StatusCreated, post("def", `"hi"`).Code)
+			assert.Equal(t, 4, calls)
+
+			// временный отказ тоже не сохраняется
+			status = http.StatusTooManyRequests
+			assert.Equal(t, http.StatusTooManyRequests, post("ghi", `"hi"`).Code)
+			status = http.StatusCreated
+			assert.Equal(t, http.StatusCreated, post("ghi", `"hi"`).Code)
+			assert.Equal(t, 6, calls)
+
+			// у другого клиента тот же ключ - другой запрос
+			client = "user:2"
+			assert.Equal(t, `{"n":7,"text":"hi"}`, post("abc", `"hi"`).Body.String())
+			client = ""
+
+			// пока первый запрос выполняется, повтор получает 409
+			_, err := store.Reserve(context.Background(), &models.IdempotencyKey{
+				Key: scopedKey("", "pending"), RequestHash: requestHash(httptest.NewRequest(http.MethodPost, "/chats/1/messages", nil), []byte(`"hi"`)),
+				ExpiresAt: now.Add(k.lease),
+			}, now)
+			require.NoError(t, err)
+			rec := post("pending", `"hi"`)
+			assert.Equal(t, http.StatusConflict, rec.Code)
+			assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+
+			// экземпляр, занявший ключ, упал: после lease запрос выполняется заново
+			now = now.Add(2 * time.Minute)
+			assert.Equal(t, http.StatusCreated, post("pending", `"hi"`).Code)
+			// сохранённый ответ живёт ttl, а не lease
+			assert.Equal(t, `{"n":1,"text":"hi"}`, post("abc", `"hi"`).Body.String())
+
+			now = now.Add(2 * time.Hour)
+			assert.Equal(t, `{"n":9,"text":"hi"}`, post("abc", `"hi"`).Body.String())
+
+			assert.Equal(t, http.StatusBadRequest, post(strings.Repeat("x", 256), `"hi"`).Code)
+			assert.Equal(t, http.StatusRequestEntityTooLarge, post("big", `"`+strings.Repeat("x", handlers.MaxBodySize)+`"`).Code)
+		})
+	}
+}
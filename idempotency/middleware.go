@@ -0,0 +1,248 @@
+// Package idempotency - повтор POST запроса с тем же заголовком Idempotency-Key получает
+// сохранённый первый ответ, а не выполняет действие ещё раз
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"chat-api/handlers"
+	"chat-api/models"
+	"chat-api/service"
+)
+
+const (
+	Header = "Idempotency-Key"
+	// ReplayedHeader - отмечает ответ, взятый из хранилища
+	ReplayedHeader = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+	// maxResponseSize - ответ больше не сохраняется, и ключ освобождается
+	maxResponseSize = 1 << 20
+	pruneInterval   = time.Minute
+)
+
+type Logger interface {
+	LogError(ctx context.Context, operation string, err error)
+}
+
+type keys struct {
+	store  Store
+	ttl    time.Duration
+	lease  time.Duration
+	logger Logger
+	now    func() time.Time
+
+	mu        sync.Mutex
+	lastPrune time.Time
+}
+
+// Middleware - ответы на POST с Idempotency-Key хранятся ttl. Временные ответы (5xx, 408, 425, 429)
+// и прерванные паникой не сохраняются: повтор выполнится заново.
+// Ключи у каждого клиента свои (service.MemberFromContext), поэтому Identify должен стоять в цепочке раньше.
+// Ключ выполняющегося запроса занят lease: если экземпляр упал, не ответив, повтор выполнится после этого
+func Middleware(store Store, ttl, lease time.Duration, logger Logger) handlers.Middleware {
+	k := &keys{store: store, ttl: ttl, lease: lease, logger: logger, now: time.Now}
+	return k.wrap
+}
+
+func (k *keys) wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(Header)
+		if r.Method != http.MethodPost || key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxKeyLength {
+			http.Error(w, "Idempotency-Key cannot exceed 255 characters", http.StatusBadRequest)
+			return
+		}
+
+		// тело читается целиком ради хеша, поэтому его размер ограничен
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, handlers.MaxBodySize))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, "Request body is too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "Failed to read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := r.Context()
+		now := k.now()
+		k.prune(ctx, now)
+
+		key = scopedKey(service.MemberFromContext(ctx), key)
+		hash := requestHash(r, body)
+		reserved := &models.IdempotencyKey{
+			Key:         key,
+			RequestHash: hash,
+			ExpiresAt:   now.Add(k.lease),
+		}
+		existing, err := k.store.Reserve(ctx, reserved, now)
+		if err != nil {
+			k.logger.LogError(ctx, "Reserve idempotency key:", err)
+			http.Error(w, "Failed to reserve Idempotency-Key", http.StatusInternalServerError)
+			return
+		}
+
+		switch {
+		case existing == nil:
+			k.record(w, r, reserved, next)
+		case existing.RequestHash != hash:
+			http.Error(w, "Idempotency-Key was already used with a different request", http.StatusUnprocessableEntity)
+		case existing.Pending():
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "A request with this Idempotency-Key is still in progress", http.StatusConflict)
+		default:
+			for name, values := range existing.Header {
+				w.Header()[name] = values
+			}
+			w.Header().Set(ReplayedHeader, "true")
+			w.WriteHeader(existing.StatusCode)
+			w.Write(existing.Body)
+		}
+	}
+}
+
+// record - выполняет запрос, занявший ключ, и сохраняет ответ. Если за время запроса аренда истекла
+// и ключ занял повтор, ответ не сохраняется и ключ повтора не трогается
+func (k *keys) record(w http.ResponseWriter, r *http.Request, key *models.IdempotencyKey, next http.HandlerFunc) {
+	recorder := &recorder{ResponseWriter: w, statusCode: http.StatusOK, before: w.Header().Clone()}
+
+	completed := false
+	defer func() {
+		// клиент мог уйти, но ответ всё равно нужно сохранить или освободить ключ
+		ctx := context.WithoutCancel(r.Context())
+
+		var err error
+		if completed && !temporary(recorder.statusCode) && !recorder.overflow {
+			err = k.store.Complete(ctx, &models.IdempotencyKey{
+				Key:        key.Key,
+				Token:      key.Token,
+				StatusCode: recorder.statusCode,
+				Header:     recorder.header(),
+				Body:       recorder.body.Bytes(),
+				ExpiresAt:  k.now().Add(k.ttl),
+			})
+		} else {
+			err = k.store.Release(ctx, key.Key, key.Token)
+		}
+		if err != nil {
+			k.logger.LogError(ctx, "Store idempotent response:", err)
+		}
+	}()
+
+	next(recorder, r)
+	completed = true
+}
+
+// prune - удаляет истёкшие ключи не чаще раза в pruneInterval
+func (k *keys) prune(ctx context.Context, now time.Time) {
+	k.mu.Lock()
+	if now.Sub(k.lastPrune) < pruneInterval {
+		k.mu.Unlock()
+		return
+	}
+	k.lastPrune = now
+	k.mu.Unlock()
+
+	if err := k.store.Prune(ctx, now); err != nil {
+		k.logger.LogError(ctx, "Prune idempotency keys:", err)
+	}
+}
+
+// temporary - ответ зависит от момента запроса, а не от самого запроса: повтор может получить другой
+func temporary(statusCode int) bool {
+	switch statusCode {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests:
+		return true
+	}
+	return statusCode >= http.StatusInternalServerError
+}
+
+// scopedKey - один и тот же Idempotency-Key разных клиентов - разные ключи
+func scopedKey(client, key string) string {
+	sum := sha256.Sum256([]byte(client + "\n" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+// requestHash - запрос с тем же ключом считается повтором, только если совпадают метод, путь и тело
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recorder - пишет ответ клиенту и копирует его для сохранения
+type recorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	// before - заголовки внешних middleware, они выставляются заново при повторе
+	before   http.Header
+	written  http.Header
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (rec *recorder) WriteHeader(code int) {
+	if !rec.wroteHeader {
+		rec.statusCode = code
+		rec.writeHeader()
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+// writeHeader - запоминает заголовки, отправленные клиенту: изменения после WriteHeader до него не доходят
+func (rec *recorder) writeHeader() {
+	rec.wroteHeader = true
+	rec.written = rec.Header().Clone()
+}
+
+// header - заголовки, которые добавил или изменил обработчик
+func (rec *recorder) header() http.Header {
+	written := rec.written
+	if !rec.wroteHeader {
+		written = rec.Header()
+	}
+
+	header := make(http.Header)
+	for name, values := range written {
+		if !slices.Equal(rec.before[name], values) {
+			header[name] = values
+		}
+	}
+	return header
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	if !rec.wroteHeader {
+		rec.writeHeader()
+	}
+	if !rec.overflow {
+		if rec.body.Len()+len(b) > maxResponseSize {
+			rec.overflow = true
+			rec.body.Reset()
+		} else {
+			rec.body.Write(b)
+		}
+	}
+	return rec.ResponseWriter.Write(b)
+}
+
+func (rec *recorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
@@ -0,0 +1,178 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"chat-api/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// reserveAttempts - сколько раз Reserve пробует занять ключ, который освобождается у него на глазах
+const reserveAttempts = 3
+
+// ErrLeaseLost - ключ истёк и его занял повтор: ответ первого запроса не сохраняется
+var ErrLeaseLost = errors.New("idempotency key lease lost")
+
+// Store - хранилище ключей идемпотентности
+type Store interface {
+	// Reserve - занимает ключ за запросом до key.ExpiresAt и записывает в key.Token новый токен.
+	// Если ключ уже занят и не истёк, возвращает его запись и ничего не меняет; истёкший ключ занимается заново
+	Reserve(ctx context.Context, key *models.IdempotencyKey, now time.Time) (*models.IdempotencyKey, error)
+	// Complete - сохраняет ответ на запрос, занявший ключ record.Key с токеном record.Token, и продлевает ключ
+	// до record.ExpiresAt. Если ключ уже занят другим запросом, возвращает ErrLeaseLost
+	Complete(ctx context.Context, record *models.IdempotencyKey) error
+	// Release - освобождает ключ, занятый с токеном token, если ответ сохранять не нужно, и повтор выполнится заново.
+	// Если ключ уже занят другим запросом, возвращает ErrLeaseLost
+	Release(ctx context.Context, key, token string) error
+	// Prune - удаляет истёкшие ключи
+	Prune(ctx context.Context, now time.Time) error
+}
+
+// DBStore - ключи в таблице idempotency_keys; общие для всех экземпляров сервера
+type DBStore struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) *DBStore {
+	return &DBStore{db: db}
+}
+
+func (s *DBStore) Reserve(ctx context.Context, key *models.IdempotencyKey, now time.Time) (*models.IdempotencyKey, error) {
+	db := s.db.WithContext(ctx)
+	key.Token = newToken()
+
+	// между вставкой и чтением занявший ключ запрос может его освободить - тогда ключ занимается снова
+	for range reserveAttempts {
+		if err := db.Where("idempotency_key = ? AND expires_at <= ?", key.Key, now).Delete(&models.IdempotencyKey{}).Error; err != nil {
+			return nil, fmt.Errorf("failed reserve idempotency key: %w", err)
+		}
+
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(key)
+		if result.Error != nil {
+			return nil, fmt.Errorf("failed reserve idempotency key: %w", result.Error)
+		}
+		if result.RowsAffected == 1 {
+			return nil, nil
+		}
+
+		var existing models.IdempotencyKey
+		err := db.Where("idempotency_key = ?", key.Key).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed reserve idempotency key: %w", err)
+		}
+		return &existing, nil
+	}
+	return nil, fmt.Errorf("failed reserve idempotency key: released %d times in a row", reserveAttempts)
+}
+
+func (s *DBStore) Complete(ctx context.Context, record *models.IdempotencyKey) error {
+	result := s.db.WithContext(ctx).Model(&models.IdempotencyKey{}).
+		Where("idempotency_key = ? AND token = ?", record.Key, record.Token).
+		Select("status_code", "headers", "body", "expires_at").
+		Updates(record)
+	if result.Error != nil {
+		return fmt.Errorf("failed store idempotent response: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("failed store idempotent response: %w", ErrLeaseLost)
+	}
+	return nil
+}
+
+func (s *DBStore) Release(ctx context.Context, key, token string) error {
+	result := s.db.WithContext(ctx).Where("idempotency_key = ? AND token = ?", key, token).Delete(&models.IdempotencyKey{})
+	if result.Error != nil {
+		return fmt.Errorf("failed release idempotency key: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("failed release idempotency key: %w", ErrLeaseLost)
+	}
+	return nil
+}
+
+func (s *DBStore) Prune(ctx context.Context, now time.Time) error {
+	if err := s.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&models.IdempotencyKey{}).Error; err != nil {
+		return fmt.Errorf("failed prune idempotency keys: %w", err)
+	}
+	return nil
+}
+
+// MemoryStore - ключи в памяти процесса для storage.backend=memory
+type MemoryStore struct {
+	mu   sync.Mutex
+	keys map[string]models.IdempotencyKey
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{keys: make(map[string]models.IdempotencyKey)}
+}
+
+func (s *MemoryStore) Reserve(ctx context.Context, key *models.IdempotencyKey, now time.Time) (*models.IdempotencyKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.keys[key.Key]; ok && existing.ExpiresAt.After(now) {
+		return &existing, nil
+	}
+	key.Token = newToken()
+	key.CreatedAt = now
+	s.keys[key.Key] = *key
+	return nil, nil
+}
+
+func (s *MemoryStore) Complete(ctx context.Context, record *models.IdempotencyKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.keys[record.Key]
+	if !ok || existing.Token != record.Token {
+		return fmt.Errorf("failed store idempotent response: %w", ErrLeaseLost)
+	}
+	existing.StatusCode = record.StatusCode
+	existing.Header = record.Header
+	existing.Body = record.Body
+	existing.ExpiresAt = record.ExpiresAt
+	s.keys[record.Key] = existing
+	return nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, key, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.keys[key]; !ok || existing.Token != token {
+		return fmt.Errorf("failed release idempotency key: %w", ErrLeaseLost)
+	}
+	delete(s.keys, key)
+	return nil
+}
+
+func (s *MemoryStore) Prune(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, record := range s.keys {
+		if !record.ExpiresAt.After(now) {
+			delete(s.keys, key)
+		}
+	}
+	return nil
+}
+
+// newToken - случайный токен занятого ключа
+func newToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
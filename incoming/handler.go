@@ -0,0 +1,172 @@
+package incoming
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"chat-api/handlers"
+	"chat-api/models"
+	"chat-api/service"
+)
+
+// Handler - управление входящими вебхуками чата и приём сообщений по токену
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) Register(g *handlers.Group) {
+	g.Post("/chats/{id}/incoming-webhooks", h.Create)
+	g.Get("/chats/{id}/incoming-webhooks", h.List)
+	g.Delete("/chats/{id}/incoming-webhooks/{hook_id}", h.Revoke)
+	g.Post("/chats/{id}/incoming-webhooks/{hook_id}/rotate", h.Rotate)
+	g.Post(pathPrefix+"{token}", h.Post, handlers.SecretParam("token"))
+}
+
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	chatID, err := handlers.PathParamUint(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CreateIncomingWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	hook, err := h.service.Create(r.Context(), chatID, req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, hook)
+}
+
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	chatID, err := handlers.PathParamUint(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
+		return
+	}
+
+	hooks, err := h.service.List(r.Context(), chatID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, hooks)
+}
+
+func (h *Handler) Rotate(w http.ResponseWriter, r *http.Request) {
+	chatID, id, ok := hookParams(w, r)
+	if !ok {
+		return
+	}
+
+	hook, err := h.service.Rotate(r.Context(), chatID, id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, hook)
+}
+
+func (h *Handler) Revoke(w http.ResponseWriter, r *http.Request) {
+	chatID, id, ok := hookParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Revoke(r.Context(), chatID, id); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Post - сообщение от внешней системы. Неверный и отозванный токен неотличимы: оба 404
+func (h *Handler) Post(w http.ResponseWriter, r *http.Request) {
+	var req models.IncomingMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	message, err := h.service.Post(r.Context(), handlers.PathParam(r, "token"), req)
+	if err != nil {
+		var (
+			rateLimited *RateLimitError
+			slowMode    *service.SlowModeError
+			bot         *service.BotError
+		)
+		switch {
+		case errors.As(err, &rateLimited):
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(rateLimited.RetryAfter.Seconds()))))
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+		case errors.As(err, &slowMode):
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(slowMode.RetryAfter().Seconds()))))
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+		case errors.As(err, &bot):
+			http.Error(w, bot.Upstream(), http.StatusBadGateway)
+		case errors.Is(err, ErrNotFound):
+			http.Error(w, "Unknown webhook", http.StatusNotFound)
+		default:
+			writeError(w, err)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, models.MessageResponse{
+		ID:         message.ID,
+		ChatID:     message.ChatID,
+		Text:       message.Text,
+		AuthorName: message.AuthorName,
+		Ephemeral:  message.Ephemeral,
+		CreatedAt:  message.CreatedAt,
+	})
+}
+
+func hookParams(w http.ResponseWriter, r *http.Request) (uint, uint, bool) {
+	chatID, err := handlers.PathParamUint(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid chat ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+
+	id, err := handlers.PathParamUint(r, "hook_id")
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+
+	return chatID, id, true
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,142 @@
+package incoming
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"chat-api/handlers"
+	"chat-api/internal/testutil"
+	"chat-api/models"
+	"chat-api/repository"
+	"chat-api/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRouter - SQLite со схемой, чат и роутер с маршрутами входящих вебхуков
+func newRouter(t *testing.T) (*handlers.Router, repository.ChatRepository, *models.Chat) {
+	t.Helper()
+	return newRouterWithSender(t, nil)
+}
+
+// newRouterWithSender - sender вместо сервиса чатов, если не nil
+func newRouterWithSender(t *testing.T, sender MessageSender) (*handlers.Router, repository.ChatRepository, *models.Chat) {
+	t.Helper()
+	ctx := context.Background()
+
+	db := testutil.SQLite(t)
+
+	repo := repository.NewRepository(db, testutil.DiscardLogger{})
+	chat, err := repo.Create(ctx, &models.Chat{Title: "deploys"})
+	require.NoError(t, err)
+
+	if sender == nil {
+		sender = service.NewChatService(repo)
+	}
+	router := handlers.NewRouter()
+	router.Mount("", NewHandler(NewService(NewStore(db), sender, 60)))
+	return router, repo, chat
+}
+
+func do(router http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return rec
+}
+
+func decode[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &v), rec.Body.String())
+	return v
+}
+
+func TestIncomingWebhook(t *testing.T) {
+	router, repo, chat := newRouter(t)
+	hooks := "/chats/" + strconvUint(chat.ID) + "/incoming-webhooks"
+
+	assert.Equal(t, http.StatusBadRequest, do(router, "POST", hooks, `{"name":" "}`).Code)
+	assert.Equal(t, http.StatusNotFound, do(router, "POST", "/chats/999/incoming-webhooks", `{"name":"CI"}`).Code)
+
+	rec := do(router, "POST", hooks, `{"name":"CI"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	hook := decode[models.IncomingWebhookResponse](t, rec)
+	require.True(t, strings.HasPrefix(hook.Token, tokenPrefix))
+	assert.Equal(t, "/hooks/"+hook.Token, hook.URL)
+
+	rec = do(router, "POST", hook.URL, `{"text":"build #12 passed"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Equal(t, "CI", decode[models.MessageResponse](t, rec).AuthorName)
+
+	rec = do(router, "POST", hook.URL, `{"text":"disk is 90% full","name":"Monitoring"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, http.StatusBadRequest, do(router, "POST", hook.URL, `{"text":""}`).Code)
+
+	messages, err := repo.ListMessages(context.Background(), chat.ID, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "Monitoring", messages[0].AuthorName)
+	assert.Equal(t, "CI", messages[1].AuthorName)
+
+	rec = do(router, "GET", hooks, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), hook.Token)
+
+	// после смены токена старый адрес не работает
+	path := hooks + "/" + strconvUint(hook.ID)
+	rec = do(router, "POST", path+"/rotate", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	rotated := decode[models.IncomingWebhookResponse](t, rec)
+	assert.NotEqual(t, hook.Token, rotated.Token)
+	assert.Equal(t, http.StatusNotFound, do(router, "POST", hook.URL, `{"text":"hi"}`).Code)
+	assert.Equal(t, http.StatusCreated, do(router, "POST", rotated.URL, `{"text":"hi"}`).Code)
+
+	assert.Equal(t, http.StatusNoContent, do(router, "DELETE", path, "").Code)
+	assert.Equal(t, http.StatusNotFound, do(router, "DELETE", path, "").Code)
+	assert.Equal(t, http.StatusNotFound, do(router, "POST", rotated.URL, `{"text":"hi"}`).Code)
+}
+
+func TestIncomingWebhook_RateLimit(t *testing.T) {
+	router, _, chat := newRouter(t)
+
+	rec := do(router, "POST", "/chats/"+strconvUint(chat.ID)+"/incoming-webhooks", `{"name":"CI","rate_limit":2}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	hook := decode[models.IncomingWebhookResponse](t, rec)
+
+	assert.Equal(t, http.StatusCreated, do(router, "POST", hook.URL, `{"text":"one"}`).Code)
+	assert.Equal(t, http.StatusCreated, do(router, "POST", hook.URL, `{"text":"two"}`).Code)
+
+	rec = do(router, "POST", hook.URL, `{"text":"three"}`)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "30", rec.Header().Get("Retry-After"))
+}
+
+type failingSender struct{}
+
+func (failingSender) SendMessage(context.Context, uint, string) (*models.Message, error) {
+	return nil, errors.New("database is locked")
+}
+
+// TestIncomingWebhook_SendFailure - сбой сохранения - ошибка сервера, а не клиента
+func TestIncomingWebhook_SendFailure(t *testing.T) {
+	router, _, chat := newRouterWithSender(t, failingSender{})
+
+	rec := do(router, "POST", "/chats/"+strconvUint(chat.ID)+"/incoming-webhooks", `{"name":"CI"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	hook := decode[models.IncomingWebhookResponse](t, rec)
+
+	assert.Equal(t, http.StatusInternalServerError, do(router, "POST", hook.URL, `{"text":"hi"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(router, "POST", hook.URL, `{"text":" "}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(router, "POST", hook.URL, `{"text":"hi","name":"`+strings.Repeat("x", 101)+`"}`).Code)
+}
+
+func strconvUint(n uint) string {
+	return strconv.FormatUint(uint64(n), 10)
+}
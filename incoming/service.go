@@ -0,0 +1,210 @@
+package incoming
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"chat-api/models"
+	"chat-api/ratelimit"
+	"chat-api/repository"
+	"chat-api/service"
+)
+
+const (
+	// tokenPrefix - у токенов, чтобы их было легко узнать в конфигах CI
+	tokenPrefix = "whin_"
+	// pathPrefix - адрес, по которому вебхук принимает сообщения: pathPrefix + токен
+	pathPrefix = "/hooks/"
+
+	maxNameLength = 100
+	maxRateLimit  = 10000
+	// maxTextLength - как у messages.text
+	maxTextLength = 5000
+)
+
+// ErrInvalid - запрос не прошёл проверку
+var ErrInvalid = errors.New("invalid incoming webhook")
+
+// RateLimitError - вебхук исчерпал лимит сообщений; RetryAfter - когда можно повторить
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("incoming webhook rate limit exceeded, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// MessageSender - отправка сообщения в чат; в приложении это service.ChatService
+type MessageSender interface {
+	SendMessage(ctx context.Context, chatID uint, text string) (*models.Message, error)
+}
+
+// Service - управление входящими вебхуками и приём сообщений по токену
+type Service struct {
+	store     *Store
+	sender    MessageSender
+	limiter   *ratelimit.Limiter
+	rateLimit int
+	now       func() time.Time
+}
+
+// NewService - rateLimit - сообщений в минуту для вебхуков без собственного лимита
+func NewService(store *Store, sender MessageSender, rateLimit int) *Service {
+	return &Service{
+		store:     store,
+		sender:    sender,
+		limiter:   ratelimit.NewLimiter(),
+		rateLimit: rateLimit,
+		now:       time.Now,
+	}
+}
+
+// Create - создаёт вебхук чата; токен возвращается только здесь и при Rotate
+func (s *Service) Create(ctx context.Context, chatID uint, req models.CreateIncomingWebhookRequest) (*models.IncomingWebhookResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name cannot be empty", ErrInvalid)
+	}
+	if len(name) > maxNameLength {
+		return nil, fmt.Errorf("%w: name cannot exceed %d characters", ErrInvalid, maxNameLength)
+	}
+	if req.RateLimit < 0 || req.RateLimit > maxRateLimit {
+		return nil, fmt.Errorf("%w: rate_limit must be between 0 and %d messages per minute", ErrInvalid, maxRateLimit)
+	}
+
+	exists, err := s.store.ChatExists(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("chat %d: %w", chatID, ErrNotFound)
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	hook := &models.IncomingWebhook{
+		ChatID:    chatID,
+		Name:      name,
+		TokenHash: repository.HashAPIKey(token),
+		RateLimit: req.RateLimit,
+	}
+	if err := s.store.Create(ctx, hook); err != nil {
+		return nil, err
+	}
+
+	return s.withToken(toResponse(*hook), token), nil
+}
+
+func (s *Service) List(ctx context.Context, chatID uint) ([]models.IncomingWebhookResponse, error) {
+	hooks, err := s.store.List(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]models.IncomingWebhookResponse, len(hooks))
+	for i, hook := range hooks {
+		responses[i] = toResponse(hook)
+	}
+	return responses, nil
+}
+
+// Rotate - выдаёт новый токен; старый перестаёт работать сразу
+func (s *Service) Rotate(ctx context.Context, chatID, id uint) (*models.IncomingWebhookResponse, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.SetTokenHash(ctx, chatID, id, repository.HashAPIKey(token)); err != nil {
+		return nil, err
+	}
+
+	hook, err := s.store.Get(ctx, chatID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.withToken(toResponse(*hook), token), nil
+}
+
+// Revoke - удаляет вебхук
+func (s *Service) Revoke(ctx context.Context, chatID, id uint) error {
+	if err := s.store.Delete(ctx, chatID, id); err != nil {
+		return err
+	}
+	s.limiter.Forget(limiterKey(id))
+	return nil
+}
+
+// Post - сообщение по токену вебхука. Проходит через ChatService.SendMessage,
+// подписывается req.Name или именем вебхука
+func (s *Service) Post(ctx context.Context, token string, req models.IncomingMessageRequest) (*models.Message, error) {
+	// ошибки проверки - ErrInvalid, остальные ошибки отправки - сбой сервера
+	text := strings.TrimSpace(req.Text)
+	if text == "" {
+		return nil, fmt.Errorf("%w: text cannot be empty", ErrInvalid)
+	}
+	if len(text) > maxTextLength {
+		return nil, fmt.Errorf("%w: text cannot exceed %d characters", ErrInvalid, maxTextLength)
+	}
+	if len(strings.TrimSpace(req.Name)) > maxNameLength {
+		return nil, fmt.Errorf("%w: name cannot exceed %d characters", ErrInvalid, maxNameLength)
+	}
+
+	hook, err := s.store.GetByTokenHash(ctx, repository.HashAPIKey(token))
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimit := hook.RateLimit
+	if rateLimit == 0 {
+		rateLimit = s.rateLimit
+	}
+	if result := s.limiter.Allow(limiterKey(hook.ID), rateLimit, s.now()); !result.Allowed {
+		return nil, &RateLimitError{RetryAfter: result.RetryAfter}
+	}
+
+	author := strings.TrimSpace(req.Name)
+	if author == "" {
+		author = hook.Name
+	}
+
+	// каждый вебхук - отдельный участник для медленного режима чата
+	ctx = service.WithMember(service.WithAuthor(ctx, author), "hook:"+limiterKey(hook.ID))
+	return s.sender.SendMessage(ctx, hook.ChatID, text)
+}
+
+func limiterKey(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+func (s *Service) withToken(response models.IncomingWebhookResponse, token string) *models.IncomingWebhookResponse {
+	response.Token = token
+	response.URL = pathPrefix + token
+	return &response
+}
+
+func newToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed generate incoming webhook token: %w", err)
+	}
+	return tokenPrefix + hex.EncodeToString(buf), nil
+}
+
+func toResponse(hook models.IncomingWebhook) models.IncomingWebhookResponse {
+	return models.IncomingWebhookResponse{
+		ID:        hook.ID,
+		ChatID:    hook.ChatID,
+		Name:      hook.Name,
+		RateLimit: hook.RateLimit,
+		CreatedAt: hook.CreatedAt,
+	}
+}
@@ -0,0 +1,90 @@
+package incoming
+
+import (
+	"context"
+	"fmt"
+
+	"chat-api/models"
+	"chat-api/repository"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotFound - вебхук не найден или токен неверный; совпадает с repository.ErrNotFound
+var ErrNotFound = repository.ErrNotFound
+
+// Store - входящие вебхуки в базе
+type Store struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) Create(ctx context.Context, hook *models.IncomingWebhook) error {
+	if err := s.db.WithContext(ctx).Create(hook).Error; err != nil {
+		return fmt.Errorf("failed create incoming webhook: %w", err)
+	}
+	return nil
+}
+
+// List - вебхуки чата
+func (s *Store) List(ctx context.Context, chatID uint) ([]models.IncomingWebhook, error) {
+	var hooks []models.IncomingWebhook
+	if err := s.db.WithContext(ctx).Where("chat_id = ?", chatID).Order("id").Find(&hooks).Error; err != nil {
+		return nil, fmt.Errorf("failed list incoming webhooks: %w", err)
+	}
+	return hooks, nil
+}
+
+// Get - вебхук чата по ID
+func (s *Store) Get(ctx context.Context, chatID, id uint) (*models.IncomingWebhook, error) {
+	var hook models.IncomingWebhook
+	if err := s.db.WithContext(ctx).Where("chat_id = ?", chatID).First(&hook, id).Error; err != nil {
+		return nil, fmt.Errorf("failed get incoming webhook: %w", err)
+	}
+	return &hook, nil
+}
+
+// GetByTokenHash - вебхук по хэшу токена из адреса
+func (s *Store) GetByTokenHash(ctx context.Context, tokenHash string) (*models.IncomingWebhook, error) {
+	var hook models.IncomingWebhook
+	if err := s.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&hook).Error; err != nil {
+		return nil, fmt.Errorf("failed get incoming webhook: %w", err)
+	}
+	return &hook, nil
+}
+
+// SetTokenHash - заменяет токен; старый адрес сразу перестаёт работать
+func (s *Store) SetTokenHash(ctx context.Context, chatID, id uint, tokenHash string) error {
+	result := s.db.WithContext(ctx).Model(&models.IncomingWebhook{}).
+		Where("id = ? AND chat_id = ?", id, chatID).
+		Update("token_hash", tokenHash)
+	if result.Error != nil {
+		return fmt.Errorf("failed rotate incoming webhook token: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("failed rotate incoming webhook token: %w", ErrNotFound)
+	}
+	return nil
+}
+
+func (s *Store) Delete(ctx context.Context, chatID, id uint) error {
+	result := s.db.WithContext(ctx).Where("chat_id = ?", chatID).Delete(&models.IncomingWebhook{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed delete incoming webhook: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("failed delete incoming webhook: %w", ErrNotFound)
+	}
+	return nil
+}
+
+func (s *Store) ChatExists(ctx context.Context, chatID uint) (bool, error) {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.Chat{}).Where("id = ?", chatID).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed check chat: %w", err)
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,53 @@
+// Package testutil - общие помощники тестов: база SQLite со схемой, логгер без вывода и трассировка
+package testutil
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"chat-api/database"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"gorm.io/gorm"
+)
+
+// DiscardLogger - логгер запросов и ошибок, который ничего не пишет
+type DiscardLogger struct{}
+
+func (DiscardLogger) Log(context.Context, string, string, string, float64, error) {}
+func (DiscardLogger) LogError(context.Context, string, error)                     {}
+
+// SQLite - база SQLite во временном каталоге теста со всеми миграциями; закрывается после теста
+func SQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+	ctx := context.Background()
+
+	db, err := database.NewDB(ctx, database.Config{URL: "sqlite://" + filepath.Join(t.TempDir(), "chat.db")})
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := db.Migrator()
+	require.NoError(t, err)
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+
+	return db.DB
+}
+
+// WithTracing - SDK трассировки с propagator traceparent на время теста
+func WithTracing(t *testing.T) {
+	t.Helper()
+	provider := sdktrace.NewTracerProvider()
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+		provider.Shutdown(context.Background())
+	})
+}
@@ -1,10 +1,13 @@
 package logger
 
 import (
-	"chat-api/utils"
+	"context"
+	"errors"
 	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"sync"
 	"time"
 )
 
@@ -12,19 +15,62 @@ type BaseLogger struct {
 	logger *slog.Logger
 }
 
+var (
+	filesMu   sync.Mutex
+	openFiles []*os.File
+
+	logToFile = true
+	logDir    = "logs"
+)
+
+// Configure - задаёт, писать ли логи в файлы и в какой каталог; вызывается до создания логгеров
+func Configure(toFile bool, dir string) {
+	filesMu.Lock()
+	defer filesMu.Unlock()
+
+	logToFile = toFile
+	logDir = dir
+}
+
+// Close - сбрасывает на диск и закрывает все файлы логов; вызывается при остановке приложения
+func Close() error {
+	filesMu.Lock()
+	defer filesMu.Unlock()
+
+	var errs []error
+	for _, file := range openFiles {
+		if err := file.Sync(); err != nil {
+			errs = append(errs, err)
+		}
+		if err := file.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	openFiles = nil
+
+	return errors.Join(errs...)
+}
+
 func CreateBaseLogger(filename string) *BaseLogger {
 	writers := []io.Writer{os.Stdout}
-	logToFile := utils.GetEnv("LOG_TO_FILE", "on")
 
-	if filename != "" && logToFile == "on" {
-		if err := os.MkdirAll("logs", 0755); err != nil {
+	filesMu.Lock()
+	toFile, dir := logToFile, logDir
+	filesMu.Unlock()
+
+	if filename != "" && toFile {
+		if err := os.MkdirAll(dir, 0755); err != nil {
 			slog.Default().Error("failed to create logs directory", "error", err)
 		} else {
-			logFile, err := os.OpenFile("logs/"+filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+			logFile, err := os.OpenFile(filepath.Join(dir, filename), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
 			if err != nil {
 				slog.Default().Error("failed to open log file", "error", err, "filename", filename)
 			} else {
 				writers = append(writers, logFile)
+
+				filesMu.Lock()
+				openFiles = append(openFiles, logFile)
+				filesMu.Unlock()
 			}
 		}
 	}
@@ -36,21 +82,21 @@ func CreateBaseLogger(filename string) *BaseLogger {
 	})
 
 	return &BaseLogger{
-		logger: slog.New(handler),
+		logger: slog.New(contextHandler{handler}),
 	}
 }
 
-func (dl *BaseLogger) LogError(operation string, err error) {
+func (dl *BaseLogger) LogError(ctx context.Context, operation string, err error) {
 	timestamp := time.Now()
-	dl.logger.Error(operation, err.Error(), slog.Time("timestamp", timestamp))
+	dl.logger.ErrorContext(ctx, operation, slog.String("error", err.Error()), slog.Time("timestamp", timestamp))
 }
 
-func (dl *BaseLogger) LogWarn(operation string, warn string) {
+func (dl *BaseLogger) LogWarn(ctx context.Context, operation string, warn string) {
 	timestamp := time.Now()
-	dl.logger.Error(operation, warn, slog.Time("timestamp", timestamp))
+	dl.logger.WarnContext(ctx, operation, slog.String("details", warn), slog.Time("timestamp", timestamp))
 }
 
-func (dl *BaseLogger) LogInfo(operation string, info string) {
+func (dl *BaseLogger) LogInfo(ctx context.Context, operation string, info string) {
 	timestamp := time.Now()
-	dl.logger.Error(operation, info, slog.Time("timestamp", timestamp))
+	dl.logger.InfoContext(ctx, operation, slog.String("details", info), slog.Time("timestamp", timestamp))
 }
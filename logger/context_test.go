@@ -0,0 +1,35 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestContextHandler_RequestID - тест добавления request_id из контекста в записи лога
+func TestContextHandler_RequestID(t *testing.T) {
+	var buf bytes.Buffer
+	dl := &DatabaseLogger{BaseLogger: &BaseLogger{
+		logger: slog.New(contextHandler{slog.NewJSONHandler(&buf, nil)}),
+	}}
+
+	ctx := WithRequestID(context.Background(), "req-7")
+	dl.Log(ctx, "Create", "messages", "chat_id: 1", 1.5, nil)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "database_operation", entry["msg"])
+	assert.Equal(t, "req-7", entry["request_id"])
+
+	buf.Reset()
+	dl.Log(context.Background(), "Create", "messages", "chat_id: 1", 1.5, nil)
+
+	entry = nil
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.NotContains(t, entry, "request_id")
+}
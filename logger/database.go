@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"context"
 	"log/slog"
 	"time"
 )
@@ -15,10 +16,10 @@ func NewDatabaseLogger() *DatabaseLogger {
 	}
 }
 
-func (dl *DatabaseLogger) Log(operation, table string, details string, durationMs float64, err error) {
+func (dl *DatabaseLogger) Log(ctx context.Context, operation, table string, details string, durationMs float64, err error) {
 	timestamp := time.Now()
 	if err != nil {
-		dl.logger.Error("database_error",
+		dl.logger.ErrorContext(ctx, "database_error",
 			slog.String("operation", operation),
 			slog.String("table", table),
 			slog.String("details", details),
@@ -27,7 +28,7 @@ func (dl *DatabaseLogger) Log(operation, table string, details string, durationM
 			slog.String("error", err.Error()),
 		)
 	} else {
-		dl.logger.Info("database_operation",
+		dl.logger.InfoContext(ctx, "database_operation",
 			slog.String("operation", operation),
 			slog.String("table", table),
 			slog.String("details", details),
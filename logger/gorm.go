@@ -0,0 +1,123 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// GORM log levels accepted by ParseGormLevel
+const (
+	GormLevelSilent = "silent"
+	GormLevelError  = "error"
+	GormLevelWarn   = "warn"
+	GormLevelInfo   = "info"
+)
+
+// GormLogger - адаптер логгера GORM: SQL ошибки, медленные запросы и (на уровне info)
+// все запросы пишутся в database.log вместе с request_id вместо stdout
+type GormLogger struct {
+	*BaseLogger
+	level         gormlogger.LogLevel
+	slowThreshold time.Duration
+}
+
+func NewGormLogger(level string, slowThreshold time.Duration) (*GormLogger, error) {
+	gormLevel, err := ParseGormLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GormLogger{
+		BaseLogger:    CreateBaseLogger("database.log"),
+		level:         gormLevel,
+		slowThreshold: slowThreshold,
+	}, nil
+}
+
+// ParseGormLevel - silent, error, warn или info
+func ParseGormLevel(level string) (gormlogger.LogLevel, error) {
+	switch strings.ToLower(level) {
+	case GormLevelSilent:
+		return gormlogger.Silent, nil
+	case GormLevelError:
+		return gormlogger.Error, nil
+	case GormLevelWarn:
+		return gormlogger.Warn, nil
+	case GormLevelInfo:
+		return gormlogger.Info, nil
+	}
+	return 0, fmt.Errorf("unknown gorm log level %q, expected silent, error, warn or info", level)
+}
+
+func (gl *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	copied := *gl
+	copied.level = level
+	return &copied
+}
+
+func (gl *GormLogger) Info(ctx context.Context, msg string, args ...any) {
+	if gl.level >= gormlogger.Info {
+		gl.logger.InfoContext(ctx, "gorm", slog.String("details", fmt.Sprintf(msg, args...)))
+	}
+}
+
+func (gl *GormLogger) Warn(ctx context.Context, msg string, args ...any) {
+	if gl.level >= gormlogger.Warn {
+		gl.logger.WarnContext(ctx, "gorm", slog.String("details", fmt.Sprintf(msg, args...)))
+	}
+}
+
+func (gl *GormLogger) Error(ctx context.Context, msg string, args ...any) {
+	if gl.level >= gormlogger.Error {
+		gl.logger.ErrorContext(ctx, "gorm", slog.String("details", fmt.Sprintf(msg, args...)))
+	}
+}
+
+// Trace - вызывается GORM после каждого запроса. "Запись не найдена" ошибкой не считается:
+// это обычный ответ 404, и он уже попадает в лог репозитория
+func (gl *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if gl.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	failed := err != nil && !errors.Is(err, gorm.ErrRecordNotFound)
+	slow := gl.slowThreshold > 0 && elapsed > gl.slowThreshold
+
+	var (
+		level slog.Level
+		msg   string
+	)
+	switch {
+	case failed && gl.level >= gormlogger.Error:
+		level, msg = slog.LevelError, "sql_error"
+	case slow && gl.level >= gormlogger.Warn:
+		level, msg = slog.LevelWarn, "sql_slow_query"
+	case gl.level >= gormlogger.Info:
+		level, msg = slog.LevelInfo, "sql_query"
+	default:
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Float64("duration_ms", float64(elapsed.Nanoseconds())/1e6),
+	}
+	if failed {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	if slow {
+		attrs = append(attrs, slog.Float64("slow_threshold_ms", float64(gl.slowThreshold.Nanoseconds())/1e6))
+	}
+
+	gl.logger.LogAttrs(ctx, level, msg, attrs...)
+}
@@ -0,0 +1,112 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func newTestGormLogger(buf *bytes.Buffer, level gormlogger.LogLevel) *GormLogger {
+	return &GormLogger{
+		BaseLogger: &BaseLogger{
+			logger: slog.New(contextHandler{slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})}),
+		},
+		level:         level,
+		slowThreshold: 100 * time.Millisecond,
+	}
+}
+
+func logEntries(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// TestGormLogger_Trace - какие запросы попадают в лог на каждом уровне
+func TestGormLogger_Trace(t *testing.T) {
+	query := func() (string, int64) { return "SELECT 1", 1 }
+	fast := time.Now()
+	slow := time.Now().Add(-time.Second)
+
+	tests := []struct {
+		name     string
+		level    gormlogger.LogLevel
+		begin    time.Time
+		err      error
+		expected []string
+	}{
+		{"silent error", gormlogger.Silent, fast, errors.New("boom"), nil},
+		{"error level", gormlogger.Error, fast, errors.New("boom"), []string{"sql_error"}},
+		{"error level skips slow", gormlogger.Error, slow, nil, nil},
+		{"warn level slow", gormlogger.Warn, slow, nil, []string{"sql_slow_query"}},
+		{"warn level fast", gormlogger.Warn, fast, nil, nil},
+		{"not found is not an error", gormlogger.Warn, fast, gorm.ErrRecordNotFound, nil},
+		{"info level fast", gormlogger.Info, fast, nil, []string{"sql_query"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			gl := newTestGormLogger(&buf, tt.level)
+
+			gl.Trace(context.Background(), tt.begin, query, tt.err)
+
+			var messages []string
+			for _, entry := range logEntries(t, &buf) {
+				messages = append(messages, entry["msg"].(string))
+			}
+			assert.Equal(t, tt.expected, messages)
+		})
+	}
+}
+
+// TestGormLogger_RequestID - записи SQL коррелируются с запросом
+func TestGormLogger_RequestID(t *testing.T) {
+	var buf bytes.Buffer
+	gl := newTestGormLogger(&buf, gormlogger.Error)
+
+	ctx := WithRequestID(context.Background(), "req-9")
+	gl.Trace(ctx, time.Now(), func() (string, int64) { return `INSERT INTO "chats"`, 0 }, errors.New("duplicate key"))
+
+	entries := logEntries(t, &buf)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "req-9", entries[0]["request_id"])
+	assert.Equal(t, `INSERT INTO "chats"`, entries[0]["sql"])
+	assert.Equal(t, "duplicate key", entries[0]["error"])
+	assert.Equal(t, "ERROR", entries[0]["level"])
+}
+
+// TestGormLogger_LogMode - LogMode не меняет исходный логгер
+func TestGormLogger_LogMode(t *testing.T) {
+	var buf bytes.Buffer
+	gl := newTestGormLogger(&buf, gormlogger.Warn)
+
+	gl.LogMode(gormlogger.Info).Info(context.Background(), "hello %s", "gorm")
+	gl.Info(context.Background(), "dropped")
+
+	entries := logEntries(t, &buf)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "hello gorm", entries[0]["details"])
+
+	_, err := ParseGormLevel("verbose")
+	assert.Error(t, err)
+}
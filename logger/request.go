@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"time"
 )
@@ -15,24 +17,24 @@ func NewRequestLogger() *RequestLogger {
 	}
 }
 
-func (rl *RequestLogger) Log(method, path, remoteAddr string, statusCode int, durationMs float64) {
-	var logFunc func(msg string, args ...any)
+func (rl *RequestLogger) Log(ctx context.Context, method, path, remoteAddr string, statusCode int, durationMs float64) {
+	var logFunc func(ctx context.Context, msg string, args ...any)
 
 	switch {
 	case statusCode >= 500:
-		logFunc = rl.logger.Error
+		logFunc = rl.logger.ErrorContext
 	case statusCode >= 400:
-		logFunc = rl.logger.Warn
+		logFunc = rl.logger.WarnContext
 	case statusCode >= 300:
-		logFunc = rl.logger.Info
+		logFunc = rl.logger.InfoContext
 	case statusCode >= 200:
-		logFunc = rl.logger.Info
+		logFunc = rl.logger.InfoContext
 	default:
-		logFunc = rl.logger.Info
+		logFunc = rl.logger.InfoContext
 	}
 
 	timestamp := time.Now()
-	logFunc("http_request",
+	logFunc(ctx, "http_request",
 		slog.String("method", method),
 		slog.String("path", path),
 		slog.String("remote_addr", remoteAddr),
@@ -41,3 +43,14 @@ func (rl *RequestLogger) Log(method, path, remoteAddr string, statusCode int, du
 		slog.Time("timestamp", timestamp),
 	)
 }
+
+func (rl *RequestLogger) LogPanic(ctx context.Context, method, route string, recovered any, stack []byte) {
+	timestamp := time.Now()
+	rl.logger.ErrorContext(ctx, "http_panic",
+		slog.String("method", method),
+		slog.String("route", route),
+		slog.String("panic", fmt.Sprint(recovered)),
+		slog.String("stack", string(stack)),
+		slog.Time("timestamp", timestamp),
+	)
+}
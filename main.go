@@ -1,41 +1,29 @@
 package main
 
 import (
-	"net/http"
+	"context"
+	"errors"
 	"os"
+	"os/signal"
+	"syscall"
 
-	"chat-api/database"
-	"chat-api/handlers"
+	"chat-api/cli"
 	"chat-api/logger"
-	"chat-api/repository"
-	"chat-api/service"
-	"chat-api/utils"
 )
 
 func main() {
-	log := logger.CreateBaseLogger("logs.log")
-	log.LogInfo("Application starting", "version=1.0.0")
-	db, err := database.NewDB()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 
-	if err != nil {
-		log.LogError("Start database:", err)
-		os.Exit(1)
-	}
-
-	defer db.Close()
-
-	requestLogger := logger.NewRequestLogger()
-	databaseLogger := logger.NewDatabaseLogger()
-
-	repo := repository.NewRepository(db.DB, databaseLogger)
-
-	chatService := service.NewChatService(repo)
+	app := &cli.App{Stdout: os.Stdout, Stderr: os.Stderr}
+	err := app.Run(ctx, os.Args[1:])
 
-	router := handlers.New(chatService, requestLogger)
+	stop()
+	logger.Close()
 
-	port := utils.GetEnv("PORT", "8080")
-	if err := http.ListenAndServe(":"+port, router); err != nil {
-		log.LogError("Start http server:", err)
+	switch {
+	case errors.Is(err, cli.ErrUsage):
+		os.Exit(2)
+	case err != nil:
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Metrics - метрики приложения: HTTP, операции репозитория, пул соединений и доменные счётчики
+type Metrics struct {
+	Registry *Registry
+
+	httpRequests *Counter
+	httpDuration *Histogram
+
+	dbOperations *Counter
+	dbDuration   *Histogram
+
+	chatsCreated *Counter
+	chatsDeleted *Counter
+	messagesSent *Counter
+
+	outboxDeliveries *Counter
+	webhookAttempts  *Counter
+	rateLimited      *Counter
+}
+
+func New() *Metrics {
+	registry := NewRegistry()
+
+	return &Metrics{
+		Registry: registry,
+
+		httpRequests: registry.NewCounter("chat_http_requests_total",
+			"HTTP requests by method, route template and status code.", "method", "route", "status"),
+		httpDuration: registry.NewHistogram("chat_http_request_duration_seconds",
+			"HTTP request latency by method and route template.", DefBuckets, "method", "route"),
+
+		dbOperations: registry.NewCounter("chat_db_operations_total",
+			"Repository operations by operation, table and result.", "operation", "table", "result"),
+		dbDuration: registry.NewHistogram("chat_db_operation_duration_seconds",
+			"Repository operation latency by operation and table.", DefBuckets, "operation", "table"),
+
+		chatsCreated: registry.NewCounter("chat_chats_created_total", "Chats created."),
+		chatsDeleted: registry.NewCounter("chat_chats_deleted_total", "Chats deleted."),
+		messagesSent: registry.NewCounter("chat_messages_sent_total", "Messages sent."),
+
+		outboxDeliveries: registry.NewCounter("chat_outbox_deliveries_total",
+			"Outbox event deliveries by sink and result.", "sink", "result"),
+		webhookAttempts: registry.NewCounter("chat_webhook_attempts_total",
+			"Outgoing webhook delivery attempts by result: delivered, retry or dead.", "result"),
+		rateLimited: registry.NewCounter("chat_rate_limited_total",
+			"HTTP requests rejected by the rate limiter by route class.", "class"),
+	}
+}
+
+func (m *Metrics) Handler() http.HandlerFunc {
+	return m.Registry.Handler()
+}
+
+// ObserveRequest - учитывает HTTP запрос; route - шаблон маршрута, а не сырой путь
+func (m *Metrics) ObserveRequest(method, route string, statusCode int, duration time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	m.httpRequests.Inc(method, route, strconv.Itoa(statusCode))
+	m.httpDuration.Observe(duration.Seconds(), method, route)
+}
+
+func (m *Metrics) ChatCreated() {
+	m.chatsCreated.Inc()
+}
+
+func (m *Metrics) ChatDeleted() {
+	m.chatsDeleted.Inc()
+}
+
+func (m *Metrics) MessageSent() {
+	m.messagesSent.Inc()
+}
+
+func (m *Metrics) OutboxDelivered(sink string) {
+	m.outboxDeliveries.Inc(sink, "ok")
+}
+
+func (m *Metrics) OutboxFailed(sink string) {
+	m.outboxDeliveries.Inc(sink, "error")
+}
+
+func (m *Metrics) WebhookAttempt(result string) {
+	m.webhookAttempts.Inc(result)
+}
+
+func (m *Metrics) RateLimited(class string) {
+	m.rateLimited.Inc(class)
+}
+
+// Logger - интерфейс логгера операций репозитория
+type Logger interface {
+	Log(ctx context.Context, operation, table string, details string, durationMs float64, err error)
+}
+
+type databaseLogger struct {
+	next    Logger
+	metrics *Metrics
+}
+
+// DatabaseLogger - оборачивает логгер репозитория: каждая операция, которую Repository
+// уже замеряет и логирует, попадает и в метрики
+func (m *Metrics) DatabaseLogger(next Logger) Logger {
+	return &databaseLogger{next: next, metrics: m}
+}
+
+func (l *databaseLogger) Log(ctx context.Context, operation, table string, details string, durationMs float64, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	l.metrics.dbOperations.Inc(operation, table, result)
+	l.metrics.dbDuration.Observe(durationMs/1e3, operation, table)
+
+	l.next.Log(ctx, operation, table, details, durationMs, err)
+}
+
+// RegisterDBStats - статистика пула соединений, читается из sql.DB.Stats() при каждом сборе
+func (m *Metrics) RegisterDBStats(db *sql.DB) {
+	stat := func(fn func(s sql.DBStats) float64) func() float64 {
+		return func() float64 {
+			return fn(db.Stats())
+		}
+	}
+
+	m.Registry.NewGaugeFunc("chat_db_pool_max_open_connections", "Maximum number of open connections to the database.",
+		stat(func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }))
+	m.Registry.NewGaugeFunc("chat_db_pool_open_connections", "The number of established connections both in use and idle.",
+		stat(func(s sql.DBStats) float64 { return float64(s.OpenConnections) }))
+	m.Registry.NewGaugeFunc("chat_db_pool_in_use_connections", "The number of connections currently in use.",
+		stat(func(s sql.DBStats) float64 { return float64(s.InUse) }))
+	m.Registry.NewGaugeFunc("chat_db_pool_idle_connections", "The number of idle connections.",
+		stat(func(s sql.DBStats) float64 { return float64(s.Idle) }))
+	m.Registry.NewCounterFunc("chat_db_pool_wait_count_total", "The total number of connections waited for.",
+		stat(func(s sql.DBStats) float64 { return float64(s.WaitCount) }))
+	m.Registry.NewCounterFunc("chat_db_pool_wait_duration_seconds_total", "The total time blocked waiting for a new connection.",
+		stat(func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }))
+	m.Registry.NewCounterFunc("chat_db_pool_max_idle_closed_total", "The total number of connections closed due to SetMaxIdleConns.",
+		stat(func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }))
+	m.Registry.NewCounterFunc("chat_db_pool_max_idle_time_closed_total", "The total number of connections closed due to SetConnMaxIdleTime.",
+		stat(func(s sql.DBStats) float64 { return float64(s.MaxIdleTimeClosed) }))
+	m.Registry.NewCounterFunc("chat_db_pool_max_lifetime_closed_total", "The total number of connections closed due to SetConnMaxLifetime.",
+		stat(func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }))
+}
@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"chat-api/handlers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nopDBLogger struct {
+	calls int
+}
+
+func (l *nopDBLogger) Log(ctx context.Context, operation, table string, details string, durationMs float64, err error) {
+	l.calls++
+}
+
+// TestRegistry_Exposition - тест текстового формата Prometheus для счётчиков и гистограмм
+func TestRegistry_Exposition(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.NewCounter("test_total", "Test counter.", "route")
+	histogram := registry.NewHistogram("test_seconds", "Test histogram.", []float64{0.1, 1}, "route")
+	registry.NewGaugeFunc("test_gauge", "Test gauge.", func() float64 { return 3 })
+
+	counter.Inc(`/chats/{id}`)
+	counter.Add(2, `/chats/{id}`)
+	counter.Inc(`quote"d`)
+	histogram.Observe(0.05, "/chats")
+	histogram.Observe(0.5, "/chats")
+
+	var buf bytes.Buffer
+	require.NoError(t, registry.Write(&buf))
+
+	expected := `# HELP test_total Test counter.
+# TYPE test_total counter
+test_total{route="/chats/{id}"} 3
+test_total{route="quote\"d"} 1
+# HELP test_seconds Test histogram.
+# TYPE test_seconds histogram
+test_seconds_bucket{route="/chats",le="0.1"} 1
+test_seconds_bucket{route="/chats",le="1"} 2
+test_seconds_bucket{route="/chats",le="+Inf"} 2
+test_seconds_sum{route="/chats"} 0.55
+test_seconds_count{route="/chats"} 2
+# HELP test_gauge Test gauge.
+# TYPE test_gauge gauge
+test_gauge 3
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+// TestRegistry_Duplicate - тест запрета повторной регистрации метрики
+func TestRegistry_Duplicate(t *testing.T) {
+	registry := NewRegistry()
+	registry.NewCounter("dup_total", "Dup.")
+
+	assert.Panics(t, func() { registry.NewCounter("dup_total", "Dup.") })
+}
+
+// TestMetrics_HTTPRouteTemplate - тест меток HTTP метрик по шаблону маршрута
+func TestMetrics_HTTPRouteTemplate(t *testing.T) {
+	m := New()
+	router := handlers.NewRouter()
+	router.Use(handlers.MetricsMiddleware(m))
+	router.Handle(http.MethodGet, "/chats/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	router.Handle(http.MethodGet, "/metrics", m.Handler())
+
+	for _, path := range []string{"/chats/1", "/chats/2", "/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	for _, method := range []string{"FOO", "BAR"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/chats/1", nil))
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rec.Body.String()
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, body, `chat_http_requests_total{method="GET",route="/chats/{id}",status="200"} 2`)
+	assert.Contains(t, body, `chat_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, body, `chat_http_request_duration_seconds_count{method="GET",route="/chats/{id}"} 2`)
+	assert.NotContains(t, body, `/chats/1`)
+	// произвольные методы не заводят новых рядов
+	assert.Contains(t, body, `chat_http_requests_total{method="OTHER",route="unmatched",status="405"} 2`)
+	assert.NotContains(t, body, `FOO`)
+}
+
+// TestMetrics_PanicCountsAs500 - тест учёта запроса с паникой как 500
+func TestMetrics_PanicCountsAs500(t *testing.T) {
+	m := New()
+	router := handlers.NewRouter()
+	router.Use(handlers.MetricsMiddleware(m))
+	router.Handle(http.MethodGet, "/boom", func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+
+	assert.Panics(t, func() {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/boom", nil))
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, m.Registry.Write(&buf))
+	assert.Contains(t, buf.String(), `chat_http_requests_total{method="GET",route="/boom",status="500"} 1`)
+}
+
+// TestMetrics_DatabaseLogger - тест метрик операций репозитория через обёртку логгера
+func TestMetrics_DatabaseLogger(t *testing.T) {
+	m := New()
+	next := &nopDBLogger{}
+	dbLogger := m.DatabaseLogger(next)
+
+	dbLogger.Log(context.Background(), "Create", "messages", "", 12, nil)
+	dbLogger.Log(context.Background(), "Create", "messages", "", 8, errors.New("fk violation"))
+	m.MessageSent()
+	m.ObserveRequest(http.MethodPost, "/chats", http.StatusCreated, 10*time.Millisecond)
+
+	var buf bytes.Buffer
+	require.NoError(t, m.Registry.Write(&buf))
+	body := buf.String()
+
+	assert.Equal(t, 2, next.calls)
+	assert.Contains(t, body, `chat_db_operations_total{operation="Create",table="messages",result="ok"} 1`)
+	assert.Contains(t, body, `chat_db_operations_total{operation="Create",table="messages",result="error"} 1`)
+	assert.Contains(t, body, `chat_db_operation_duration_seconds_sum{operation="Create",table="messages"} 0.02`)
+	assert.Contains(t, body, "chat_messages_sent_total 1")
+}
@@ -0,0 +1,345 @@
+// Package migrate - применение SQL миграций, встроенных в бинарник.
+// Состояние хранится в таблице goose_db_version, поэтому базы,
+// размеченные goose раньше, подхватываются без изменений.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+)
+
+const (
+	// VersionTable - таблица версий, совместимая с goose
+	VersionTable = "goose_db_version"
+
+	// lockID - ключ pg_advisory_lock, не даёт двум экземплярам мигрировать одновременно
+	lockID int64 = 0x636861745f617069 // "chat_api"
+)
+
+var ErrNoMigrations = errors.New("no migrations to roll back")
+
+// Dialect - диалект SQL базы, к которой применяются миграции
+type Dialect string
+
+const (
+	PostgreSQL Dialect = "postgres"
+	// SQLite - без межпроцессной блокировки: запись в файл и так сериализует сама SQLite
+	SQLite Dialect = "sqlite"
+)
+
+// Option - настройка Migrator
+type Option func(*Migrator)
+
+// WithDialect - диалект базы, по умолчанию PostgreSQL
+func WithDialect(dialect Dialect) Option {
+	return func(m *Migrator) {
+		m.dialect = dialect
+	}
+}
+
+// Status - состояние одной миграции
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator - применяет миграции к базе PostgreSQL или SQLite
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+func New(db *sql.DB, fsys fs.FS, opts ...Option) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Migrator{db: db, dialect: PostgreSQL, migrations: migrations}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.dialect != PostgreSQL && m.dialect != SQLite {
+		return nil, fmt.Errorf("unsupported dialect %q", m.dialect)
+	}
+	return m, nil
+}
+
+// Migrations - все известные миграции по возрастанию версии
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Latest - версия последней встроенной миграции, то есть версия схемы, которую ожидает бинарник
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up - применяет все неприменённые миграции по порядку и возвращает применённые
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := versions[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration, migration.Up, true); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down - откатывает последнюю применённую миграцию
+func (m *Migrator) Down(ctx context.Context) (Migration, error) {
+	var rolledBack Migration
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := versions[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d has no -- +goose Down section", migration.Version)
+			}
+			rolledBack = migration
+			return m.apply(ctx, conn, migration, migration.Down, false)
+		}
+
+		return ErrNoMigrations
+	})
+
+	return rolledBack, err
+}
+
+// Status - состояние всех известных миграций
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+
+	err := m.withConn(ctx, func(conn *sql.Conn) error {
+		versions, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		statuses = make([]Status, 0, len(m.migrations))
+		for _, migration := range m.migrations {
+			appliedAt, ok := versions[migration.Version]
+			statuses = append(statuses, Status{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				Applied:   ok,
+				AppliedAt: appliedAt,
+			})
+		}
+		return nil
+	})
+
+	return statuses, err
+}
+
+// Version - наибольшая применённая версия, 0 для пустой базы
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	var version int64
+
+	err := m.withConn(ctx, func(conn *sql.Conn) error {
+		versions, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for v := range versions {
+			version = max(version, v)
+		}
+		return nil
+	})
+
+	return version, err
+}
+
+func (m *Migrator) withConn(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %w", err)
+	}
+	defer conn.Close()
+
+	return fn(conn)
+}
+
+// withLock - выполняет fn под сессионной advisory блокировкой на выделенном соединении.
+// Второй экземпляр, стартующий одновременно, ждёт и затем видит уже применённые миграции
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %w", err)
+	}
+	defer conn.Close()
+
+	if m.dialect == PostgreSQL {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		defer conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", lockID)
+	}
+
+	if err := m.ensureVersionTable(ctx, conn); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func (m *Migrator) versionTableExists(ctx context.Context, conn *sql.Conn) (bool, error) {
+	query := "SELECT to_regclass($1) IS NOT NULL"
+	if m.dialect == SQLite {
+		query = "SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = ?"
+	}
+
+	var exists bool
+	if err := conn.QueryRowContext(ctx, query, VersionTable).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check %s: %w", VersionTable, err)
+	}
+	return exists, nil
+}
+
+func (m *Migrator) ensureVersionTable(ctx context.Context, conn *sql.Conn) error {
+	exists, err := m.versionTableExists(ctx, conn)
+	if err != nil || exists {
+		return err
+	}
+
+	// схема как у goose для соответствующего диалекта
+	ddl := `CREATE TABLE IF NOT EXISTS ` + VersionTable + ` (
+    id SERIAL PRIMARY KEY,
+    version_id BIGINT NOT NULL,
+    is_applied BOOLEAN NOT NULL,
+    tstamp TIMESTAMP DEFAULT now()
+)`
+	if m.dialect == SQLite {
+		ddl = `CREATE TABLE IF NOT EXISTS ` + VersionTable + ` (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    version_id INTEGER NOT NULL,
+    is_applied INTEGER NOT NULL,
+    tstamp TIMESTAMP DEFAULT (datetime('now'))
+)`
+	}
+
+	if _, err := conn.ExecContext(ctx, ddl); err != nil {
+		return fmt.Errorf("failed to create %s: %w", VersionTable, err)
+	}
+	return nil
+}
+
+// appliedVersions - применённые версии и время применения.
+// goose при откате удаляет строку, но старые версии писали is_applied = false,
+// поэтому учитывается только последняя запись по каждой версии.
+// Для базы без таблицы версий возвращается пустой набор, сама таблица не создаётся
+func (m *Migrator) appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	versions := make(map[int64]time.Time)
+
+	exists, err := m.versionTableExists(ctx, conn)
+	if err != nil || !exists {
+		return versions, err
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT version_id, is_applied, tstamp
+FROM `+VersionTable+`
+WHERE version_id > 0
+ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", VersionTable, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			version   int64
+			isApplied bool
+			tstamp    sql.NullTime
+		)
+		if err := rows.Scan(&version, &isApplied, &tstamp); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", VersionTable, err)
+		}
+		// строки идут по id, поэтому последняя запись по версии перезаписывает предыдущие
+		if isApplied {
+			versions[version] = tstamp.Time
+		} else {
+			delete(versions, version)
+		}
+	}
+
+	return versions, rows.Err()
+}
+
+// apply - выполняет up или down секцию и обновляет таблицу версий
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration, statement string, up bool) error {
+	placeholder := "$1"
+	if m.dialect == SQLite {
+		placeholder = "?"
+	}
+
+	record := func(exec func(ctx context.Context, query string, args ...any) (sql.Result, error)) error {
+		var err error
+		if up {
+			_, err = exec(ctx, "INSERT INTO "+VersionTable+" (version_id, is_applied) VALUES ("+placeholder+", TRUE)", migration.Version)
+		} else {
+			_, err = exec(ctx, "DELETE FROM "+VersionTable+" WHERE version_id = "+placeholder, migration.Version)
+		}
+		return err
+	}
+
+	if migration.NoTransaction {
+		if statement != "" {
+			if _, err := conn.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+		}
+		if err := record(conn.ExecContext); err != nil {
+			return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+		}
+		return nil
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if statement != "" {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+	}
+	if err := record(tx.ExecContext); err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,65 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"chat-api/migrations"
+
+	_ "github.com/glebarez/go-sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSQLiteMigrator(t *testing.T) *Migrator {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := New(db, migrations.SQLiteFS, WithDialect(SQLite))
+	require.NoError(t, err)
+	return migrator
+}
+
+// TestMigrator_SQLite - полный цикл up/status/down на SQLite
+func TestMigrator_SQLite(t *testing.T) {
+	ctx := context.Background()
+	migrator := newSQLiteMigrator(t)
+
+	version, err := migrator.Version(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, version)
+
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(migrator.Migrations()))
+
+	version, err = migrator.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, migrator.Latest(), version)
+
+	applied, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied, "second up applies nothing")
+
+	rolledBack, err := migrator.Down(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, migrator.Latest(), rolledBack.Version)
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, len(migrator.Migrations()))
+	assert.True(t, statuses[0].Applied)
+	assert.False(t, statuses[0].AppliedAt.IsZero())
+	assert.False(t, statuses[len(statuses)-1].Applied)
+}
+
+// TestNew_UnknownDialect - неизвестный диалект отклоняется сразу
+func TestNew_UnknownDialect(t *testing.T) {
+	_, err := New(nil, migrations.FS, WithDialect("mysql"))
+	assert.ErrorContains(t, err, "unsupported dialect")
+}
@@ -0,0 +1,142 @@
+package migrate
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migration - одна миграция, прочитанная из файла NNN_name.sql
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+	// NoTransaction - миграция помечена -- +goose NO TRANSACTION
+	// (например, CREATE INDEX CONCURRENTLY) и выполняется вне транзакции
+	NoTransaction bool
+}
+
+const annotationPrefix = "-- +goose"
+
+const (
+	sectionNone = iota
+	sectionUp
+	sectionDown
+)
+
+// Load - читает все *.sql из корня fsys и сортирует их по версии
+func Load(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	migrations := make([]Migration, 0, len(files))
+	seen := make(map[int64]string, len(files))
+
+	for _, file := range files {
+		version, name, err := parseFileName(file)
+		if err != nil {
+			return nil, err
+		}
+		if other, exists := seen[version]; exists {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, other, file)
+		}
+		seen[version] = file
+
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", file, err)
+		}
+
+		migration, err := parseMigration(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse migration %s: %w", file, err)
+		}
+		migration.Version = version
+		migration.Name = name
+
+		migrations = append(migrations, migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// parseFileName - версия и имя из 001_create_chats.sql
+func parseFileName(file string) (int64, string, error) {
+	base := strings.TrimSuffix(path.Base(file), ".sql")
+
+	prefix, name, _ := strings.Cut(base, "_")
+	version, err := strconv.ParseInt(prefix, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, "", fmt.Errorf("invalid migration file name %s: expected NNN_name.sql", file)
+	}
+
+	return version, name, nil
+}
+
+// parseMigration - разбивает файл на секции Up и Down по аннотациям goose.
+// StatementBegin/StatementEnd не нужны: секция выполняется одним запросом
+func parseMigration(data string) (Migration, error) {
+	var (
+		migration Migration
+		up, down  strings.Builder
+		section   = sectionNone
+	)
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if annotation, ok := strings.CutPrefix(strings.TrimSpace(line), annotationPrefix); ok {
+			switch strings.ToUpper(strings.TrimSpace(annotation)) {
+			case "UP":
+				section = sectionUp
+			case "DOWN":
+				section = sectionDown
+			case "NO TRANSACTION":
+				migration.NoTransaction = true
+			case "STATEMENTBEGIN", "STATEMENTEND":
+			default:
+				return Migration{}, fmt.Errorf("unknown annotation %q", strings.TrimSpace(line))
+			}
+			continue
+		}
+
+		switch section {
+		case sectionUp:
+			up.WriteString(line)
+			up.WriteByte('\n')
+		case sectionDown:
+			down.WriteString(line)
+			down.WriteByte('\n')
+		default:
+			if strings.TrimSpace(line) != "" && !strings.HasPrefix(strings.TrimSpace(line), "--") {
+				return Migration{}, fmt.Errorf("statement outside of -- +goose Up/Down section")
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Migration{}, err
+	}
+
+	migration.Up = strings.TrimSpace(up.String())
+	migration.Down = strings.TrimSpace(down.String())
+
+	if migration.Up == "" {
+		return Migration{}, fmt.Errorf("missing -- +goose Up section")
+	}
+
+	return migration, nil
+}
@@ -0,0 +1,87 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"chat-api/migrations"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoad_Embedded - встроенные миграции разбираются и идут по возрастанию версии
+func TestLoad_Embedded(t *testing.T) {
+	loaded, err := Load(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, loaded)
+
+	assert.Equal(t, int64(1), loaded[0].Version)
+	assert.Equal(t, "create_chats_and_messages", loaded[0].Name)
+	assert.Contains(t, loaded[0].Up, "CREATE TABLE IF NOT EXISTS chats")
+	assert.NotContains(t, loaded[0].Up, "DROP TABLE")
+	assert.Contains(t, loaded[0].Down, "DROP TABLE IF EXISTS messages")
+
+	for i := 1; i < len(loaded); i++ {
+		assert.Less(t, loaded[i-1].Version, loaded[i].Version)
+	}
+}
+
+// TestLoad_Sections - разбор аннотаций goose
+func TestLoad_Sections(t *testing.T) {
+	fsys := fstest.MapFS{
+		"010_index.sql": {Data: []byte("-- +goose NO TRANSACTION\n-- +goose Up\nCREATE INDEX CONCURRENTLY idx ON t(a);\n-- +goose Down\nDROP INDEX idx;\n")},
+		"002_func.sql":  {Data: []byte("-- +goose Up\n-- +goose StatementBegin\nCREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql;\n-- +goose StatementEnd\n")},
+	}
+
+	loaded, err := Load(fsys)
+	require.NoError(t, err)
+	require.Len(t, loaded, 2)
+
+	assert.Equal(t, int64(2), loaded[0].Version)
+	assert.Equal(t, "CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql;", loaded[0].Up)
+	assert.Empty(t, loaded[0].Down)
+	assert.False(t, loaded[0].NoTransaction)
+
+	assert.Equal(t, int64(10), loaded[1].Version)
+	assert.True(t, loaded[1].NoTransaction)
+	assert.Equal(t, "DROP INDEX idx;", loaded[1].Down)
+}
+
+// TestLoad_Invalid - ошибки в именах и содержимом файлов
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{"bad file name", fstest.MapFS{"create.sql": {Data: []byte("-- +goose Up\nSELECT 1;")}}},
+		{"duplicate version", fstest.MapFS{
+			"001_a.sql":  {Data: []byte("-- +goose Up\nSELECT 1;")},
+			"0001_b.sql": {Data: []byte("-- +goose Up\nSELECT 1;")},
+		}},
+		{"missing up", fstest.MapFS{"001_a.sql": {Data: []byte("-- +goose Down\nSELECT 1;")}}},
+		{"statement outside section", fstest.MapFS{"001_a.sql": {Data: []byte("SELECT 1;\n-- +goose Up\nSELECT 1;")}}},
+		{"unknown annotation", fstest.MapFS{"001_a.sql": {Data: []byte("-- +goose Sideways\n-- +goose Up\nSELECT 1;")}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.files)
+			assert.Error(t, err)
+		})
+	}
+}
+
+// TestLoad_SQLiteParity - у каждой миграции PostgreSQL есть вариант для SQLite с той же версией
+func TestLoad_SQLiteParity(t *testing.T) {
+	postgres, err := Load(migrations.FS)
+	require.NoError(t, err)
+	sqlite, err := Load(migrations.SQLiteFS)
+	require.NoError(t, err)
+
+	require.Len(t, sqlite, len(postgres))
+	for i := range postgres {
+		assert.Equal(t, postgres[i].Version, sqlite[i].Version)
+		assert.Equal(t, postgres[i].Name, sqlite[i].Name)
+	}
+}
@@ -0,0 +1,23 @@
+-- +goose Up
+-- bot accounts; their slash commands are dispatched to url with a request signed by secret
+CREATE TABLE IF NOT EXISTS bots (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    url VARCHAR(2000) NOT NULL,
+    secret VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- a command name belongs to exactly one bot
+CREATE TABLE IF NOT EXISTS bot_commands (
+    id SERIAL PRIMARY KEY,
+    bot_id INTEGER NOT NULL REFERENCES bots(id) ON DELETE CASCADE,
+    name VARCHAR(32) NOT NULL UNIQUE
+);
+
+CREATE INDEX IF NOT EXISTS idx_bot_commands_bot_id ON bot_commands(bot_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS bot_commands;
+DROP TABLE IF EXISTS bots;
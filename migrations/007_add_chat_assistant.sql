@@ -0,0 +1,6 @@
+-- +goose Up
+-- per-chat switch for the assistant that replies to messages mentioning it
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS assistant_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE chats DROP COLUMN IF EXISTS assistant_enabled;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- per-chat slow mode: minimum interval between messages of one member, 0 - off
+ALTER TABLE chats ADD COLUMN IF NOT EXISTS slow_mode_seconds INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE chats DROP COLUMN IF EXISTS slow_mode_seconds;
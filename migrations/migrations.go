@@ -0,0 +1,19 @@
+// Package migrations - SQL миграции схемы, встроенные в бинарник
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// FS - файлы миграций PostgreSQL в формате goose: NNN_name.sql с секциями -- +goose Up / -- +goose Down
+//
+//go:embed *.sql
+var FS embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFS embed.FS
+
+// SQLiteFS - те же миграции для SQLite. Версии и имена файлов совпадают с FS,
+// поэтому каждая новая миграция добавляется в оба каталога
+var SQLiteFS, _ = fs.Sub(sqliteFS, "sqlite")
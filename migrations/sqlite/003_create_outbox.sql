@@ -0,0 +1,31 @@
+-- +goose Up
+-- domain events written in the same transaction as chat and message changes;
+-- no foreign key: chat.deleted must outlive its chat
+CREATE TABLE IF NOT EXISTS outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id INTEGER NOT NULL,
+    type VARCHAR(64) NOT NULL,
+    payload TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_chat_id ON outbox(chat_id);
+
+-- failed events wait for next_attempt_at (NULL - due now); after too many attempts they move here
+CREATE TABLE IF NOT EXISTS outbox_dead_letters (
+    id INTEGER PRIMARY KEY,
+    chat_id INTEGER NOT NULL,
+    type VARCHAR(64) NOT NULL,
+    payload TEXT NOT NULL,
+    attempts INTEGER NOT NULL,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at DATETIME,
+    dead_at DATETIME NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS outbox_dead_letters;
+DROP TABLE IF EXISTS outbox;
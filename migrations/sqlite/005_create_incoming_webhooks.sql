@@ -0,0 +1,20 @@
+-- +goose Up
+-- display name of whoever posted the message when it is not a user, e.g. an incoming webhook
+ALTER TABLE messages ADD COLUMN author_name VARCHAR(100) NOT NULL DEFAULT '';
+
+-- per-chat incoming webhooks; tokens are stored as sha256 hashes only
+CREATE TABLE IF NOT EXISTS incoming_webhooks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id INTEGER NOT NULL REFERENCES chats(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    rate_limit INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_incoming_webhooks_chat_id ON incoming_webhooks(chat_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS incoming_webhooks;
+ALTER TABLE messages DROP COLUMN author_name;
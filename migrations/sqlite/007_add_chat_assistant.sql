@@ -0,0 +1,6 @@
+-- +goose Up
+-- per-chat switch for the assistant that replies to messages mentioning it
+ALTER TABLE chats ADD COLUMN assistant_enabled BOOLEAN NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE chats DROP COLUMN assistant_enabled;
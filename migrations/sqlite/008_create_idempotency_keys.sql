@@ -0,0 +1,18 @@
+-- +goose Up
+-- responses to POST requests with an Idempotency-Key header, replayed to retries until expires_at;
+-- token identifies the request holding the key, so a request whose lease expired cannot overwrite the retry's
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    idempotency_key VARCHAR(255) PRIMARY KEY,
+    request_hash CHAR(64) NOT NULL,
+    token CHAR(32) NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    headers TEXT,
+    body BLOB,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Bot - учётная запись бота: его имя подписывает ответы в чате, а свои слэш-команды
+// он обрабатывает на внешнем адресе URL
+type Bot struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"not null;size:100;uniqueIndex"`
+	URL       string `gorm:"not null;size:2000"`
+	Secret    string `gorm:"not null;size:100"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	Commands []BotCommand `gorm:"foreignKey:BotID;constraint:OnDelete:CASCADE"`
+}
+
+// BotCommand - слэш-команда бота; имя уникально среди всех ботов
+type BotCommand struct {
+	ID    uint   `gorm:"primaryKey"`
+	BotID uint   `gorm:"not null;index"`
+	Name  string `gorm:"not null;size:32;uniqueIndex"`
+}
+
+// CreateBotRequest - запрос на создание бота
+type CreateBotRequest struct {
+	Name     string   `json:"name"`
+	URL      string   `json:"url"`
+	Secret   string   `json:"secret,omitempty"`
+	Commands []string `json:"commands"`
+}
+
+// BotResponse - бот в ответах API; Secret показывается только при создании
+type BotResponse struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Commands  []string  `json:"commands"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
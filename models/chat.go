@@ -5,9 +5,13 @@ import (
 )
 
 type Chat struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Title     string    `json:"title" gorm:"not null;size:200" validate:"required,min=1,max=200"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Messages  []Message `json:"messages,omitempty" gorm:"foreignKey:ChatID;constraint:OnDelete:CASCADE"`
+	ID    uint   `json:"id" gorm:"primaryKey"`
+	Title string `json:"title" gorm:"not null;size:200" validate:"required,min=1,max=200"`
+	// AssistantEnabled - ассистент отвечает на сообщения с упоминанием
+	AssistantEnabled bool `json:"assistant_enabled" gorm:"not null;default:false"`
+	// SlowModeSeconds - как часто каждый участник может писать в чат, 0 - без ограничения
+	SlowModeSeconds int       `json:"slow_mode_seconds" gorm:"not null;default:0"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	Messages        []Message `json:"messages,omitempty" gorm:"foreignKey:ChatID;constraint:OnDelete:CASCADE"`
 }
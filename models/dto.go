@@ -12,18 +12,33 @@ type CreateMessageRequest struct {
 	Text string `json:"text" validate:"required,min=1,max=5000"`
 }
 
+// SlowModeRequest - интервал медленного режима чата в секундах, 0 - выключить
+type SlowModeRequest struct {
+	Seconds *int `json:"seconds"`
+}
+
+// SlowModeResponse - настройка медленного режима после изменения
+type SlowModeResponse struct {
+	ChatID          uint `json:"chat_id"`
+	SlowModeSeconds int  `json:"slow_mode_seconds"`
+}
+
 // ChatResponse represents the chat response
 type ChatResponse struct {
-	ID        uint              `json:"id"`
-	Title     string            `json:"title"`
-	CreatedAt time.Time         `json:"created_at"`
-	Messages  []MessageResponse `json:"messages,omitempty"`
+	ID               uint              `json:"id"`
+	Title            string            `json:"title"`
+	AssistantEnabled bool              `json:"assistant_enabled"`
+	SlowModeSeconds  int               `json:"slow_mode_seconds"`
+	CreatedAt        time.Time         `json:"created_at"`
+	Messages         []MessageResponse `json:"messages,omitempty"`
 }
 
 // MessageResponse represents the message response
 type MessageResponse struct {
-	ID        uint      `json:"id"`
-	ChatID    uint      `json:"chat_id"`
-	Text      string    `json:"text"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         uint      `json:"id"`
+	ChatID     uint      `json:"chat_id"`
+	Text       string    `json:"text"`
+	AuthorName string    `json:"author_name,omitempty"`
+	Ephemeral  bool      `json:"ephemeral,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
 }
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"net/http"
+	"time"
+)
+
+// IdempotencyKey - первый ответ на POST с заголовком Idempotency-Key; повторы получают его же
+type IdempotencyKey struct {
+	Key string `gorm:"column:idempotency_key;primaryKey;size:255"`
+	// RequestHash - SHA-256 метода, пути и тела: тот же ключ с другим запросом - ошибка клиента
+	RequestHash string `gorm:"not null;size:64"`
+	// Token - кто занял ключ: ответ сохраняет или освобождает ключ только запрос с этим токеном
+	Token string `gorm:"not null;size:32"`
+	// StatusCode - 0, пока первый запрос выполняется
+	StatusCode int `gorm:"not null;default:0"`
+	// Header - заголовки, которые выставил обработчик; заголовки внешних middleware не сохраняются
+	Header    http.Header `gorm:"column:headers;serializer:json"`
+	Body      []byte
+	CreatedAt time.Time
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+// Pending - первый запрос с этим ключом ещё не завершён
+func (k *IdempotencyKey) Pending() bool {
+	return k.StatusCode == 0
+}
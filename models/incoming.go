@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// IncomingWebhook - адрес, по которому внешняя система пишет сообщения в чат без учётной записи
+type IncomingWebhook struct {
+	ID        uint   `gorm:"primaryKey"`
+	ChatID    uint   `gorm:"not null;index"`
+	Name      string `gorm:"not null;size:100"`
+	TokenHash string `gorm:"not null;size:64;uniqueIndex"`
+	// RateLimit - сообщений в минуту, 0 - значение из настроек
+	RateLimit int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// CreateIncomingWebhookRequest - запрос на создание входящего вебхука
+type CreateIncomingWebhookRequest struct {
+	Name      string `json:"name"`
+	RateLimit int    `json:"rate_limit,omitempty"`
+}
+
+// IncomingWebhookResponse - входящий вебхук в ответах API; Token и URL только при создании и смене токена
+type IncomingWebhookResponse struct {
+	ID        uint      `json:"id"`
+	ChatID    uint      `json:"chat_id"`
+	Name      string    `json:"name"`
+	RateLimit int       `json:"rate_limit"`
+	Token     string    `json:"token,omitempty"`
+	URL       string    `json:"url,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IncomingMessageRequest - сообщение от внешней системы; Name заменяет имя вебхука в подписи
+type IncomingMessageRequest struct {
+	Text string `json:"text"`
+	Name string `json:"name,omitempty"`
+}
@@ -5,9 +5,13 @@ import (
 )
 
 type Message struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	ChatID    uint      `json:"chat_id" gorm:"not null;index" validate:"required"`
-	Text      string    `json:"text" gorm:"not null;size:5000" validate:"required,min=1,max=5000"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	ChatID uint   `json:"chat_id" gorm:"not null;index" validate:"required"`
+	Text   string `json:"text" gorm:"not null;size:5000" validate:"required,min=1,max=5000"`
+	// AuthorName - подпись отправителя без учётной записи: входящего вебхука или бота
+	AuthorName string    `json:"author_name,omitempty" gorm:"not null;size:100;default:''"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	// Ephemeral - ответ бота только вызвавшему команду, в базе не хранится
+	Ephemeral bool `json:"ephemeral,omitempty" gorm:"-"`
 }
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+)
+
+// Типы событий outbox
+const (
+	EventChatCreated    = "chat.created"
+	EventChatDeleted    = "chat.deleted"
+	EventMessageCreated = "message.created"
+)
+
+// OutboxEvent - доменное событие, записанное в той же транзакции, что и изменение чата.
+// Строка удаляется после доставки во все приёмники
+type OutboxEvent struct {
+	ID        uint64 `gorm:"primaryKey"`
+	ChatID    uint   `gorm:"not null;index"`
+	Type      string `gorm:"not null;size:64"`
+	Payload   string `gorm:"not null"`
+	Attempts  int    `gorm:"not null;default:0"`
+	LastError string
+	// NextAttemptAt - после неудачи событие и следующие события чата ждут до этого времени; nil - сразу
+	NextAttemptAt *time.Time
+	CreatedAt     time.Time
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox"
+}
+
+// OutboxDeadLetter - событие, которое не удалось доставить за outbox.max_attempts попыток.
+// ID совпадает с id события в outbox
+type OutboxDeadLetter struct {
+	ID        uint64 `gorm:"primaryKey;autoIncrement:false"`
+	ChatID    uint   `gorm:"not null"`
+	Type      string `gorm:"not null;size:64"`
+	Payload   string `gorm:"not null"`
+	Attempts  int    `gorm:"not null"`
+	LastError string
+	CreatedAt time.Time
+	DeadAt    time.Time `gorm:"not null"`
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+)
+
+// Состояния доставки вебхука
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	// DeliveryDead - попытки исчерпаны, доставка ждёт ручного повтора
+	DeliveryDead = "dead"
+)
+
+// WebhookSubscription - подписка на события одного чата или всех чатов (ChatID = nil).
+// Пустой EventTypes - все типы событий
+type WebhookSubscription struct {
+	ID         uint   `gorm:"primaryKey"`
+	URL        string `gorm:"not null;size:2000"`
+	Secret     string `gorm:"not null;size:100"`
+	ChatID     *uint  `gorm:"index"`
+	EventTypes string `gorm:"not null;default:''"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// WebhookDelivery - доставка одного события одной подписке
+type WebhookDelivery struct {
+	ID             uint64 `gorm:"primaryKey"`
+	SubscriptionID uint   `gorm:"not null;uniqueIndex:idx_webhook_deliveries_event"`
+	EventID        uint64 `gorm:"not null;uniqueIndex:idx_webhook_deliveries_event"`
+	EventType      string `gorm:"not null;size:64"`
+	ChatID         uint   `gorm:"not null"`
+	// Payload - тело запроса, неизменное между попытками
+	Payload        string `gorm:"not null"`
+	Status         string `gorm:"not null;size:16"`
+	Attempts       int    `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time
+	LastError      string
+	LastStatusCode int
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+
+	History []WebhookAttempt `gorm:"foreignKey:DeliveryID;constraint:OnDelete:CASCADE"`
+}
+
+// WebhookAttempt - одна попытка доставки, для отладки интеграций
+type WebhookAttempt struct {
+	ID         uint64 `gorm:"primaryKey"`
+	DeliveryID uint64 `gorm:"not null;index"`
+	StatusCode int
+	Error      string
+	DurationMs float64
+	CreatedAt  time.Time
+}
+
+// CreateWebhookRequest - запрос на создание подписки
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret,omitempty"`
+	ChatID *uint    `json:"chat_id,omitempty"`
+	Events []string `json:"events,omitempty"`
+}
+
+// WebhookResponse - подписка в ответах API; Secret показывается только при создании
+type WebhookResponse struct {
+	ID        uint      `json:"id"`
+	URL       string    `json:"url"`
+	ChatID    *uint     `json:"chat_id"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookDeliveryResponse - доставка в ответах API
+type WebhookDeliveryResponse struct {
+	ID             uint64                   `json:"id"`
+	SubscriptionID uint                     `json:"subscription_id"`
+	EventID        uint64                   `json:"event_id"`
+	EventType      string                   `json:"event_type"`
+	ChatID         uint                     `json:"chat_id"`
+	Status         string                   `json:"status"`
+	Attempts       int                      `json:"attempts"`
+	NextAttemptAt  *time.Time               `json:"next_attempt_at,omitempty"`
+	LastError      string                   `json:"last_error,omitempty"`
+	LastStatusCode int                      `json:"last_status_code,omitempty"`
+	DeliveredAt    *time.Time               `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time                `json:"created_at"`
+	History        []WebhookAttemptResponse `json:"history,omitempty"`
+}
+
+// WebhookAttemptResponse - попытка доставки в ответах API
+type WebhookAttemptResponse struct {
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs float64   `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
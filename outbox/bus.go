@@ -0,0 +1,65 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+)
+
+// Handler - подписчик шины; ошибка приводит к повторной доставке события
+type Handler func(ctx context.Context, event Event) error
+
+// Bus - приёмник внутри процесса: вызывает подписчиков по очереди в порядке подписки
+type Bus struct {
+	mu       sync.RWMutex
+	nextID   int
+	handlers map[int]Handler
+	order    []int
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[int]Handler)}
+}
+
+// Subscribe - добавляет подписчика и возвращает функцию отписки
+func (b *Bus) Subscribe(handler Handler) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	id := b.nextID
+	b.handlers[id] = handler
+	b.order = append(b.order, id)
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		delete(b.handlers, id)
+		for i, existing := range b.order {
+			if existing == id {
+				b.order = append(b.order[:i], b.order[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+func (b *Bus) Name() string {
+	return "bus"
+}
+
+func (b *Bus) Deliver(ctx context.Context, event Event) error {
+	b.mu.RLock()
+	handlers := make([]Handler, 0, len(b.order))
+	for _, id := range b.order {
+		handlers = append(handlers, b.handlers[id])
+	}
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
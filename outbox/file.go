@@ -0,0 +1,48 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileSink - дописывает события в NDJSON файл, по строке на событие.
+// Запись сбрасывается на диск до подтверждения доставки
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed open outbox file: %w", err)
+	}
+	return &FileSink{file: file}, nil
+}
+
+func (f *FileSink) Name() string {
+	return "file"
+}
+
+func (f *FileSink) Deliver(ctx context.Context, event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := f.file.Write(line); err != nil {
+		return err
+	}
+	return f.file.Sync()
+}
+
+func (f *FileSink) Close() error {
+	return f.file.Close()
+}
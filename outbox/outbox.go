@@ -0,0 +1,50 @@
+// Package outbox - доставка доменных событий из таблицы outbox во внешние приёмники.
+// Repository с WithOutbox пишет событие в одной транзакции с изменением чата, а Relay
+// читает таблицу и доставляет события как минимум один раз, по порядку внутри каждого чата
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"chat-api/models"
+)
+
+// Event - событие в том виде, в котором его получают приёмники
+type Event struct {
+	// ID - возрастает в пределах чата; при повторной доставке тот же, по нему получатель отбрасывает дубли
+	ID        uint64          `json:"id"`
+	Type      string          `json:"type"`
+	ChatID    uint            `json:"chat_id"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+func eventFromRow(row models.OutboxEvent) Event {
+	return Event{
+		ID:        row.ID,
+		Type:      row.Type,
+		ChatID:    row.ChatID,
+		Data:      json.RawMessage(row.Payload),
+		CreatedAt: row.CreatedAt,
+	}
+}
+
+// Sink - приёмник событий. Deliver должен быть идемпотентным со стороны получателя:
+// после ошибки любого приёмника событие доставляется повторно во все приёмники
+type Sink interface {
+	Name() string
+	Deliver(ctx context.Context, event Event) error
+}
+
+// Logger - логгер ошибок доставки
+type Logger interface {
+	LogError(ctx context.Context, operation string, err error)
+}
+
+// Metrics - счётчики доставки по приёмникам
+type Metrics interface {
+	OutboxDelivered(sink string)
+	OutboxFailed(sink string)
+}
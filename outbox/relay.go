@@ -0,0 +1,261 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"chat-api/database"
+	"chat-api/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultBatchSize   = 100
+	defaultInterval    = time.Second
+	defaultMaxAttempts = 20
+	defaultBackoff     = time.Second
+	defaultMaxBackoff  = 10 * time.Minute
+
+	// maxErrorLength - сколько текста ошибки хранить в outbox.last_error
+	maxErrorLength = 1000
+
+	// lockID - ключ database.TryLock: пачку обрабатывает только один экземпляр,
+	// иначе события одного чата могли бы обогнать друг друга
+	lockID int64 = 0x636861745f6f7574 // "chat_out"
+)
+
+type Option func(*Relay)
+
+// WithBatchSize - сколько событий читать за один проход
+func WithBatchSize(size int) Option {
+	return func(r *Relay) {
+		if size > 0 {
+			r.batchSize = size
+		}
+	}
+}
+
+// WithInterval - пауза между проходами, когда таблица пуста или доставка не удалась
+func WithInterval(interval time.Duration) Option {
+	return func(r *Relay) {
+		if interval > 0 {
+			r.interval = interval
+		}
+	}
+}
+
+// WithRetry - после неудачи событие повторяется через backoff, каждая следующая пауза вдвое больше,
+// но не больше maxBackoff. После maxAttempts неудач событие переносится в outbox_dead_letters
+func WithRetry(maxAttempts int, backoff, maxBackoff time.Duration) Option {
+	return func(r *Relay) {
+		if maxAttempts > 0 {
+			r.maxAttempts = maxAttempts
+		}
+		if backoff > 0 {
+			r.backoff = backoff
+		}
+		if maxBackoff >= r.backoff {
+			r.maxBackoff = maxBackoff
+		}
+	}
+}
+
+func WithLogger(logger Logger) Option {
+	return func(r *Relay) {
+		r.logger = logger
+	}
+}
+
+func WithMetrics(metrics Metrics) Option {
+	return func(r *Relay) {
+		r.metrics = metrics
+	}
+}
+
+// Relay - доставляет события из таблицы outbox во все приёмники
+type Relay struct {
+	db          *gorm.DB
+	sinks       []Sink
+	batchSize   int
+	interval    time.Duration
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	logger      Logger
+	metrics     Metrics
+	now         func() time.Time
+}
+
+func NewRelay(db *gorm.DB, sinks []Sink, opts ...Option) *Relay {
+	r := &Relay{
+		db:          db,
+		sinks:       sinks,
+		batchSize:   defaultBatchSize,
+		interval:    defaultInterval,
+		maxAttempts: defaultMaxAttempts,
+		backoff:     defaultBackoff,
+		maxBackoff:  defaultMaxBackoff,
+		logger:      nopLogger{},
+		metrics:     nopMetrics{},
+		now:         time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Run - обрабатывает таблицу до отмены ctx. Полная пачка означает очередь, поэтому
+// следующий проход начинается сразу, иначе после паузы
+func (r *Relay) Run(ctx context.Context) {
+	for {
+		delivered, err := r.ProcessBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.logger.LogError(ctx, "Outbox relay:", err)
+		}
+
+		wait := r.interval
+		if err == nil && delivered == r.batchSize {
+			wait = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// ProcessBatch - один проход: события по возрастанию id, каждое во все приёмники.
+// После неудачи события оно и следующие события его чата ждут повтора, чтобы не нарушить порядок;
+// в пачку они не попадают и не мешают другим чатам. Возвращает число доставленных событий
+func (r *Relay) ProcessBatch(ctx context.Context) (int, error) {
+	unlock, ok, err := database.TryLock(ctx, r.db, lockID)
+	if err != nil || !ok {
+		return 0, err
+	}
+	defer unlock()
+
+	now := r.now()
+	var rows []models.OutboxEvent
+	err = r.db.WithContext(ctx).
+		Where("next_attempt_at IS NULL OR next_attempt_at <= ?", now).
+		Where("NOT EXISTS (SELECT 1 FROM outbox waiting WHERE waiting.chat_id = outbox.chat_id AND waiting.id < outbox.id AND waiting.next_attempt_at > ?)", now).
+		Order("id").Limit(r.batchSize).Find(&rows).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed read outbox: %w", err)
+	}
+
+	var (
+		delivered int
+		blocked   = make(map[uint]bool)
+		errs      []error
+	)
+	for _, row := range rows {
+		if blocked[row.ChatID] {
+			continue
+		}
+
+		if err := r.deliver(ctx, eventFromRow(row)); err != nil {
+			blocked[row.ChatID] = true
+			errs = append(errs, fmt.Errorf("event %d: %w", row.ID, err))
+			if err := r.recordFailure(ctx, row, err); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		// до удаления строки событие уже доставлено: при падении здесь оно придёт повторно
+		if err := r.db.WithContext(ctx).Delete(&models.OutboxEvent{}, row.ID).Error; err != nil {
+			return delivered, fmt.Errorf("failed delete delivered event %d: %w", row.ID, err)
+		}
+		delivered++
+	}
+
+	return delivered, errors.Join(errs...)
+}
+
+func (r *Relay) deliver(ctx context.Context, event Event) error {
+	for _, sink := range r.sinks {
+		if err := sink.Deliver(ctx, event); err != nil {
+			r.metrics.OutboxFailed(sink.Name())
+			return fmt.Errorf("sink %s: %w", sink.Name(), err)
+		}
+		r.metrics.OutboxDelivered(sink.Name())
+	}
+	return nil
+}
+
+// recordFailure - откладывает событие до следующей попытки или переносит в мёртвые после maxAttempts неудач
+func (r *Relay) recordFailure(ctx context.Context, row models.OutboxEvent, deliveryErr error) error {
+	message := deliveryErr.Error()
+	if len(message) > maxErrorLength {
+		message = message[:maxErrorLength]
+	}
+
+	now := r.now()
+	attempts := row.Attempts + 1
+	if attempts >= r.maxAttempts {
+		return r.bury(ctx, row, attempts, message, now)
+	}
+
+	err := r.db.WithContext(ctx).Model(&models.OutboxEvent{}).Where("id = ?", row.ID).Updates(map[string]any{
+		"attempts":        attempts,
+		"last_error":      message,
+		"next_attempt_at": now.Add(r.retryAfter(attempts)),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed record outbox failure %d: %w", row.ID, err)
+	}
+	return nil
+}
+
+// bury - переносит событие в outbox_dead_letters; следующие события чата после этого доставляются
+func (r *Relay) bury(ctx context.Context, row models.OutboxEvent, attempts int, message string, now time.Time) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		dead := &models.OutboxDeadLetter{
+			ID:        row.ID,
+			ChatID:    row.ChatID,
+			Type:      row.Type,
+			Payload:   row.Payload,
+			Attempts:  attempts,
+			LastError: message,
+			CreatedAt: row.CreatedAt,
+			DeadAt:    now,
+		}
+		if err := tx.Create(dead).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.OutboxEvent{}, row.ID).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed move outbox event %d to dead letters: %w", row.ID, err)
+	}
+
+	r.logger.LogError(ctx, "Outbox relay:", fmt.Errorf("event %d moved to dead letters after %d attempts: %s", row.ID, attempts, message))
+	return nil
+}
+
+// retryAfter - пауза после attempts неудач: backoff, 2*backoff, 4*backoff... не больше maxBackoff
+func (r *Relay) retryAfter(attempts int) time.Duration {
+	wait := r.backoff
+	for i := 1; i < attempts && wait < r.maxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, r.maxBackoff)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) LogError(context.Context, string, error) {}
+
+type nopMetrics struct{}
+
+func (nopMetrics) OutboxDelivered(string) {}
+func (nopMetrics) OutboxFailed(string)    {}
@@ -0,0 +1,327 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"chat-api/internal/testutil"
+	"chat-api/models"
+	"chat-api/repository"
+	"chat-api/tracing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// newOutboxDB - SQLite со схемой и репозиторий, пишущий события в outbox
+func newOutboxDB(t *testing.T) (*gorm.DB, repository.ChatRepository) {
+	t.Helper()
+	db := testutil.SQLite(t)
+	return db, repository.NewRepository(db, testutil.DiscardLogger{}, repository.WithOutbox())
+}
+
+// recordingSink - запоминает доставленные события и отказывает, пока fail возвращает true
+type recordingSink struct {
+	mu     sync.Mutex
+	events []Event
+	fail   func(Event) bool
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+
+func (s *recordingSink) Deliver(_ context.Context, event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail != nil && s.fail(event) {
+		return errors.New("sink is down")
+	}
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *recordingSink) types(chatID uint) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var types []string
+	for _, event := range s.events {
+		if event.ChatID == chatID {
+			types = append(types, event.Type)
+		}
+	}
+	return types
+}
+
+func pending(t *testing.T, db *gorm.DB) []models.OutboxEvent {
+	t.Helper()
+	var rows []models.OutboxEvent
+	require.NoError(t, db.Order("id").Find(&rows).Error)
+	return rows
+}
+
+// TestRelay_DeliversInOrder - изменения чата попадают в outbox и доставляются по порядку
+func TestRelay_DeliversInOrder(t *testing.T) {
+	ctx := context.Background()
+	db, repo := newOutboxDB(t)
+
+	chat, err := repo.Create(ctx, &models.Chat{Title: "outbox"})
+	require.NoError(t, err)
+	message, err := repo.CreateMessage(ctx, chat.ID, &models.Message{ChatID: chat.ID, Text: "hello"})
+	require.NoError(t, err)
+	require.NoError(t, repo.Delete(ctx, chat.ID))
+
+	// удаление несуществующего чата и сообщение в него событий не создают
+	require.NoError(t, repo.Delete(ctx, chat.ID))
+	_, err = repo.CreateMessage(ctx, chat.ID, &models.Message{ChatID: chat.ID, Text: "late"})
+	require.ErrorIs(t, err, repository.ErrNotFound)
+
+	require.Len(t, pending(t, db), 3)
+
+	sink := &recordingSink{}
+	delivered, err := NewRelay(db, []Sink{sink}).ProcessBatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, delivered)
+	assert.Empty(t, pending(t, db))
+
+	assert.Equal(t, []string{models.EventChatCreated, models.EventMessageCreated, models.EventChatDeleted}, sink.types(chat.ID))
+
+	var data models.Message
+	require.NoError(t, json.Unmarshal(sink.events[1].Data, &data))
+	assert.Equal(t, message.ID, data.ID)
+	assert.Equal(t, "hello", data.Text)
+}
+
+// TestRelay_FailureKeepsChatOrder - после ошибки события чата ждут повтора, другие чаты доставляются
+func TestRelay_FailureKeepsChatOrder(t *testing.T) {
+	ctx := context.Background()
+	db, repo := newOutboxDB(t)
+
+	broken, err := repo.Create(ctx, &models.Chat{Title: "broken"})
+	require.NoError(t, err)
+	healthy, err := repo.Create(ctx, &models.Chat{Title: "healthy"})
+	require.NoError(t, err)
+	for _, id := range []uint{broken.ID, healthy.ID} {
+		_, err := repo.CreateMessage(ctx, id, &models.Message{ChatID: id, Text: "message"})
+		require.NoError(t, err)
+	}
+
+	down := true
+	sink := &recordingSink{fail: func(event Event) bool { return down && event.ChatID == broken.ID }}
+	relay := NewRelay(db, []Sink{sink})
+	now := time.Now()
+	relay.now = func() time.Time { return now }
+
+	delivered, err := relay.ProcessBatch(ctx)
+	assert.ErrorContains(t, err, "sink is down")
+	assert.Equal(t, 2, delivered)
+	assert.Empty(t, sink.types(broken.ID))
+	assert.Equal(t, []string{models.EventChatCreated, models.EventMessageCreated}, sink.types(healthy.ID))
+
+	rows := pending(t, db)
+	require.Len(t, rows, 2)
+	assert.Equal(t, 1, rows[0].Attempts)
+	assert.Contains(t, rows[0].LastError, "sink is down")
+	assert.Zero(t, rows[1].Attempts, "later events of the chat are not attempted")
+	require.NotNil(t, rows[0].NextAttemptAt)
+
+	// до следующей попытки события чата не читаются
+	down = false
+	delivered, err = relay.ProcessBatch(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, delivered)
+
+	now = now.Add(defaultBackoff)
+	delivered, err = relay.ProcessBatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, delivered)
+	assert.Equal(t, []string{models.EventChatCreated, models.EventMessageCreated}, sink.types(broken.ID))
+}
+
+// TestRelay_BlockedChatDoesNotStarve - события чата, ждущего повтора, не занимают пачку
+func TestRelay_BlockedChatDoesNotStarve(t *testing.T) {
+	ctx := context.Background()
+	db, repo := newOutboxDB(t)
+
+	broken, err := repo.Create(ctx, &models.Chat{Title: "broken"})
+	require.NoError(t, err)
+	for range 3 {
+		_, err := repo.CreateMessage(ctx, broken.ID, &models.Message{ChatID: broken.ID, Text: "message"})
+		require.NoError(t, err)
+	}
+	healthy, err := repo.Create(ctx, &models.Chat{Title: "healthy"})
+	require.NoError(t, err)
+
+	sink := &recordingSink{fail: func(event Event) bool { return event.ChatID == broken.ID }}
+	relay := NewRelay(db, []Sink{sink}, WithBatchSize(2), WithRetry(5, time.Minute, time.Hour))
+	now := time.Now()
+	relay.now = func() time.Time { return now }
+
+	_, err = relay.ProcessBatch(ctx)
+	require.Error(t, err)
+
+	delivered, err := relay.ProcessBatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, []string{models.EventChatCreated}, sink.types(healthy.ID))
+}
+
+// TestRelay_DeadLetters - после maxAttempts неудач событие переносится в мёртвые, и чат продолжает доставляться
+func TestRelay_DeadLetters(t *testing.T) {
+	ctx := context.Background()
+	db, repo := newOutboxDB(t)
+
+	chat, err := repo.Create(ctx, &models.Chat{Title: "poison"})
+	require.NoError(t, err)
+	_, err = repo.CreateMessage(ctx, chat.ID, &models.Message{ChatID: chat.ID, Text: "after"})
+	require.NoError(t, err)
+
+	sink := &recordingSink{fail: func(event Event) bool { return event.Type == models.EventChatCreated }}
+	relay := NewRelay(db, []Sink{sink}, WithRetry(3, time.Second, 2*time.Second))
+	now := time.Now()
+	relay.now = func() time.Time { return now }
+
+	var waits []time.Duration
+	for range 3 {
+		_, err = relay.ProcessBatch(ctx)
+		require.Error(t, err)
+		if rows := pending(t, db); rows[0].NextAttemptAt != nil {
+			waits = append(waits, rows[0].NextAttemptAt.Sub(now))
+		}
+		now = now.Add(time.Hour)
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, waits)
+
+	var dead []models.OutboxDeadLetter
+	require.NoError(t, db.Find(&dead).Error)
+	require.Len(t, dead, 1)
+	assert.Equal(t, models.EventChatCreated, dead[0].Type)
+	assert.Equal(t, 3, dead[0].Attempts)
+	assert.Contains(t, dead[0].LastError, "sink is down")
+
+	delivered, err := relay.ProcessBatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, []string{models.EventMessageCreated}, sink.types(chat.ID))
+	assert.Empty(t, pending(t, db))
+}
+
+// TestRelay_AtLeastOnce - при отказе второго приёмника событие повторяется во всех
+func TestRelay_AtLeastOnce(t *testing.T) {
+	ctx := context.Background()
+	db, repo := newOutboxDB(t)
+
+	_, err := repo.Create(ctx, &models.Chat{Title: "twice"})
+	require.NoError(t, err)
+
+	first := &recordingSink{}
+	failures := 1
+	second := &recordingSink{fail: func(Event) bool { failures--; return failures >= 0 }}
+	relay := NewRelay(db, []Sink{first, second})
+	now := time.Now()
+	relay.now = func() time.Time { return now }
+
+	_, err = relay.ProcessBatch(ctx)
+	require.Error(t, err)
+	now = now.Add(defaultBackoff)
+	_, err = relay.ProcessBatch(ctx)
+	require.NoError(t, err)
+
+	require.Len(t, first.events, 2)
+	assert.Equal(t, first.events[0].ID, first.events[1].ID)
+	assert.Len(t, second.events, 1)
+}
+
+// TestRelay_Run - фоновая доставка и остановка по контексту
+func TestRelay_Run(t *testing.T) {
+	db, repo := newOutboxDB(t)
+
+	bus := NewBus()
+	received := make(chan Event, 1)
+	unsubscribe := bus.Subscribe(func(_ context.Context, event Event) error {
+		received <- event
+		return nil
+	})
+	defer unsubscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		NewRelay(db, []Sink{bus}, WithInterval(10*time.Millisecond)).Run(ctx)
+		close(done)
+	}()
+
+	chat, err := repo.Create(context.Background(), &models.Chat{Title: "bus"})
+	require.NoError(t, err)
+
+	select {
+	case event := <-received:
+		assert.Equal(t, chat.ID, event.ChatID)
+		assert.Equal(t, models.EventChatCreated, event.Type)
+	case <-time.After(2 * time.Second):
+		t.Fatal("event was not delivered")
+	}
+
+	cancel()
+	<-done
+}
+
+// TestWebhookSink - JSON тело, заголовки события, не-2xx ответ - ошибка
+func TestWebhookSink(t *testing.T) {
+	var (
+		status = http.StatusNoContent
+		got    *http.Request
+		body   []byte
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	testutil.WithTracing(t)
+	sink := NewWebhookSink(srv.URL, time.Second)
+	event := Event{ID: 42, Type: models.EventMessageCreated, ChatID: 7, Data: json.RawMessage(`{"text":"hi"}`)}
+
+	ctx, span := tracing.Tracer().Start(context.Background(), "relay")
+	defer span.End()
+	require.NoError(t, sink.Deliver(ctx, event))
+	assert.Equal(t, http.MethodPost, got.Method)
+	assert.Contains(t, got.Header.Get("traceparent"), span.SpanContext().TraceID().String())
+	assert.Equal(t, "42", got.Header.Get("X-Event-ID"))
+	assert.Equal(t, models.EventMessageCreated, got.Header.Get("X-Event-Type"))
+	assert.JSONEq(t, `{"id":42,"type":"message.created","chat_id":7,"data":{"text":"hi"},"created_at":"0001-01-01T00:00:00Z"}`, string(body))
+
+	status = http.StatusBadGateway
+	assert.ErrorContains(t, sink.Deliver(context.Background(), event), "502")
+}
+
+// TestFileSink - события дописываются строками NDJSON
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+	require.NoError(t, sink.Deliver(context.Background(), Event{ID: 1, Type: models.EventChatCreated, Data: json.RawMessage(`{}`)}))
+	require.NoError(t, sink.Deliver(context.Background(), Event{ID: 2, Type: models.EventChatDeleted, Data: json.RawMessage(`{}`)}))
+	require.NoError(t, sink.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+
+	var event Event
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &event))
+	assert.Equal(t, uint64(2), event.ID)
+}
@@ -0,0 +1,65 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"chat-api/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// WebhookSink - отправляет каждое событие POST запросом с JSON телом.
+// Успех - любой ответ 2xx; заголовок X-Event-ID позволяет получателю отбросить повтор
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookSink(url string, timeout time.Duration) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (w *WebhookSink) Name() string {
+	return "webhook"
+}
+
+func (w *WebhookSink) Deliver(ctx context.Context, event Event) (err error) {
+	ctx, span := tracing.StartClient(ctx, "Outbox.Webhook", attribute.String("event.type", event.Type))
+	defer func() { tracing.End(span, err) }()
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", strconv.FormatUint(event.ID, 10))
+	req.Header.Set("X-Event-Type", event.Type)
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
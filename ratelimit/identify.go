@@ -0,0 +1,144 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"chat-api/handlers"
+	"chat-api/models"
+	"chat-api/repository"
+	"chat-api/service"
+)
+
+// userCacheTTL - сколько помнить найденного по ключу пользователя; удалённый ключ перестаёт действовать не позже
+const userCacheTTL = time.Minute
+
+// keyLookupsPerMinute - сколько ключей, которых нет в кэше, один адрес может проверить в базе за минуту.
+// Дальше ключи с этого адреса не проверяются, и клиентом считается адрес
+const keyLookupsPerMinute = 60
+
+// Users - поиск пользователя по API ключу; в приложении это repository.Admin
+type Users interface {
+	UserByAPIKey(ctx context.Context, apiKey string) (*models.User, error)
+}
+
+// Identifier - определяет клиента запроса: пользователя по API ключу из Authorization: Bearer
+// или X-API-Key, иначе IP адрес. Ключ, которого нет в users, не отличает клиента от других запросов с его адреса
+type Identifier struct {
+	users             Users
+	trustForwardedFor bool
+	now               func() time.Time
+	// lookups - бюджет поиска ключей в базе на адрес: случайные ключи не должны давать запрос в базу каждый раз
+	lookups *Limiter
+
+	mu    sync.Mutex
+	cache map[string]cachedUser
+}
+
+type cachedUser struct {
+	id      uint
+	expires time.Time
+}
+
+// NewIdentifier - users может быть nil, например для хранилища в памяти: тогда клиент - всегда IP адрес
+func NewIdentifier(users Users, trustForwardedFor bool) *Identifier {
+	return &Identifier{
+		users:             users,
+		trustForwardedFor: trustForwardedFor,
+		now:               time.Now,
+		lookups:           NewLimiter(),
+		cache:             make(map[string]cachedUser),
+	}
+}
+
+// Identify - передаёт клиента запроса дальше как участника чата: по нему считают бюджеты Middleware,
+// медленный режим и ключи идемпотентности
+func (id *Identifier) Identify() handlers.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			member := id.ClientKey(r)
+			next(w, r.WithContext(service.WithMember(r.Context(), member)))
+		}
+	}
+}
+
+// ClientKey - "user:<id>" для известного API ключа, иначе "ip:<адрес>"
+func (id *Identifier) ClientKey(r *http.Request) string {
+	ip := "ip:" + clientIP(r, id.trustForwardedFor)
+	if userID, ok := id.user(r.Context(), apiKey(r), ip); ok {
+		return "user:" + strconv.FormatUint(uint64(userID), 10)
+	}
+	return ip
+}
+
+func (id *Identifier) user(ctx context.Context, key, ip string) (uint, bool) {
+	if key == "" || id.users == nil {
+		return 0, false
+	}
+
+	hash := repository.HashAPIKey(key)
+	now := id.now()
+
+	id.mu.Lock()
+	cached, ok := id.cache[hash]
+	id.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.id, true
+	}
+
+	// неизвестные ключи не кэшируются: случайные значения не должны занимать память,
+	// а их проверки в базе ограничены бюджетом адреса
+	if !id.lookups.Allow(ip, keyLookupsPerMinute, now).Allowed {
+		return 0, false
+	}
+	user, err := id.users.UserByAPIKey(ctx, key)
+	if err != nil {
+		return 0, false
+	}
+
+	id.mu.Lock()
+	for hash, cached := range id.cache {
+		if !now.Before(cached.expires) {
+			delete(id.cache, hash)
+		}
+	}
+	id.cache[hash] = cachedUser{id: user.ID, expires: now.Add(userCacheTTL)}
+	id.mu.Unlock()
+
+	return user.ID, true
+}
+
+func apiKey(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+// clientIP - адрес соединения. За доверенным прокси - последний адрес X-Forwarded-For:
+// его дописал сам прокси, а всё левее клиент может подставить любым
+func clientIP(r *http.Request, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		forwarded := r.Header.Values("X-Forwarded-For")
+		if len(forwarded) > 0 {
+			last := forwarded[len(forwarded)-1]
+			if i := strings.LastIndexByte(last, ','); i >= 0 {
+				last = last[i+1:]
+			}
+			if ip := strings.TrimSpace(last); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval - как часто удалять состояние ключей, которые давно не обращались.
+// Ведро полностью наполняется за минуту, поэтому такое состояние ничем не отличается от нового
+const sweepInterval = time.Minute
+
+// Limiter - token bucket на каждый ключ: до perMinute запросов подряд,
+// дальше по одному каждые minute/perMinute. Состояние в памяти процесса
+type Limiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Result - решение по запросу и состояние ведра после него
+type Result struct {
+	Allowed bool
+	Limit   int
+	// Remaining - сколько запросов можно сделать сразу
+	Remaining int
+	// RetryAfter - через сколько появится следующий токен, если запрос отклонён
+	RetryAfter time.Duration
+	// Reset - через сколько ведро наполнится полностью
+	Reset time.Duration
+}
+
+func NewLimiter() *Limiter {
+	return &Limiter{buckets: make(map[string]*bucket)}
+}
+
+// Allow - забирает токен ключа key
+func (l *Limiter) Allow(key string, perMinute int, now time.Time) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	capacity := float64(perMinute)
+	rate := capacity / time.Minute.Seconds()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = min(capacity, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	result := Result{Limit: perMinute}
+	if b.tokens < 1 {
+		result.RetryAfter = seconds((1 - b.tokens) / rate)
+	} else {
+		b.tokens--
+		result.Allowed = true
+	}
+	result.Remaining = int(math.Floor(b.tokens))
+	result.Reset = seconds((capacity - b.tokens) / rate)
+	return result
+}
+
+// Forget - удаляет состояние ключа, например отозванного вебхука
+func (l *Limiter) Forget(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.buckets, key)
+}
+
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= time.Minute {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,114 @@
+// Package ratelimit - ограничение частоты запросов: token bucket на клиента
+// с отдельным бюджетом для каждого класса маршрутов
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"chat-api/handlers"
+	"chat-api/service"
+)
+
+// Классы маршрутов с отдельными бюджетами
+const (
+	ClassRead     = "read"
+	ClassWrite    = "write"
+	ClassMessages = "messages"
+)
+
+// Config - бюджеты в запросах в минуту на клиента; 0 - класс не ограничивается.
+// Клиента определяет Identify, который должен стоять в цепочке раньше
+type Config struct {
+	Read     int
+	Write    int
+	Messages int
+	// TrustForwardedFor - адрес клиента берётся из X-Forwarded-For, сервер стоит за прокси
+	TrustForwardedFor bool
+	// Exempt - пути без ограничений, например проверки готовности и метрики
+	Exempt []string
+}
+
+type Metrics interface {
+	RateLimited(class string)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) RateLimited(string) {}
+
+// Middleware - отклоняет запросы сверх бюджета клиента с 429 и Retry-After.
+// Ответы ограниченных классов получают заголовки RateLimit-Limit, RateLimit-Remaining и RateLimit-Reset
+func Middleware(limiter *Limiter, cfg Config, metrics Metrics) handlers.Middleware {
+	if metrics == nil {
+		metrics = nopMetrics{}
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if slices.Contains(cfg.Exempt, r.URL.Path) {
+				next(w, r)
+				return
+			}
+
+			class := Classify(r)
+			perMinute := cfg.budget(class)
+			if perMinute <= 0 {
+				next(w, r)
+				return
+			}
+
+			client := service.MemberFromContext(r.Context())
+			if client == "" {
+				client = "ip:" + clientIP(r, cfg.TrustForwardedFor)
+			}
+			result := limiter.Allow(class+"|"+client, perMinute, time.Now())
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+			h.Set("RateLimit-Policy", strconv.Itoa(result.Limit)+";w=60")
+
+			if !result.Allowed {
+				metrics.RateLimited(class)
+				h.Set("Retry-After", strconv.Itoa(max(1, ceilSeconds(result.RetryAfter))))
+				http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+
+			next(w, r)
+		}
+	}
+}
+
+func (c Config) budget(class string) int {
+	switch class {
+	case ClassRead:
+		return c.Read
+	case ClassMessages:
+		return c.Messages
+	default:
+		return c.Write
+	}
+}
+
+// Classify - класс маршрута: отправка сообщений в чат, прочие изменения или чтение
+func Classify(r *http.Request) string {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return ClassRead
+	}
+	if r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/chats/") && strings.HasSuffix(r.URL.Path, "/messages") {
+		return ClassMessages
+	}
+	return ClassWrite
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,223 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"chat-api/handlers"
+	"chat-api/models"
+	"chat-api/repository"
+	"chat-api/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter()
+	now := time.Unix(0, 0)
+
+	for i := range 3 {
+		result := l.Allow("a", 3, now)
+		require.True(t, result.Allowed)
+		assert.Equal(t, 2-i, result.Remaining)
+	}
+	result := l.Allow("a", 3, now)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 20*time.Second, result.RetryAfter)
+	assert.Equal(t, time.Minute, result.Reset)
+
+	// другие ключи не затронуты
+	assert.True(t, l.Allow("b", 3, now).Allowed)
+
+	assert.True(t, l.Allow("a", 3, now.Add(20*time.Second)).Allowed)
+	assert.False(t, l.Allow("a", 3, now.Add(20*time.Second)).Allowed)
+
+	l.Forget("a")
+	assert.True(t, l.Allow("a", 3, now.Add(20*time.Second)).Allowed)
+}
+
+func TestLimiter_Sweep(t *testing.T) {
+	l := NewLimiter()
+	now := time.Unix(0, 0)
+
+	l.Allow("a", 3, now)
+	l.Allow("b", 3, now.Add(90*time.Second))
+	assert.Len(t, l.buckets, 1)
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		method, path, class string
+	}{
+		{http.MethodGet, "/chats/1/messages", ClassRead},
+		{http.MethodPost, "/chats/1/messages", ClassMessages},
+		{http.MethodPost, "/chats", ClassWrite},
+		{http.MethodDelete, "/chats/1", ClassWrite},
+		{http.MethodPost, "/hooks/whin_abc", ClassWrite},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.class, Classify(httptest.NewRequest(tt.method, tt.path, nil)), tt.method+" "+tt.path)
+	}
+}
+
+type fakeUsers map[string]uint
+
+func (u fakeUsers) UserByAPIKey(_ context.Context, apiKey string) (*models.User, error) {
+	id, ok := u[apiKey]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &models.User{ID: id}, nil
+}
+
+func TestClientKey(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/chats", nil)
+	r.RemoteAddr = "10.0.0.1:5000"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1, 203.0.113.7")
+	assert.Equal(t, "ip:10.0.0.1", NewIdentifier(nil, false).ClientKey(r))
+	// левые адреса клиент мог подставить сам, доверять можно только добавленному прокси
+	assert.Equal(t, "ip:203.0.113.7", NewIdentifier(nil, true).ClientKey(r))
+
+	users := fakeUsers{"secret": 7}
+	id := NewIdentifier(users, false)
+	r.Header.Set("Authorization", "Bearer secret")
+	assert.Equal(t, "user:7", id.ClientKey(r))
+
+	other := httptest.NewRequest(http.MethodGet, "/chats", nil)
+	other.Header.Set("X-API-Key", "secret")
+	assert.Equal(t, "user:7", id.ClientKey(other))
+
+	// ключ, которого нет у пользователей, не меняет клиента
+	unknown := httptest.NewRequest(http.MethodGet, "/chats", nil)
+	unknown.RemoteAddr = "10.0.0.1:5000"
+	unknown.Header.Set("X-API-Key", "guess")
+	assert.Equal(t, "ip:10.0.0.1", id.ClientKey(unknown))
+	assert.Len(t, id.cache, 1)
+}
+
+// countingUsers - считает обращения к базе
+type countingUsers struct {
+	fakeUsers
+	lookups int
+}
+
+func (u *countingUsers) UserByAPIKey(ctx context.Context, apiKey string) (*models.User, error) {
+	u.lookups++
+	return u.fakeUsers.UserByAPIKey(ctx, apiKey)
+}
+
+// TestClientKey_LookupBudget - случайные ключи с одного адреса не дают запросов к базе сверх бюджета
+func TestClientKey_LookupBudget(t *testing.T) {
+	users := &countingUsers{fakeUsers: fakeUsers{"secret": 7}}
+	id := NewIdentifier(users, false)
+
+	request := func(remote, apiKey string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/chats", nil)
+		r.RemoteAddr = remote
+		r.Header.Set("X-API-Key", apiKey)
+		return r
+	}
+
+	// известный ключ ищется в базе один раз, дальше берётся из кэша
+	assert.Equal(t, "user:7", id.ClientKey(request("10.0.0.1:1", "secret")))
+	for i := range 2 * keyLookupsPerMinute {
+		assert.Equal(t, "ip:10.0.0.1", id.ClientKey(request("10.0.0.1:1", fmt.Sprintf("guess-%d", i))))
+	}
+	assert.Equal(t, keyLookupsPerMinute, users.lookups)
+	assert.Equal(t, "user:7", id.ClientKey(request("10.0.0.1:1", "secret")))
+
+	// у другого адреса свой бюджет
+	assert.Equal(t, "ip:10.0.0.2", id.ClientKey(request("10.0.0.2:1", "guess")))
+	assert.Equal(t, keyLookupsPerMinute+1, users.lookups)
+}
+
+type countingMetrics map[string]int
+
+func (m countingMetrics) RateLimited(class string) { m[class]++ }
+
+func TestMiddleware(t *testing.T) {
+	metrics := countingMetrics{}
+	router := handlers.NewRouter()
+	router.Use(Middleware(NewLimiter(), Config{Read: 100, Messages: 2, Exempt: []string{"/readyz"}}, metrics))
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	router.Handle(http.MethodPost, "/chats/{id}/messages", ok)
+	router.Handle(http.MethodGet, "/chats/{id}/messages", ok)
+	router.Handle(http.MethodPost, "/chats", ok)
+	router.Handle(http.MethodGet, "/readyz", ok)
+
+	do := func(method, path, remote string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		r.RemoteAddr = remote
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, r)
+		return rec
+	}
+
+	for range 2 {
+		assert.Equal(t, http.StatusOK, do(http.MethodPost, "/chats/1/messages", "10.0.0.1:1").Code)
+	}
+	rec := do(http.MethodPost, "/chats/1/messages", "10.0.0.1:2")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "30", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", rec.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, 1, metrics[ClassMessages])
+
+	// у другого клиента и другого класса свой бюджет
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/chats/1/messages", "10.0.0.2:1").Code)
+	rec = do(http.MethodGet, "/chats/1/messages", "10.0.0.1:1")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "99", rec.Header().Get("RateLimit-Remaining"))
+
+	// класс без бюджета и исключённые пути не ограничиваются
+	rec = do(http.MethodPost, "/chats", "10.0.0.1:1")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+	assert.Empty(t, do(http.MethodGet, "/readyz", "10.0.0.1:1").Header().Get("RateLimit-Limit"))
+}
+
+// TestMiddleware_RandomKeys - случайные API ключи не дают нового бюджета
+func TestMiddleware_RandomKeys(t *testing.T) {
+	router := handlers.NewRouter()
+	router.Use(NewIdentifier(fakeUsers{"secret": 7}, false).Identify())
+	router.Use(Middleware(NewLimiter(), Config{Messages: 2}, nil))
+	router.Handle(http.MethodPost, "/chats/{id}/messages", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	do := func(apiKey string) int {
+		r := httptest.NewRequest(http.MethodPost, "/chats/1/messages", nil)
+		r.RemoteAddr = "10.0.0.1:5000"
+		r.Header.Set("X-API-Key", apiKey)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, r)
+		return rec.Code
+	}
+
+	for i := range 2 {
+		assert.Equal(t, http.StatusOK, do(fmt.Sprintf("random-%d", i)))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, do("random-2"))
+
+	// настоящий ключ - отдельный клиент со своим бюджетом
+	assert.Equal(t, http.StatusOK, do("secret"))
+}
+
+func TestIdentify(t *testing.T) {
+	var member string
+	handler := NewIdentifier(nil, false).Identify()(func(w http.ResponseWriter, r *http.Request) {
+		member = service.MemberFromContext(r.Context())
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/chats/1/messages", nil)
+	r.RemoteAddr = "10.0.0.1:5000"
+	handler(httptest.NewRecorder(), r)
+	assert.Equal(t, "ip:10.0.0.1", member)
+}
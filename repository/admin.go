@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"chat-api/models"
+	"chat-api/tracing"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+// exportBatchSize - сколько чатов читается за один запрос при выгрузке
+const exportBatchSize = 100
+
+// Admin - операции обслуживания для CLI: выгрузка и загрузка чатов, очистка, пользователи
+type Admin struct {
+	db     *gorm.DB
+	logger Logger
+}
+
+func NewAdmin(db *gorm.DB, logger Logger) *Admin {
+	return &Admin{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// ExportChats - вызывает fn для каждого чата со всеми его сообщениями в порядке id
+func (a *Admin) ExportChats(ctx context.Context, fn func(chat *models.Chat) error) error {
+	ctx, span := startSpan(ctx, "Admin.ExportChats", "chats, messages")
+	start := time.Now()
+
+	var (
+		exported int
+		lastID   uint
+		err      error
+	)
+
+	for {
+		var chats []models.Chat
+		err = a.db.WithContext(ctx).
+			Preload("Messages", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+			Where("id > ?", lastID).Order("id").Limit(exportBatchSize).
+			Find(&chats).Error
+		if err != nil {
+			err = fmt.Errorf("failed export chats: %w", err)
+			break
+		}
+
+		for i := range chats {
+			if err = fn(&chats[i]); err != nil {
+				break
+			}
+			exported++
+		}
+		if err != nil || len(chats) < exportBatchSize {
+			break
+		}
+		lastID = chats[len(chats)-1].ID
+	}
+
+	durationMs := float64(time.Since(start).Nanoseconds()) / 1e6
+
+	a.logger.Log(ctx, "Export", "chats, messages", fmt.Sprintf("chats: %d", exported), durationMs, err)
+	tracing.End(span, err)
+
+	return err
+}
+
+// ImportChat - создаёт чат вместе с сообщениями в одной транзакции.
+// Идентификаторы назначаются заново, временные метки сохраняются
+func (a *Admin) ImportChat(ctx context.Context, chat *models.Chat) error {
+	ctx, span := startSpan(ctx, "Admin.ImportChat", "chats, messages", attribute.Int("messages", len(chat.Messages)))
+	start := time.Now()
+
+	chat.ID = 0
+	for i := range chat.Messages {
+		chat.Messages[i].ID = 0
+		chat.Messages[i].ChatID = 0
+	}
+
+	err := a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.Create(chat).Error
+	})
+
+	durationMs := float64(time.Since(start).Nanoseconds()) / 1e6
+
+	a.logger.Log(ctx, "Transaction: import", "chats, messages", fmt.Sprintf("title: %q, messages: %d", chat.Title, len(chat.Messages)), durationMs, err)
+	tracing.End(span, err)
+
+	if err != nil {
+		return fmt.Errorf("failed import chat: %w", err)
+	}
+	return nil
+}
+
+// PurgeChats - удаляет чаты без активности с момента before вместе с сообщениями (каскадом).
+// При dryRun только считает, сколько чатов было бы удалено
+func (a *Admin) PurgeChats(ctx context.Context, before time.Time, dryRun bool) (int64, error) {
+	ctx, span := startSpan(ctx, "Admin.PurgeChats", "chats", attribute.Bool("dry_run", dryRun))
+	start := time.Now()
+
+	stale := a.db.WithContext(ctx).Model(&models.Chat{}).
+		Where("updated_at < ?", before).
+		Where("NOT EXISTS (SELECT 1 FROM messages WHERE messages.chat_id = chats.id AND messages.created_at >= ?)", before)
+
+	var (
+		affected int64
+		err      error
+	)
+	if dryRun {
+		err = stale.Count(&affected).Error
+	} else {
+		result := stale.Delete(&models.Chat{})
+		affected, err = result.RowsAffected, result.Error
+	}
+
+	durationMs := float64(time.Since(start).Nanoseconds()) / 1e6
+
+	a.logger.Log(ctx, "Purge", "chats", fmt.Sprintf("before: %s, dry_run: %t, chats: %d", before.Format(time.RFC3339), dryRun, affected), durationMs, err)
+	tracing.End(span, err)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed purge chats: %w", err)
+	}
+	return affected, nil
+}
+
+// CreateUser - создаёт пользователя и возвращает его API ключ.
+// В базе хранится только хэш, сам ключ показывается один раз
+func (a *Admin) CreateUser(ctx context.Context, name string) (*models.User, string, error) {
+	ctx, span := startSpan(ctx, "Admin.CreateUser", "users")
+	start := time.Now()
+
+	apiKey, err := generateAPIKey()
+	if err != nil {
+		tracing.End(span, err)
+		return nil, "", err
+	}
+
+	user := &models.User{
+		Name:       name,
+		APIKeyHash: HashAPIKey(apiKey),
+	}
+	err = a.db.WithContext(ctx).Create(user).Error
+
+	durationMs := float64(time.Since(start).Nanoseconds()) / 1e6
+
+	a.logger.Log(ctx, "Create", "users", fmt.Sprintf("name: %q", name), durationMs, err)
+	tracing.End(span, err)
+
+	if err != nil {
+		return nil, "", fmt.Errorf("failed create user: %w", err)
+	}
+	return user, apiKey, nil
+}
+
+// UserByAPIKey - пользователь, которому принадлежит API ключ; ErrNotFound, если такого нет
+func (a *Admin) UserByAPIKey(ctx context.Context, apiKey string) (*models.User, error) {
+	ctx, span := startSpan(ctx, "Admin.UserByAPIKey", "users")
+	start := time.Now()
+
+	var user models.User
+	err := a.db.WithContext(ctx).Where("api_key_hash = ?", HashAPIKey(apiKey)).First(&user).Error
+
+	durationMs := float64(time.Since(start).Nanoseconds()) / 1e6
+
+	a.logger.Log(ctx, "Get", "users", "by api key", durationMs, err)
+	tracing.End(span, err)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed get user: %w", err)
+	}
+	return &user, nil
+}
+
+// HashAPIKey - хэш, под которым API ключ хранится в users.api_key_hash
+func HashAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateAPIKey() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed generate api key: %w", err)
+	}
+	return "chk_" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,37 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"chat-api/internal/testutil"
+	"chat-api/repository"
+	"chat-api/repository/repotest"
+)
+
+// TestMemoryRepository_Contract - хранилище в памяти ведёт себя как PostgreSQL (см. tests/)
+func TestMemoryRepository_Contract(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repository.ChatRepository {
+		return repository.NewMemoryRepository(nil)
+	})
+}
+
+// TestSQLiteRepository_Contract - Repository поверх SQLite ведёт себя так же, как поверх PostgreSQL
+func TestSQLiteRepository_Contract(t *testing.T) {
+	db := testutil.SQLite(t)
+
+	repotest.Run(t, func(t *testing.T) repository.ChatRepository {
+		return repository.NewRepository(db, testutil.DiscardLogger{})
+	})
+
+	// запись событий в outbox не меняет поведение репозитория
+	t.Run("WithOutbox", func(t *testing.T) {
+		repotest.Run(t, func(t *testing.T) repository.ChatRepository {
+			return repository.NewRepository(db, testutil.DiscardLogger{}, repository.WithOutbox())
+		})
+	})
+}
+
+type discardLogger struct{}
+
+func (discardLogger) Log(context.Context, string, string, string, float64, error) {}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound - чат не найден. Совпадает с gorm.ErrRecordNotFound, поэтому
+// errors.Is(err, ErrNotFound) одинаково работает для всех реализаций ChatRepository
+var ErrNotFound = gorm.ErrRecordNotFound
+
+// sqliteConstraintForeignKey - расширенный код SQLITE_CONSTRAINT_FOREIGNKEY
+const sqliteConstraintForeignKey = 787
+
+// isForeignKeyViolation - вставка ссылается на несуществующий чат
+func isForeignKeyViolation(err error) bool {
+	if errors.Is(err, gorm.ErrForeignKeyViolated) {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "23503"
+	}
+
+	// ошибки драйвера SQLite проверяются по коду, без зависимости от самого драйвера
+	var sqliteErr interface{ Code() int }
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqliteConstraintForeignKey
+}
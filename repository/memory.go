@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"chat-api/models"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemoryRepository - ChatRepository в памяти процесса для разработки и тестов.
+// Повторяет поведение Repository: отдельные последовательности id для чатов и сообщений,
+// порядок выдачи, каскадное удаление сообщений и ошибки ErrNotFound
+type MemoryRepository struct {
+	logger Logger
+
+	mu            sync.RWMutex
+	lastChatID    uint
+	lastMessageID uint
+	chats         map[uint]*memoryChat
+}
+
+type memoryChat struct {
+	chat     models.Chat
+	messages []models.Message
+}
+
+// NewMemoryRepository - logger может быть nil
+func NewMemoryRepository(logger Logger) *MemoryRepository {
+	return &MemoryRepository{
+		logger: logger,
+		chats:  make(map[uint]*memoryChat),
+	}
+}
+
+func (m *MemoryRepository) Create(ctx context.Context, chat *models.Chat) (*models.Chat, error) {
+	start := time.Now()
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed create chat: %w", err)
+	}
+
+	m.mu.Lock()
+
+	now := time.Now()
+	m.lastChatID++
+	chat.ID = m.lastChatID
+	setTimestamps(&chat.CreatedAt, &chat.UpdatedAt, now)
+
+	stored := &memoryChat{chat: *chat}
+	stored.chat.Messages = nil
+
+	// как и GORM, создаёт вложенные сообщения вместе с чатом
+	for i := range chat.Messages {
+		m.lastMessageID++
+		chat.Messages[i].ID = m.lastMessageID
+		chat.Messages[i].ChatID = chat.ID
+		setTimestamps(&chat.Messages[i].CreatedAt, &chat.Messages[i].UpdatedAt, now)
+		stored.messages = append(stored.messages, chat.Messages[i])
+	}
+	m.chats[chat.ID] = stored
+
+	m.mu.Unlock()
+
+	m.log(ctx, "Create", "chats", fmt.Sprintf("chat: %+v", chat), start, nil)
+	return chat, nil
+}
+
+func (m *MemoryRepository) Get(ctx context.Context, id uint, limit int) (*models.Chat, error) {
+	start := time.Now()
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed get chat: %w", err)
+	}
+
+	m.mu.RLock()
+
+	var (
+		chat models.Chat
+		err  error
+	)
+	stored, ok := m.chats[id]
+	if ok {
+		chat = stored.chat
+		chat.Messages = slices.Clone(stored.messages)
+		if chat.Messages == nil {
+			chat.Messages = []models.Message{}
+		}
+	} else {
+		err = fmt.Errorf("failed get chat: %w", ErrNotFound)
+	}
+
+	m.mu.RUnlock()
+
+	if ok {
+		// ORDER BY updated_at DESC, при равенстве - более новые id первыми
+		slices.SortStableFunc(chat.Messages, func(a, b models.Message) int {
+			if c := b.UpdatedAt.Compare(a.UpdatedAt); c != 0 {
+				return c
+			}
+			return compareDesc(a.ID, b.ID)
+		})
+		chat.Messages = applyLimit(chat.Messages, limit)
+	}
+
+	m.log(ctx, "Transaction: get, get", "chats, messages", fmt.Sprintf("chat_id: %d, limit: %d", id, limit), start, err)
+
+	if err != nil {
+		return nil, err
+	}
+	return &chat, nil
+}
+
+// Delete - удаляет чат вместе с сообщениями; удаление несуществующего чата не ошибка, как и в Repository
+func (m *MemoryRepository) Delete(ctx context.Context, id uint) error {
+	start := time.Now()
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("failed delete chat: %w", err)
+	}
+
+	m.mu.Lock()
+	delete(m.chats, id)
+	m.mu.Unlock()
+
+	m.log(ctx, "Delete", "chats", fmt.Sprintf("chat_id: %d", id), start, nil)
+	return nil
+}
+
+func (m *MemoryRepository) CreateMessage(ctx context.Context, id uint, message *models.Message) (*models.Message, error) {
+	start := time.Now()
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed create message: %w", err)
+	}
+
+	m.mu.Lock()
+
+	var err error
+	stored, ok := m.chats[message.ChatID]
+	if ok {
+		m.lastMessageID++
+		message.ID = m.lastMessageID
+		setTimestamps(&message.CreatedAt, &message.UpdatedAt, time.Now())
+		stored.messages = append(stored.messages, *message)
+	} else {
+		err = fmt.Errorf("failed create message: chat %d: %w", message.ChatID, ErrNotFound)
+	}
+
+	m.mu.Unlock()
+
+	m.log(ctx, "Create", "messages", fmt.Sprintf("chat_id: %d, message: %+v", id, message), start, err)
+
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+func (m *MemoryRepository) ListMessages(ctx context.Context, chatID uint, before uint, limit int) ([]models.Message, error) {
+	start := time.Now()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+
+	var (
+		messages []models.Message
+		err      error
+	)
+	stored, ok := m.chats[chatID]
+	if ok {
+		messages = make([]models.Message, 0, len(stored.messages))
+		for _, message := range stored.messages {
+			if before == 0 || message.ID < before {
+				messages = append(messages, message)
+			}
+		}
+	} else {
+		err = fmt.Errorf("failed get chat: %w", ErrNotFound)
+	}
+
+	m.mu.RUnlock()
+
+	if ok {
+		slices.SortFunc(messages, func(a, b models.Message) int { return compareDesc(a.ID, b.ID) })
+		messages = applyLimit(messages, limit)
+	}
+
+	m.log(ctx, "Transaction: get, list", "chats, messages", fmt.Sprintf("chat_id: %d, before: %d, limit: %d", chatID, before, limit), start, err)
+
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (m *MemoryRepository) SetAssistant(ctx context.Context, id uint, enabled bool) error {
+	start := time.Now()
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("failed set chat assistant: %w", err)
+	}
+
+	var err error
+	m.mu.Lock()
+	if stored, ok := m.chats[id]; ok {
+		stored.chat.AssistantEnabled = enabled
+		stored.chat.UpdatedAt = time.Now()
+	} else {
+		err = fmt.Errorf("failed set chat assistant: %w", ErrNotFound)
+	}
+	m.mu.Unlock()
+
+	m.log(ctx, "Update", "chats", fmt.Sprintf("chat_id: %d, assistant_enabled: %t", id, enabled), start, err)
+	return err
+}
+
+func (m *MemoryRepository) SetSlowMode(ctx context.Context, id uint, seconds int) error {
+	start := time.Now()
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("failed set chat slow mode: %w", err)
+	}
+
+	var err error
+	m.mu.Lock()
+	if stored, ok := m.chats[id]; ok {
+		stored.chat.SlowModeSeconds = seconds
+		stored.chat.UpdatedAt = time.Now()
+	} else {
+		err = fmt.Errorf("failed set chat slow mode: %w", ErrNotFound)
+	}
+	m.mu.Unlock()
+
+	m.log(ctx, "Update", "chats", fmt.Sprintf("chat_id: %d, slow_mode_seconds: %d", id, seconds), start, err)
+	return err
+}
+
+func (m *MemoryRepository) log(ctx context.Context, operation, table, details string, start time.Time, err error) {
+	if m.logger == nil {
+		return
+	}
+	durationMs := float64(time.Since(start).Nanoseconds()) / 1e6
+	m.logger.Log(ctx, operation, table, details, durationMs, err)
+}
+
+// setTimestamps - как GORM, заполняет только незаданные метки времени
+func setTimestamps(createdAt, updatedAt *time.Time, now time.Time) {
+	if createdAt.IsZero() {
+		*createdAt = now
+	}
+	if updatedAt.IsZero() {
+		*updatedAt = now
+	}
+}
+
+// applyLimit - LIMIT как в GORM: отрицательный лимит не ограничивает выборку
+func applyLimit(messages []models.Message, limit int) []models.Message {
+	if limit >= 0 && len(messages) > limit {
+		return messages[:limit]
+	}
+	return messages
+}
+
+func compareDesc(a, b uint) int {
+	switch {
+	case a > b:
+		return -1
+	case a < b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"chat-api/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ ChatRepository = (*MemoryRepository)(nil)
+
+// TestMemoryRepository_Isolation - изменение возвращённых значений не меняет хранилище
+func TestMemoryRepository_Isolation(t *testing.T) {
+	repo := NewMemoryRepository(nil)
+	ctx := context.Background()
+
+	chat, err := repo.Create(ctx, &models.Chat{Title: "original"})
+	require.NoError(t, err)
+	_, err = repo.CreateMessage(ctx, chat.ID, &models.Message{ChatID: chat.ID, Text: "original"})
+	require.NoError(t, err)
+
+	chat.Title = "changed"
+	got, err := repo.Get(ctx, chat.ID, 10)
+	require.NoError(t, err)
+	got.Messages[0].Text = "changed"
+
+	again, err := repo.Get(ctx, chat.ID, 10)
+	require.NoError(t, err)
+	assert.Equal(t, "original", again.Title)
+	assert.Equal(t, "original", again.Messages[0].Text)
+}
+
+// TestMemoryRepository_Cancelled - отменённый контекст не выполняет операцию
+func TestMemoryRepository_Cancelled(t *testing.T) {
+	repo := NewMemoryRepository(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := repo.Create(ctx, &models.Chat{Title: "never"})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = repo.Get(context.Background(), 1, 10)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
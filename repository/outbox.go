@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"chat-api/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WithOutbox - каждое изменение чата записывает событие в таблицу outbox в той же транзакции,
+// поэтому событие не теряется, даже если процесс упадёт сразу после записи. Доставляет их outbox.Relay
+func WithOutbox() Option {
+	return func(r *Repository) {
+		r.outbox = true
+	}
+}
+
+// write - выполняет изменение; с outbox - в транзакции вместе с событием, которое вернул fn.
+// fn может вернуть nil событие, если записывать нечего
+func (r *Repository) write(ctx context.Context, fn func(tx *gorm.DB) (*models.OutboxEvent, error)) error {
+	if !r.outbox {
+		_, err := fn(r.db.WithContext(ctx))
+		return err
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		event, err := fn(tx)
+		if err != nil || event == nil {
+			return err
+		}
+		return tx.Create(event).Error
+	})
+}
+
+// lockChat - с outbox блокирует строку чата до конца транзакции, чтобы события одного чата
+// получали id в порядке фиксации и relay доставлял их по порядку
+func (r *Repository) lockChat(tx *gorm.DB, chatID uint) error {
+	if !r.outbox {
+		return nil
+	}
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Chat{}, chatID).Error
+}
+
+func newEvent(chatID uint, eventType string, data any) (*models.OutboxEvent, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed encode %s event: %w", eventType, err)
+	}
+	return &models.OutboxEvent{ChatID: chatID, Type: eventType, Payload: string(payload)}, nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// pruneThreshold - при каком размере таблицы недавних записей из неё удаляются устаревшие
+const pruneThreshold = 1024
+
+type Option func(*Repository)
+
+// WithReader - источник базы для чтений истории чатов (например, database.ClientDB.Reader)
+func WithReader(reader func(ctx context.Context) *gorm.DB) Option {
+	return func(r *Repository) {
+		r.reader = reader
+	}
+}
+
+// WithReadYourWrites - после записи в чат его чтения идут в основную базу в течение window,
+// чтобы автор сообщения увидел его сразу, не дожидаясь репликации.
+// Окно должно покрывать типичное отставание реплик
+func WithReadYourWrites(window time.Duration) Option {
+	return func(r *Repository) {
+		if window > 0 {
+			r.writes = &recentWrites{window: window, chats: make(map[uint]time.Time)}
+		}
+	}
+}
+
+// readDB - база для чтения чата: основная, если в чат недавно писали, иначе из reader
+func (r *Repository) readDB(ctx context.Context, chatID uint) *gorm.DB {
+	if r.reader == nil || r.writes.recent(chatID) {
+		return r.db
+	}
+	return r.reader(ctx)
+}
+
+// recentWrites - время последней записи в каждый чат в пределах окна.
+// Хранится в памяти процесса: записи через другие экземпляры здесь не видны
+type recentWrites struct {
+	window time.Duration
+	mu     sync.Mutex
+	chats  map[uint]time.Time
+}
+
+func (w *recentWrites) mark(chatID uint) {
+	if w == nil {
+		return
+	}
+
+	now := time.Now()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.chats[chatID] = now
+
+	if len(w.chats) > pruneThreshold {
+		for id, at := range w.chats {
+			if now.Sub(at) > w.window {
+				delete(w.chats, id)
+			}
+		}
+	}
+}
+
+func (w *recentWrites) recent(chatID uint) bool {
+	if w == nil {
+		return false
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	at, ok := w.chats[chatID]
+	return ok && time.Since(at) <= w.window
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func lazyDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.Open("host=127.0.0.1 user=postgres dbname=chat_api"), &gorm.Config{DisableAutomaticPing: true})
+	require.NoError(t, err)
+	return db
+}
+
+// TestReadDB_ReadYourWrites - после записи чат читается из основной базы, пока не истечёт окно
+func TestReadDB_ReadYourWrites(t *testing.T) {
+	primary, replica := lazyDB(t), lazyDB(t)
+	r := NewRepository(primary, nil,
+		WithReader(func(ctx context.Context) *gorm.DB { return replica }),
+		WithReadYourWrites(50*time.Millisecond),
+	).(*Repository)
+
+	assert.Same(t, replica, r.readDB(context.Background(), 1))
+
+	r.writes.mark(1)
+	assert.Same(t, primary, r.readDB(context.Background(), 1))
+	assert.Same(t, replica, r.readDB(context.Background(), 2))
+
+	time.Sleep(60 * time.Millisecond)
+	assert.Same(t, replica, r.readDB(context.Background(), 1))
+}
+
+// TestReadDB_Defaults - без реплик и без окна чтения идут туда, куда скажет reader
+func TestReadDB_Defaults(t *testing.T) {
+	primary, replica := lazyDB(t), lazyDB(t)
+
+	r := NewRepository(primary, nil).(*Repository)
+	r.writes.mark(1)
+	assert.Same(t, primary, r.readDB(context.Background(), 1))
+
+	r = NewRepository(primary, nil,
+		WithReader(func(ctx context.Context) *gorm.DB { return replica }),
+		WithReadYourWrites(0),
+	).(*Repository)
+	r.writes.mark(1)
+	assert.Same(t, replica, r.readDB(context.Background(), 1))
+}
+
+// TestRecentWrites_Prune - устаревшие записи удаляются, таблица не растёт бесконечно
+func TestRecentWrites_Prune(t *testing.T) {
+	w := &recentWrites{window: time.Millisecond, chats: make(map[uint]time.Time)}
+	for id := range uint(pruneThreshold) {
+		w.mark(id)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	w.mark(pruneThreshold)
+	w.mark(pruneThreshold + 1)
+
+	assert.LessOrEqual(t, len(w.chats), 2)
+	assert.True(t, w.recent(pruneThreshold+1))
+}
@@ -2,11 +2,15 @@ package repository
 
 import (
 	"chat-api/models"
+	"chat-api/tracing"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
 )
 
@@ -15,47 +19,68 @@ type ChatRepository interface {
 	Get(ctx context.Context, id uint, limit int) (*models.Chat, error)
 	Delete(ctx context.Context, id uint) error
 	CreateMessage(ctx context.Context, id uint, message *models.Message) (*models.Message, error)
+	ListMessages(ctx context.Context, chatID uint, before uint, limit int) ([]models.Message, error)
+	SetAssistant(ctx context.Context, id uint, enabled bool) error
+	SetSlowMode(ctx context.Context, id uint, seconds int) error
 }
 
 type Logger interface {
-	Log(operation, table string, details string, durationMs float64, err error)
+	Log(ctx context.Context, operation, table string, details string, durationMs float64, err error)
 }
 
 type Repository struct {
 	db     *gorm.DB
 	logger Logger
+
+	reader func(ctx context.Context) *gorm.DB
+	writes *recentWrites
+	outbox bool
 }
 
-func NewRepository(db *gorm.DB, logger Logger) ChatRepository {
-	return &Repository{
+func NewRepository(db *gorm.DB, logger Logger, opts ...Option) ChatRepository {
+	r := &Repository{
 		db:     db,
 		logger: logger,
 	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
 }
 
 func (r *Repository) Create(ctx context.Context, chat *models.Chat) (*models.Chat, error) {
+	ctx, span := startSpan(ctx, "Repository.Create", "chats")
 	start := time.Now()
 
-	result := r.db.WithContext(ctx).Create(chat)
-	err := result.Error
+	err := r.write(ctx, func(tx *gorm.DB) (*models.OutboxEvent, error) {
+		if err := tx.Create(chat).Error; err != nil {
+			return nil, err
+		}
+		return newEvent(chat.ID, models.EventChatCreated, chat)
+	})
 
 	duration := time.Since(start)
 	durationMs := float64(duration.Nanoseconds()) / 1e6
 
-	r.logger.Log("Create", "chats", fmt.Sprintf("chat: %+v", chat), durationMs, result.Error)
+	r.logger.Log(ctx, "Create", "chats", fmt.Sprintf("chat: %+v", chat), durationMs, err)
+	tracing.End(span, err)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed create chat: %w", err)
 	}
+	r.writes.mark(chat.ID)
 	return chat, nil
 }
 
 func (r *Repository) Get(ctx context.Context, id uint, limit int) (*models.Chat, error) {
+	ctx, span := startSpan(ctx, "Repository.Get", "chats, messages", attribute.Int("chat.id", int(id)), attribute.Int("limit", limit))
 	start := time.Now()
 
 	var chat models.Chat
 
-	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	err := r.readDB(ctx, id).WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		resultC := tx.First(&chat, id)
 
 		if resultC.Error != nil {
@@ -71,22 +96,30 @@ func (r *Repository) Get(ctx context.Context, id uint, limit int) (*models.Chat,
 	duration := time.Since(start)
 	durationMs := float64(duration.Nanoseconds()) / 1e6
 
-	r.logger.Log("Transaction: get, get", "chats, messages", fmt.Sprintf("chat_id: %d, limit: %d", id, limit), durationMs, err)
+	r.logger.Log(ctx, "Transaction: get, get", "chats, messages", fmt.Sprintf("chat_id: %d, limit: %d", id, limit), durationMs, err)
+	tracing.End(span, err)
 
 	return &chat, err
 }
 
 func (r *Repository) Delete(ctx context.Context, id uint) error {
+	ctx, span := startSpan(ctx, "Repository.Delete", "chats", attribute.Int("chat.id", int(id)))
 	start := time.Now()
 
-	var chat models.Chat
-	result := r.db.WithContext(ctx).Delete(&chat, id)
-	err := result.Error
+	err := r.write(ctx, func(tx *gorm.DB) (*models.OutboxEvent, error) {
+		result := tx.Delete(&models.Chat{}, id)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return nil, result.Error
+		}
+		return newEvent(id, models.EventChatDeleted, map[string]uint{"id": id})
+	})
+	r.writes.mark(id)
 
 	duration := time.Since(start)
 	durationMs := float64(duration.Nanoseconds()) / 1e6
 
-	r.logger.Log("Delete", "chats", fmt.Sprintf("chat_id: %d", id), durationMs, result.Error)
+	r.logger.Log(ctx, "Delete", "chats", fmt.Sprintf("chat_id: %d", id), durationMs, err)
+	tracing.End(span, err)
 
 	if err != nil {
 		return fmt.Errorf("failed delete chat: %w", err)
@@ -95,18 +128,120 @@ func (r *Repository) Delete(ctx context.Context, id uint) error {
 }
 
 func (r *Repository) CreateMessage(ctx context.Context, id uint, message *models.Message) (*models.Message, error) {
+	ctx, span := startSpan(ctx, "Repository.CreateMessage", "messages", attribute.Int("chat.id", int(id)))
 	start := time.Now()
 
-	result := r.db.WithContext(ctx).Create(message)
-	err := result.Error
+	err := r.write(ctx, func(tx *gorm.DB) (*models.OutboxEvent, error) {
+		if err := r.lockChat(tx, message.ChatID); err != nil {
+			return nil, err
+		}
+		if err := tx.Create(message).Error; err != nil {
+			return nil, err
+		}
+		return newEvent(message.ChatID, models.EventMessageCreated, message)
+	})
 
 	duration := time.Since(start)
 	durationMs := float64(duration.Nanoseconds()) / 1e6
 
-	r.logger.Log("Create", "messages", fmt.Sprintf("chat_id: %d, message: %+v", id, message), durationMs, result.Error)
+	r.logger.Log(ctx, "Create", "messages", fmt.Sprintf("chat_id: %d, message: %+v", id, message), durationMs, err)
+	tracing.End(span, err)
 
+	if isForeignKeyViolation(err) || errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed create message: chat %d: %w", message.ChatID, ErrNotFound)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed create message: %w", err)
 	}
+	r.writes.mark(id)
 	return message, nil
 }
+
+func (r *Repository) ListMessages(ctx context.Context, chatID uint, before uint, limit int) ([]models.Message, error) {
+	ctx, span := startSpan(ctx, "Repository.ListMessages", "chats, messages", attribute.Int("chat.id", int(chatID)), attribute.Int("limit", limit))
+	start := time.Now()
+
+	var messages []models.Message
+
+	err := r.readDB(ctx, chatID).WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		resultC := tx.Select("id").First(&models.Chat{}, chatID)
+
+		if resultC.Error != nil {
+			return fmt.Errorf("failed get chat: %w", resultC.Error)
+		}
+
+		query := tx.Where("chat_id = ?", chatID)
+		if before > 0 {
+			query = query.Where("id < ?", before)
+		}
+
+		return query.Order("id DESC").Limit(limit).Find(&messages).Error
+	}, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  true,
+	})
+
+	duration := time.Since(start)
+	durationMs := float64(duration.Nanoseconds()) / 1e6
+
+	r.logger.Log(ctx, "Transaction: get, list", "chats, messages", fmt.Sprintf("chat_id: %d, before: %d, limit: %d", chatID, before, limit), durationMs, err)
+	tracing.End(span, err)
+
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// SetAssistant - включает или выключает ассистента чата
+func (r *Repository) SetAssistant(ctx context.Context, id uint, enabled bool) error {
+	ctx, span := startSpan(ctx, "Repository.SetAssistant", "chats", attribute.Int("chat.id", int(id)))
+	start := time.Now()
+
+	result := r.db.WithContext(ctx).Model(&models.Chat{}).Where("id = ?", id).Update("assistant_enabled", enabled)
+	err := result.Error
+	if err == nil && result.RowsAffected == 0 {
+		err = ErrNotFound
+	}
+	r.writes.mark(id)
+
+	duration := time.Since(start)
+	durationMs := float64(duration.Nanoseconds()) / 1e6
+
+	r.logger.Log(ctx, "Update", "chats", fmt.Sprintf("chat_id: %d, assistant_enabled: %t", id, enabled), durationMs, err)
+	tracing.End(span, err)
+
+	if err != nil {
+		return fmt.Errorf("failed set chat assistant: %w", err)
+	}
+	return nil
+}
+
+// SetSlowMode - интервал медленного режима чата в секундах, 0 - выключен
+func (r *Repository) SetSlowMode(ctx context.Context, id uint, seconds int) error {
+	ctx, span := startSpan(ctx, "Repository.SetSlowMode", "chats", attribute.Int("chat.id", int(id)))
+	start := time.Now()
+
+	result := r.db.WithContext(ctx).Model(&models.Chat{}).Where("id = ?", id).Update("slow_mode_seconds", seconds)
+	err := result.Error
+	if err == nil && result.RowsAffected == 0 {
+		err = ErrNotFound
+	}
+	r.writes.mark(id)
+
+	duration := time.Since(start)
+	durationMs := float64(duration.Nanoseconds()) / 1e6
+
+	r.logger.Log(ctx, "Update", "chats", fmt.Sprintf("chat_id: %d, slow_mode_seconds: %d", id, seconds), durationMs, err)
+	tracing.End(span, err)
+
+	if err != nil {
+		return fmt.Errorf("failed set chat slow mode: %w", err)
+	}
+	return nil
+}
+
+func startSpan(ctx context.Context, name, table string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("db.sql.table", table))
+	return tracing.Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
@@ -0,0 +1,329 @@
+// Package repotest - общий набор проверок поведения для реализаций repository.ChatRepository.
+// Любое хранилище (PostgreSQL, память, будущие) должно проходить Run, чтобы поведение бэкендов не расходилось.
+package repotest
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"chat-api/models"
+	"chat-api/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory - возвращает репозиторий для очередной проверки.
+// Хранилище может быть общим для всех проверок: каждая работает только со своими чатами
+type Factory func(t *testing.T) repository.ChatRepository
+
+// Run - запускает все проверки контракта подтестами t
+func Run(t *testing.T, factory Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, repo repository.ChatRepository)
+	}{
+		{"CreateGet", testCreateGet},
+		{"CreateWithMessages", testCreateWithMessages},
+		{"Delete", testDelete},
+		{"DeleteCascade", testDeleteCascade},
+		{"NotFound", testNotFound},
+		{"GetLimit", testGetLimit},
+		{"GetOrder", testGetOrder},
+		{"ListMessages", testListMessages},
+		{"ConcurrentInserts", testConcurrentInserts},
+		{"SetAssistant", testSetAssistant},
+		{"SetSlowMode", testSetSlowMode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, factory(t))
+		})
+	}
+}
+
+func testCreateGet(t *testing.T, repo repository.ChatRepository) {
+	ctx := context.Background()
+
+	chat, err := repo.Create(ctx, &models.Chat{Title: "contract create"})
+	require.NoError(t, err)
+	assert.NotZero(t, chat.ID)
+	assert.False(t, chat.CreatedAt.IsZero())
+	assert.False(t, chat.UpdatedAt.IsZero())
+
+	other, err := repo.Create(ctx, &models.Chat{Title: "contract other"})
+	require.NoError(t, err)
+	assert.NotEqual(t, chat.ID, other.ID)
+
+	got, err := repo.Get(ctx, chat.ID, 10)
+	require.NoError(t, err)
+	assert.Equal(t, chat.ID, got.ID)
+	assert.Equal(t, "contract create", got.Title)
+	assert.WithinDuration(t, chat.CreatedAt, got.CreatedAt, time.Millisecond)
+	assert.Empty(t, got.Messages)
+
+	message, err := repo.CreateMessage(ctx, chat.ID, &models.Message{ChatID: chat.ID, Text: "hello"})
+	require.NoError(t, err)
+	assert.NotZero(t, message.ID)
+	assert.Equal(t, chat.ID, message.ChatID)
+	assert.False(t, message.CreatedAt.IsZero())
+
+	got, err = repo.Get(ctx, chat.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, got.Messages, 1)
+	assert.Equal(t, message.ID, got.Messages[0].ID)
+	assert.Equal(t, "hello", got.Messages[0].Text)
+
+	// сообщения одного чата не видны в другом
+	got, err = repo.Get(ctx, other.ID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, got.Messages)
+}
+
+func testCreateWithMessages(t *testing.T, repo repository.ChatRepository) {
+	ctx := context.Background()
+
+	chat, err := repo.Create(ctx, &models.Chat{
+		Title:    "contract nested",
+		Messages: []models.Message{{Text: "first"}, {Text: "second"}},
+	})
+	require.NoError(t, err)
+
+	for _, message := range chat.Messages {
+		assert.NotZero(t, message.ID)
+		assert.Equal(t, chat.ID, message.ChatID)
+	}
+
+	messages, err := repo.ListMessages(ctx, chat.ID, 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, messages, 2)
+}
+
+func testDelete(t *testing.T, repo repository.ChatRepository) {
+	ctx := context.Background()
+
+	chat, err := repo.Create(ctx, &models.Chat{Title: "contract delete"})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Delete(ctx, chat.ID))
+
+	_, err = repo.Get(ctx, chat.ID, 10)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	// повторное удаление не ошибка
+	assert.NoError(t, repo.Delete(ctx, chat.ID))
+}
+
+func testDeleteCascade(t *testing.T, repo repository.ChatRepository) {
+	ctx := context.Background()
+
+	chat, err := repo.Create(ctx, &models.Chat{Title: "contract cascade"})
+	require.NoError(t, err)
+	kept, err := repo.Create(ctx, &models.Chat{Title: "contract kept"})
+	require.NoError(t, err)
+
+	for _, id := range []uint{chat.ID, chat.ID, kept.ID} {
+		_, err := repo.CreateMessage(ctx, id, &models.Message{ChatID: id, Text: "message"})
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, repo.Delete(ctx, chat.ID))
+
+	_, err = repo.ListMessages(ctx, chat.ID, 0, 10)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	// новый чат не получает сообщений удалённого
+	fresh, err := repo.Create(ctx, &models.Chat{Title: "contract fresh"})
+	require.NoError(t, err)
+	messages, err := repo.ListMessages(ctx, fresh.ID, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+
+	messages, err = repo.ListMessages(ctx, kept.ID, 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, messages, 1)
+}
+
+func testNotFound(t *testing.T, repo repository.ChatRepository) {
+	ctx := context.Background()
+	const missing = math.MaxInt32
+
+	_, err := repo.Get(ctx, missing, 10)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	_, err = repo.ListMessages(ctx, missing, 0, 10)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	_, err = repo.CreateMessage(ctx, missing, &models.Message{ChatID: missing, Text: "nobody"})
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	assert.NoError(t, repo.Delete(ctx, missing))
+}
+
+func testGetLimit(t *testing.T, repo repository.ChatRepository) {
+	ctx := context.Background()
+	chat := createChatWithMessages(t, repo, "contract limit", 5)
+
+	tests := []struct {
+		limit int
+		want  int
+	}{
+		{limit: 3, want: 3},
+		{limit: 5, want: 5},
+		{limit: 10, want: 5},
+		{limit: 0, want: 0},
+		{limit: -1, want: 5},
+	}
+
+	for _, tt := range tests {
+		got, err := repo.Get(ctx, chat.ID, tt.limit)
+		require.NoError(t, err)
+		assert.Len(t, got.Messages, tt.want, "Get limit %d", tt.limit)
+
+		messages, err := repo.ListMessages(ctx, chat.ID, 0, tt.limit)
+		require.NoError(t, err)
+		assert.Len(t, messages, tt.want, "ListMessages limit %d", tt.limit)
+	}
+}
+
+func testGetOrder(t *testing.T, repo repository.ChatRepository) {
+	ctx := context.Background()
+	chat := createChatWithMessages(t, repo, "contract order", 4)
+
+	got, err := repo.Get(ctx, chat.ID, 2)
+	require.NoError(t, err)
+	require.Len(t, got.Messages, 2)
+
+	// Get отдаёт последние изменённые сообщения первыми
+	assert.Equal(t, chat.Messages[3].ID, got.Messages[0].ID)
+	assert.Equal(t, chat.Messages[2].ID, got.Messages[1].ID)
+	assert.False(t, got.Messages[0].UpdatedAt.Before(got.Messages[1].UpdatedAt))
+}
+
+func testListMessages(t *testing.T, repo repository.ChatRepository) {
+	ctx := context.Background()
+	chat := createChatWithMessages(t, repo, "contract list", 5)
+	ids := make([]uint, len(chat.Messages))
+	for i, message := range chat.Messages {
+		ids[i] = message.ID
+	}
+
+	messages, err := repo.ListMessages(ctx, chat.ID, 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, []uint{ids[4], ids[3], ids[2], ids[1], ids[0]}, messageIDs(messages))
+
+	// курсор before исключает само сообщение и всё новее
+	messages, err = repo.ListMessages(ctx, chat.ID, ids[3], 2)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{ids[2], ids[1]}, messageIDs(messages))
+
+	messages, err = repo.ListMessages(ctx, chat.ID, ids[0], 10)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+}
+
+func testConcurrentInserts(t *testing.T, repo repository.ChatRepository) {
+	ctx := context.Background()
+
+	chat, err := repo.Create(ctx, &models.Chat{Title: "contract concurrent"})
+	require.NoError(t, err)
+
+	const writers, perWriter = 8, 25
+
+	var wg sync.WaitGroup
+	for range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range perWriter {
+				_, err := repo.CreateMessage(ctx, chat.ID, &models.Message{ChatID: chat.ID, Text: "concurrent"})
+				assert.NoError(t, err)
+				_, err = repo.Get(ctx, chat.ID, 5)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	messages, err := repo.ListMessages(ctx, chat.ID, 0, -1)
+	require.NoError(t, err)
+	require.Len(t, messages, writers*perWriter)
+
+	ids := make(map[uint]struct{}, len(messages))
+	for _, message := range messages {
+		ids[message.ID] = struct{}{}
+	}
+	assert.Len(t, ids, writers*perWriter, "message ids must be unique")
+}
+
+// createChatWithMessages - создаёт чат и count сообщений по очереди, сообщения возвращаются в порядке создания
+func createChatWithMessages(t *testing.T, repo repository.ChatRepository, title string, count int) *models.Chat {
+	t.Helper()
+	ctx := context.Background()
+
+	chat, err := repo.Create(ctx, &models.Chat{Title: title})
+	require.NoError(t, err)
+
+	for range count {
+		message, err := repo.CreateMessage(ctx, chat.ID, &models.Message{ChatID: chat.ID, Text: "message"})
+		require.NoError(t, err)
+		chat.Messages = append(chat.Messages, *message)
+		// разные updated_at даже при грубом разрешении часов хранилища
+		time.Sleep(time.Millisecond)
+	}
+	return chat
+}
+
+func messageIDs(messages []models.Message) []uint {
+	ids := make([]uint, len(messages))
+	for i, message := range messages {
+		ids[i] = message.ID
+	}
+	return ids
+}
+
+func testSetAssistant(t *testing.T, repo repository.ChatRepository) {
+	ctx := context.Background()
+
+	chat, err := repo.Create(ctx, &models.Chat{Title: "contract assistant"})
+	require.NoError(t, err)
+	assert.False(t, chat.AssistantEnabled)
+
+	require.NoError(t, repo.SetAssistant(ctx, chat.ID, true))
+	got, err := repo.Get(ctx, chat.ID, 10)
+	require.NoError(t, err)
+	assert.True(t, got.AssistantEnabled)
+
+	// повторная установка того же значения не ошибка
+	require.NoError(t, repo.SetAssistant(ctx, chat.ID, true))
+	require.NoError(t, repo.SetAssistant(ctx, chat.ID, false))
+	got, err = repo.Get(ctx, chat.ID, 10)
+	require.NoError(t, err)
+	assert.False(t, got.AssistantEnabled)
+
+	assert.ErrorIs(t, repo.SetAssistant(ctx, math.MaxInt32, true), repository.ErrNotFound)
+}
+
+func testSetSlowMode(t *testing.T, repo repository.ChatRepository) {
+	ctx := context.Background()
+
+	chat, err := repo.Create(ctx, &models.Chat{Title: "contract slow mode"})
+	require.NoError(t, err)
+	assert.Zero(t, chat.SlowModeSeconds)
+
+	require.NoError(t, repo.SetSlowMode(ctx, chat.ID, 30))
+	got, err := repo.Get(ctx, chat.ID, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 30, got.SlowModeSeconds)
+
+	require.NoError(t, repo.SetSlowMode(ctx, chat.ID, 0))
+	got, err = repo.Get(ctx, chat.ID, 10)
+	require.NoError(t, err)
+	assert.Zero(t, got.SlowModeSeconds)
+
+	assert.ErrorIs(t, repo.SetSlowMode(ctx, math.MaxInt32, 30), repository.ErrNotFound)
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Server - HTTP сервер с корректной остановкой: перестаёт принимать соединения,
+// дожидается активных запросов и потоковых соединений, затем возвращает управление
+type Server struct {
+	http            *http.Server
+	shutdownTimeout time.Duration
+	shutdownDelay   time.Duration
+	beforeShutdown  []func()
+
+	drainOnce sync.Once
+	draining  chan struct{}
+
+	streams sync.WaitGroup
+}
+
+type Config struct {
+	Addr            string
+	ShutdownTimeout time.Duration
+	// ShutdownDelay - пауза между сигналом остановки и закрытием listener, чтобы балансировщик
+	// успел увидеть not ready в /readyz и перестал присылать новые запросы
+	ShutdownDelay     time.Duration
+	ReadHeaderTimeout time.Duration
+	IdleTimeout       time.Duration
+}
+
+func New(cfg Config, handler http.Handler) *Server {
+	s := &Server{
+		shutdownTimeout: cfg.ShutdownTimeout,
+		shutdownDelay:   cfg.ShutdownDelay,
+		draining:        make(chan struct{}),
+	}
+
+	s.http = &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+	s.http.RegisterOnShutdown(s.startDraining)
+
+	return s
+}
+
+// Draining - канал закрывается в начале остановки; потоковые обработчики должны
+// дописать текущие данные и завершиться
+func (s *Server) Draining() <-chan struct{} {
+	return s.draining
+}
+
+// TrackStream - отмечает долгоживущее соединение, которого нужно дождаться при остановке.
+// Возвращаемую функцию нужно вызвать, когда поток завершён.
+func (s *Server) TrackStream() func() {
+	s.streams.Add(1)
+	var once sync.Once
+	return func() {
+		once.Do(s.streams.Done)
+	}
+}
+
+// BeforeShutdown - функция, вызываемая сразу после сигнала остановки, пока сервер ещё принимает запросы
+func (s *Server) BeforeShutdown(fn func()) {
+	s.beforeShutdown = append(s.beforeShutdown, fn)
+}
+
+// OnShutdown - функция, вызываемая в начале остановки сервера
+func (s *Server) OnShutdown(fn func()) {
+	s.http.RegisterOnShutdown(fn)
+}
+
+// Run - обслуживает запросы до отмены ctx, затем выполняет остановку с ожиданием
+// активных запросов не дольше ShutdownTimeout
+func (s *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.http.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.http.Addr, err)
+	}
+
+	return s.Serve(ctx, listener)
+}
+
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.http.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	for _, fn := range s.beforeShutdown {
+		fn()
+	}
+	if s.shutdownDelay > 0 {
+		time.Sleep(s.shutdownDelay)
+	}
+
+	return s.Shutdown()
+}
+
+func (s *Server) Shutdown() error {
+	shutdownCtx := context.Background()
+	if s.shutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		shutdownCtx, cancel = context.WithTimeout(shutdownCtx, s.shutdownTimeout)
+		defer cancel()
+	}
+
+	err := s.http.Shutdown(shutdownCtx)
+	if err == nil {
+		err = s.waitStreams(shutdownCtx)
+	}
+
+	if err != nil {
+		s.http.Close()
+		return fmt.Errorf("graceful shutdown interrupted: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) startDraining() {
+	s.drainOnce.Do(func() {
+		close(s.draining)
+	})
+}
+
+func (s *Server) waitStreams(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.streams.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startServer(t *testing.T, cfg Config, handler http.Handler) (*Server, string, context.CancelFunc, <-chan error) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := New(cfg, handler)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Serve(ctx, listener)
+	}()
+
+	return srv, "http://" + listener.Addr().String(), cancel, done
+}
+
+// TestServer_DrainsInFlightRequests - тест ожидания активного запроса при остановке
+func TestServer_DrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	_, url, cancel, done := startServer(t, Config{ShutdownTimeout: 5 * time.Second},
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+			w.Write([]byte("sent"))
+		}))
+
+	respCh := make(chan string, 1)
+	go func() {
+		resp, err := http.Post(url+"/chats/1/messages", "application/json", nil)
+		if err != nil {
+			respCh <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		respCh <- string(body)
+	}()
+
+	<-started
+	cancel()
+
+	select {
+	case <-done:
+		t.Fatal("server stopped before in-flight request finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	_, err := http.Get(url + "/health")
+	assert.Error(t, err, "new connections must be refused during shutdown")
+
+	close(release)
+	assert.Equal(t, "sent", <-respCh)
+	assert.NoError(t, <-done)
+}
+
+// TestServer_ShutdownTimeout - тест принудительной остановки по истечении дедлайна
+func TestServer_ShutdownTimeout(t *testing.T) {
+	started := make(chan struct{})
+
+	_, url, cancel, done := startServer(t, Config{ShutdownTimeout: 50 * time.Millisecond},
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-r.Context().Done()
+		}))
+
+	go http.Get(url)
+
+	<-started
+	cancel()
+
+	err := <-done
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// TestServer_DrainsStreams - тест сигнала остановки для потоковых соединений
+func TestServer_DrainsStreams(t *testing.T) {
+	var srv *Server
+	started := make(chan struct{})
+	finished := make(chan struct{})
+
+	srv, url, cancel, done := startServer(t, Config{ShutdownTimeout: 5 * time.Second},
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			finish := srv.TrackStream()
+			defer finish()
+
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			close(started)
+
+			<-srv.Draining()
+			w.Write([]byte("bye"))
+			close(finished)
+		}))
+
+	go func() {
+		resp, err := http.Get(url)
+		if err == nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+	}()
+
+	<-started
+	cancel()
+
+	<-finished
+	assert.NoError(t, <-done)
+}
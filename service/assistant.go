@@ -0,0 +1,296 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"chat-api/models"
+)
+
+// subscriberBuffer - сколько событий ждёт медленного подписчика, дальше события ему не доставляются
+const subscriberBuffer = 64
+
+// errBudgetExhausted - чат исчерпал бюджет ответов в текущем окне
+var errBudgetExhausted = errors.New("assistant reply budget is exhausted")
+
+// Responder - модель, отвечающая в чате. Ответ передаётся в emit по частям по мере генерации;
+// итоговый текст - их конкатенация. Ошибка emit (отмена, таймаут) должна прерывать генерацию
+type Responder interface {
+	Respond(ctx context.Context, req AssistantRequest, emit func(chunk string) error) error
+}
+
+// AssistantRequest - что видит Responder
+type AssistantRequest struct {
+	// Name - имя ассистента, им подписываются ответы
+	Name   string
+	ChatID uint
+	Title  string
+	// History - последние сообщения чата от старых к новым, последнее - Message
+	History []models.Message
+	// Message - сообщение с упоминанием ассистента
+	Message models.Message
+}
+
+// AssistantEvent - событие потока ответа: очередная часть, итоговое сообщение или ошибка
+type AssistantEvent struct {
+	ChatID  uint            `json:"chat_id"`
+	Chunk   string          `json:"chunk,omitempty"`
+	Message *models.Message `json:"message,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// AssistantRepository - то, что ассистенту нужно от хранилища
+type AssistantRepository interface {
+	Get(ctx context.Context, id uint, limit int) (*models.Chat, error)
+	CreateMessage(ctx context.Context, id uint, message *models.Message) (*models.Message, error)
+	SetAssistant(ctx context.Context, id uint, enabled bool) error
+}
+
+// AssistantConfig - параметры ассистента
+type AssistantConfig struct {
+	// Name - имя для упоминания (@Name) и подписи ответов
+	Name string
+	// HistoryLimit - сколько последних сообщений передавать Responder
+	HistoryLimit int
+	// Timeout - на один ответ целиком, включая генерацию
+	Timeout time.Duration
+	// Budget - сколько ответов в чате допускается за BudgetWindow
+	Budget       int
+	BudgetWindow time.Duration
+}
+
+// Logger - логгер ошибок ответов ассистента
+type Logger interface {
+	LogError(ctx context.Context, operation string, err error)
+}
+
+// Assistant - отвечает через Responder на сообщения с упоминанием в чатах, где он включён.
+// Ответ генерируется в фоне: отправка сообщения его не ждёт, части ответа доступны через Subscribe
+type Assistant struct {
+	repo      AssistantRepository
+	responder Responder
+	config    AssistantConfig
+	logger    Logger
+	mention   *regexp.Regexp
+	now       func() time.Time
+
+	mu      sync.Mutex
+	budgets map[uint]*budget
+	// pruned - когда из budgets последний раз удалялись истёкшие окна
+	pruned      time.Time
+	subscribers map[uint]map[chan AssistantEvent]struct{}
+	inflight    sync.WaitGroup
+}
+
+// budget - ответы чата в текущем окне
+type budget struct {
+	start time.Time
+	spent int
+}
+
+func NewAssistant(repo AssistantRepository, responder Responder, config AssistantConfig, logger Logger) *Assistant {
+	return &Assistant{
+		repo:        repo,
+		responder:   responder,
+		config:      config,
+		logger:      logger,
+		mention:     regexp.MustCompile(`(?i)(^|[^\w@])@` + regexp.QuoteMeta(config.Name) + `\b`),
+		now:         time.Now,
+		budgets:     make(map[uint]*budget),
+		subscribers: make(map[uint]map[chan AssistantEvent]struct{}),
+	}
+}
+
+// WithAssistant - сообщения с упоминанием ассистента получают его ответ
+func WithAssistant(assistant *Assistant) Option {
+	return func(s *service) {
+		s.assistant = assistant
+	}
+}
+
+// SetEnabled - включает или выключает ассистента в чате
+func (a *Assistant) SetEnabled(ctx context.Context, chatID uint, enabled bool) error {
+	if chatID == 0 {
+		return fmt.Errorf("chat ID must be greater than 0")
+	}
+	return a.repo.SetAssistant(ctx, chatID, enabled)
+}
+
+// CheckChat - ошибка repository.ErrNotFound, если чата нет
+func (a *Assistant) CheckChat(ctx context.Context, chatID uint) error {
+	_, err := a.repo.Get(ctx, chatID, 1)
+	return err
+}
+
+// Mentioned - упоминается ли ассистент в тексте
+func (a *Assistant) Mentioned(text string) bool {
+	return a.mention.MatchString(text)
+}
+
+// Subscribe - поток событий ответов ассистента в чате; cancel отписывает и закрывает канал
+func (a *Assistant) Subscribe(chatID uint) (<-chan AssistantEvent, func()) {
+	events := make(chan AssistantEvent, subscriberBuffer)
+
+	a.mu.Lock()
+	if a.subscribers[chatID] == nil {
+		a.subscribers[chatID] = make(map[chan AssistantEvent]struct{})
+	}
+	a.subscribers[chatID][events] = struct{}{}
+	a.mu.Unlock()
+
+	var once sync.Once
+	return events, func() {
+		once.Do(func() {
+			a.mu.Lock()
+			delete(a.subscribers[chatID], events)
+			if len(a.subscribers[chatID]) == 0 {
+				delete(a.subscribers, chatID)
+			}
+			a.mu.Unlock()
+			close(events)
+		})
+	}
+}
+
+// Wait - ждёт ответы, генерация которых уже началась
+func (a *Assistant) Wait() {
+	a.inflight.Wait()
+}
+
+// handle - запускает ответ на сообщение в фоне; ctx запроса отправителя не ограничивает ответ
+func (a *Assistant) handle(ctx context.Context, message *models.Message) {
+	a.inflight.Add(1)
+	go func() {
+		defer a.inflight.Done()
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), a.config.Timeout)
+		defer cancel()
+		if err := a.reply(ctx, *message); err != nil {
+			a.logger.LogError(ctx, "Assistant reply:", err)
+			a.publish(AssistantEvent{ChatID: message.ChatID, Error: publicError(err)})
+		}
+	}()
+}
+
+func (a *Assistant) reply(ctx context.Context, message models.Message) error {
+	chat, err := a.repo.Get(ctx, message.ChatID, a.config.HistoryLimit)
+	if err != nil {
+		return err
+	}
+	if !chat.AssistantEnabled {
+		return nil
+	}
+	if !a.spend(chat.ID) {
+		return fmt.Errorf("chat %d: %w: %d replies per %s", chat.ID, errBudgetExhausted, a.config.Budget, a.config.BudgetWindow)
+	}
+
+	// Get отдаёт новые сообщения первыми
+	history := slices.Clone(chat.Messages)
+	slices.SortFunc(history, func(x, y models.Message) int { return cmp.Compare(x.ID, y.ID) })
+
+	var text strings.Builder
+	err = a.responder.Respond(ctx, AssistantRequest{
+		Name:    a.config.Name,
+		ChatID:  chat.ID,
+		Title:   chat.Title,
+		History: history,
+		Message: message,
+	}, func(chunk string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		text.WriteString(chunk)
+		a.publish(AssistantEvent{ChatID: chat.ID, Chunk: chunk})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("chat %d: responder failed: %w", chat.ID, err)
+	}
+
+	reply := strings.TrimSpace(text.String())
+	if reply == "" {
+		return nil
+	}
+	reply = truncate(reply, 5000)
+
+	stored, err := a.repo.CreateMessage(ctx, chat.ID, &models.Message{
+		ChatID:     chat.ID,
+		Text:       reply,
+		AuthorName: a.config.Name,
+	})
+	if err != nil {
+		return err
+	}
+
+	a.publish(AssistantEvent{ChatID: chat.ID, Message: stored})
+	return nil
+}
+
+// spend - списывает ответ из бюджета чата; окно отсчитывается от первого ответа в нём
+func (a *Assistant) spend(chatID uint) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	if now.Sub(a.pruned) >= a.config.BudgetWindow {
+		for id, b := range a.budgets {
+			if now.Sub(b.start) >= a.config.BudgetWindow {
+				delete(a.budgets, id)
+			}
+		}
+		a.pruned = now
+	}
+
+	b, ok := a.budgets[chatID]
+	if !ok || now.Sub(b.start) >= a.config.BudgetWindow {
+		b = &budget{start: now}
+		a.budgets[chatID] = b
+	}
+	if b.spent >= a.config.Budget {
+		return false
+	}
+	b.spent++
+	return true
+}
+
+// publicError - причина отказа для подписчиков чата; подробности ошибок хранилища и провайдера остаются в логе
+func publicError(err error) string {
+	switch {
+	case errors.Is(err, errBudgetExhausted):
+		return errBudgetExhausted.Error()
+	case errors.Is(err, context.DeadlineExceeded):
+		return "assistant reply timed out"
+	}
+	return "assistant failed to reply"
+}
+
+// truncate - не длиннее n байт, не разрезая символ
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func (a *Assistant) publish(event AssistantEvent) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for events := range a.subscribers[event.ChatID] {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package service
+
+import "context"
+
+// maxAuthorNameLength - как у messages.author_name
+const maxAuthorNameLength = 100
+
+type authorKey struct{}
+
+// WithAuthor - сообщения, отправленные с этим контекстом, подписываются именем name.
+// Нужен отправителям без учётной записи, например входящим вебхукам
+func WithAuthor(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, authorKey{}, name)
+}
+
+func AuthorFromContext(ctx context.Context) string {
+	author, _ := ctx.Value(authorKey{}).(string)
+	return author
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"chat-api/models"
+)
+
+// maxCommandLength - длина имени слэш-команды
+const maxCommandLength = 32
+
+// Command - вызов слэш-команды из текста сообщения
+type Command struct {
+	ChatID uint
+	// Name - имя без "/", в нижнем регистре
+	Name string
+	// Args - текст после имени команды
+	Args string
+	// Text - сообщение целиком
+	Text string
+	// User - подпись вызвавшего (WithAuthor), может быть пустой
+	User string
+}
+
+// CommandReply - ответ бота. Ephemeral - ответ видит только вызвавший: он возвращается
+// в ответе на его запрос и не сохраняется, как и сама команда
+type CommandReply struct {
+	Author    string
+	Text      string
+	Ephemeral bool
+}
+
+// BotError - внешний бот недоступен или ответил ошибкой. Клиент получает только имя бота:
+// в Err бывают адрес бота и ошибки соединения
+type BotError struct {
+	Bot string
+	Err error
+}
+
+func (e *BotError) Error() string {
+	return fmt.Sprintf("bot %s failed: %v", e.Bot, e.Err)
+}
+
+func (e *BotError) Unwrap() error {
+	return e.Err
+}
+
+// Upstream - что сообщить клиенту вместо подробностей
+func (e *BotError) Upstream() string {
+	return fmt.Sprintf("bot %s is unavailable", e.Bot)
+}
+
+// Commands - реестр слэш-команд. handled=false - такой команды нет, сообщение обычное;
+// reply=nil - команда выполнена без ответа
+type Commands interface {
+	Dispatch(ctx context.Context, cmd Command) (reply *CommandReply, handled bool, err error)
+}
+
+// WithCommands - сообщения вида "/name args" передаются в реестр команд
+func WithCommands(commands Commands) Option {
+	return func(s *service) {
+		s.commands = commands
+	}
+}
+
+// parseCommand - "/name args" -> name, args. Имя - латиница, цифры, "-" и "_",
+// иначе это обычный текст, например путь "/usr/bin"
+func parseCommand(text string) (name, args string, ok bool) {
+	if !strings.HasPrefix(text, "/") {
+		return "", "", false
+	}
+
+	name, args, _ = strings.Cut(text[1:], " ")
+	if name == "" || len(name) > maxCommandLength {
+		return "", "", false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return "", "", false
+		}
+	}
+
+	return strings.ToLower(name), strings.TrimSpace(args), true
+}
+
+// runCommand - выполняет команду до сохранения сообщения: если бот ответил ошибкой,
+// в чате не остаётся команды без ответа
+func (s *service) runCommand(ctx context.Context, message *models.Message, name, args string) (*models.Message, bool, error) {
+	reply, handled, err := s.commands.Dispatch(ctx, Command{
+		ChatID: message.ChatID,
+		Name:   name,
+		Args:   args,
+		Text:   message.Text,
+		User:   message.AuthorName,
+	})
+	if err != nil {
+		return nil, true, fmt.Errorf("command /%s failed: %w", name, err)
+	}
+	if !handled {
+		return nil, false, nil
+	}
+
+	if reply != nil {
+		reply.Text = strings.TrimSpace(reply.Text)
+		if len(reply.Text) > 5000 {
+			err := &BotError{Bot: reply.Author, Err: fmt.Errorf("reply cannot exceed 5000 characters")}
+			return nil, true, fmt.Errorf("command /%s failed: %w", name, err)
+		}
+		if reply.Ephemeral && reply.Text != "" {
+			return &models.Message{
+				ChatID:     message.ChatID,
+				Text:       reply.Text,
+				AuthorName: reply.Author,
+				Ephemeral:  true,
+				CreatedAt:  time.Now(),
+			}, true, nil
+		}
+	}
+
+	message, err = s.repo.CreateMessage(ctx, message.ChatID, message)
+	if err != nil {
+		return nil, true, err
+	}
+	s.metrics.MessageSent()
+
+	if reply != nil && !reply.Ephemeral && reply.Text != "" {
+		_, err := s.repo.CreateMessage(ctx, message.ChatID, &models.Message{
+			ChatID:     message.ChatID,
+			Text:       reply.Text,
+			AuthorName: reply.Author,
+		})
+		if err != nil {
+			return nil, true, err
+		}
+		s.metrics.MessageSent()
+	}
+
+	return message, true, nil
+}
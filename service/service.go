@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 )
 
 type ChatRepository interface {
@@ -12,6 +13,8 @@ type ChatRepository interface {
 	Get(ctx context.Context, id uint, limit int) (*models.Chat, error)
 	Delete(ctx context.Context, id uint) error
 	CreateMessage(ctx context.Context, id uint, message *models.Message) (*models.Message, error)
+	ListMessages(ctx context.Context, chatID uint, before uint, limit int) ([]models.Message, error)
+	SetSlowMode(ctx context.Context, id uint, seconds int) error
 }
 
 type ChatService interface {
@@ -19,16 +22,47 @@ type ChatService interface {
 	GetChat(ctx context.Context, id uint, limit int) (*models.Chat, error)
 	DeleteChat(ctx context.Context, id uint) error
 	SendMessage(ctx context.Context, chatID uint, text string) (*models.Message, error)
+	ListMessages(ctx context.Context, chatID uint, before uint, limit int) ([]models.Message, error)
+	SetSlowMode(ctx context.Context, chatID uint, interval time.Duration) error
+}
+
+// Metrics - доменные счётчики сервиса
+type Metrics interface {
+	ChatCreated()
+	ChatDeleted()
+	MessageSent()
+}
+
+type Option func(*service)
+
+func WithMetrics(metrics Metrics) Option {
+	return func(s *service) {
+		s.metrics = metrics
+	}
 }
 
 type service struct {
-	repo ChatRepository
+	repo      ChatRepository
+	metrics   Metrics
+	commands  Commands
+	assistant *Assistant
+	slowMode  *slowMode
+	now       func() time.Time
 }
 
-func NewChatService(repo ChatRepository) ChatService {
-	return &service{
-		repo,
+func NewChatService(repo ChatRepository, opts ...Option) ChatService {
+	s := &service{
+		repo:     repo,
+		metrics:  nopMetrics{},
+		slowMode: newSlowMode(),
+		now:      time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	return s
 }
 
 func (s *service) CreateChat(ctx context.Context, title string) (*models.Chat, error) {
@@ -44,19 +78,22 @@ func (s *service) CreateChat(ctx context.Context, title string) (*models.Chat, e
 		Title: title,
 	}
 
-	return s.repo.Create(ctx, chat)
+	chat, err := s.repo.Create(ctx, chat)
+	if err != nil {
+		return nil, err
+	}
+
+	s.metrics.ChatCreated()
+	return chat, nil
 }
 
 func (s *service) GetChat(ctx context.Context, id uint, limit int) (*models.Chat, error) {
 	if id == 0 {
 		return nil, fmt.Errorf("chat ID must be greater than 0")
 	}
-	if limit == 0 {
-		limit = 20
-	} else if limit < 0 {
-		return nil, fmt.Errorf("limit cannot be negative")
-	} else if limit > 100 {
-		return nil, fmt.Errorf("limit cannot exceed 100 messages")
+	limit, err := normalizeLimit(limit)
+	if err != nil {
+		return nil, err
 	}
 
 	chat, err := s.repo.Get(ctx, id, limit)
@@ -72,7 +109,12 @@ func (s *service) DeleteChat(ctx context.Context, id uint) error {
 		return fmt.Errorf("chat ID must be greater than 0")
 	}
 
-	return s.repo.Delete(ctx, id)
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.metrics.ChatDeleted()
+	return nil
 }
 
 func (s *service) SendMessage(ctx context.Context, chatID uint, text string) (*models.Message, error) {
@@ -88,10 +130,115 @@ func (s *service) SendMessage(ctx context.Context, chatID uint, text string) (*m
 		return nil, fmt.Errorf("message text cannot exceed 5000 characters")
 	}
 
+	author := strings.TrimSpace(AuthorFromContext(ctx))
+	if len(author) > maxAuthorNameLength {
+		return nil, fmt.Errorf("author name cannot exceed %d characters", maxAuthorNameLength)
+	}
+
+	// слот медленного режима занимается до сохранения и освобождается, если сообщение не сохранено
+	release := func() {}
+	if member := MemberFromContext(ctx); member != "" {
+		var err error
+		if release, err = s.reserveSlowMode(ctx, chatID, member); err != nil {
+			return nil, err
+		}
+	}
+
 	message := &models.Message{
-		ChatID: chatID,
-		Text:   text,
+		ChatID:     chatID,
+		Text:       text,
+		AuthorName: author,
+	}
+
+	if s.commands != nil {
+		if name, args, ok := parseCommand(text); ok {
+			if reply, handled, err := s.runCommand(ctx, message, name, args); handled {
+				// эфемерный ответ означает, что команда не сохранялась в чат
+				if err != nil || reply.Ephemeral {
+					release()
+				}
+				return reply, err
+			}
+		}
+	}
+
+	message, err := s.repo.CreateMessage(ctx, chatID, message)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	s.metrics.MessageSent()
+	if s.assistant != nil && s.assistant.Mentioned(text) {
+		s.assistant.handle(ctx, message)
+	}
+	return message, nil
+}
+
+func (s *service) ListMessages(ctx context.Context, chatID uint, before uint, limit int) ([]models.Message, error) {
+	if chatID == 0 {
+		return nil, fmt.Errorf("chat ID must be greater than 0")
+	}
+	limit, err := normalizeLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := s.repo.ListMessages(ctx, chatID, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// SetSlowMode - участник может писать в чат не чаще раза в interval, 0 - без ограничения
+func (s *service) SetSlowMode(ctx context.Context, chatID uint, interval time.Duration) error {
+	if chatID == 0 {
+		return fmt.Errorf("chat ID must be greater than 0")
+	}
+	if interval < 0 || interval > MaxSlowMode {
+		return fmt.Errorf("slow mode must be between 0 and %s", MaxSlowMode)
+	}
+	if interval%time.Second != 0 {
+		return fmt.Errorf("slow mode must be a whole number of seconds")
 	}
 
-	return s.repo.CreateMessage(ctx, chatID, message)
+	return s.repo.SetSlowMode(ctx, chatID, int(interval/time.Second))
 }
+
+// reserveSlowMode - занимает слот участника в чате с медленным режимом; возвращённая функция освобождает его.
+// Настройка читается из чата при каждом сообщении, поэтому изменение действует сразу
+func (s *service) reserveSlowMode(ctx context.Context, chatID uint, member string) (func(), error) {
+	chat, err := s.repo.Get(ctx, chatID, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat: %w", err)
+	}
+	if chat.SlowModeSeconds == 0 {
+		return func() {}, nil
+	}
+
+	interval := time.Duration(chat.SlowModeSeconds) * time.Second
+	release, wait := s.slowMode.reserve(chatID, member, interval, s.now())
+	if wait > 0 {
+		return nil, &SlowModeError{Wait: wait}
+	}
+	return release, nil
+}
+
+func normalizeLimit(limit int) (int, error) {
+	if limit == 0 {
+		return 20, nil
+	} else if limit < 0 {
+		return 0, fmt.Errorf("limit cannot be negative")
+	} else if limit > 100 {
+		return 0, fmt.Errorf("limit cannot exceed 100 messages")
+	}
+	return limit, nil
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) ChatCreated() {}
+func (nopMetrics) ChatDeleted() {}
+func (nopMetrics) MessageSent() {}
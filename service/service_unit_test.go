@@ -2,9 +2,15 @@ package service
 
 import (
 	"chat-api/models"
+	"chat-api/repository"
 	"context"
 	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -35,6 +41,16 @@ func (m *MockChatRepository) CreateMessage(ctx context.Context, id uint, message
 	return args.Get(0).(*models.Message), args.Error(1)
 }
 
+func (m *MockChatRepository) ListMessages(ctx context.Context, chatID uint, before uint, limit int) ([]models.Message, error) {
+	args := m.Called(ctx, chatID, before, limit)
+	return args.Get(0).([]models.Message), args.Error(1)
+}
+
+func (m *MockChatRepository) SetSlowMode(ctx context.Context, id uint, seconds int) error {
+	args := m.Called(ctx, id, seconds)
+	return args.Error(0)
+}
+
 // TestCreateChat_EmptyTitle - тест создания чата с пустым названием
 func TestCreateChat_EmptyTitle(t *testing.T) {
 	mockRepo := new(MockChatRepository)
@@ -332,6 +348,26 @@ func TestSendMessage_Success(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+// TestSendMessage_Author - имя отправителя из контекста попадает в сообщение
+func TestSendMessage_Author(t *testing.T) {
+	mockRepo := new(MockChatRepository)
+	service := NewChatService(mockRepo)
+
+	ctx := WithAuthor(context.Background(), " CI ")
+
+	mockRepo.On("CreateMessage", ctx, uint(1), mock.MatchedBy(func(msg *models.Message) bool {
+		return msg.AuthorName == "CI"
+	})).Return(&models.Message{ID: 1, ChatID: 1, Text: "build passed", AuthorName: "CI"}, nil)
+
+	_, err := service.SendMessage(ctx, 1, "build passed")
+	assert.NoError(t, err)
+
+	_, err = service.SendMessage(WithAuthor(context.Background(), strings.Repeat("a", 101)), 1, "build passed")
+	assert.ErrorContains(t, err, "author name cannot exceed")
+
+	mockRepo.AssertExpectations(t)
+}
+
 // TestSendMessage_RepositoryError - тест ошибки репозитория при отправке сообщения
 func TestSendMessage_RepositoryError(t *testing.T) {
 	mockRepo := new(MockChatRepository)
@@ -348,4 +384,343 @@ func TestSendMessage_RepositoryError(t *testing.T) {
 	assert.Equal(t, repoError, err)
 
 	mockRepo.AssertExpectations(t)
-}
\ No newline at end of file
+}
+
+// TestListMessages_InvalidLimit - тест постраничного получения сообщений с некорректным limit
+func TestListMessages_InvalidLimit(t *testing.T) {
+	mockRepo := new(MockChatRepository)
+	service := NewChatService(mockRepo)
+
+	ctx := context.Background()
+
+	_, err := service.ListMessages(ctx, 1, 0, -1)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "limit cannot be negative")
+
+	_, err = service.ListMessages(ctx, 1, 0, 101)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "limit cannot exceed 100 messages")
+
+	mockRepo.AssertNotCalled(t, "ListMessages")
+}
+
+// TestListMessages_Success - тест успешного постраничного получения сообщений
+func TestListMessages_Success(t *testing.T) {
+	mockRepo := new(MockChatRepository)
+	service := NewChatService(mockRepo)
+
+	ctx := context.Background()
+	expected := []models.Message{{ID: 9, ChatID: 1, Text: "older"}}
+
+	mockRepo.On("ListMessages", ctx, uint(1), uint(10), 20).Return(expected, nil)
+
+	result, err := service.ListMessages(ctx, 1, 10, 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// fakeCommands - реестр с одной командой /echo, отвечающей reply
+type fakeCommands struct {
+	reply *CommandReply
+	calls []Command
+}
+
+func (f *fakeCommands) Dispatch(_ context.Context, cmd Command) (*CommandReply, bool, error) {
+	if cmd.Name != "echo" {
+		return nil, false, nil
+	}
+	f.calls = append(f.calls, cmd)
+	return f.reply, true, nil
+}
+
+// TestParseCommand - тест разбора слэш-команды из текста сообщения
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		text, name, args string
+		ok               bool
+	}{
+		{"/remind 10m standup", "remind", "10m standup", true},
+		{"/Poll", "poll", "", true},
+		{"/deploy_prod   now ", "deploy_prod", "now", true},
+		{"/usr/bin is full", "", "", false},
+		{"/ space", "", "", false},
+		{"hello /remind", "", "", false},
+	}
+	for _, tt := range tests {
+		name, args, ok := parseCommand(tt.text)
+		assert.Equal(t, tt.ok, ok, tt.text)
+		assert.Equal(t, tt.name, name, tt.text)
+		assert.Equal(t, tt.args, args, tt.text)
+	}
+}
+
+// TestSendMessage_Command - команда сохраняется вместе с ответом бота, эфемерный ответ
+// возвращается без записи, неизвестная команда - обычное сообщение
+func TestSendMessage_Command(t *testing.T) {
+	mockRepo := new(MockChatRepository)
+	commands := &fakeCommands{reply: &CommandReply{Author: "Echo", Text: "hi"}}
+	service := NewChatService(mockRepo, WithCommands(commands))
+
+	ctx := WithAuthor(context.Background(), "alice")
+
+	mockRepo.On("CreateMessage", ctx, uint(1), mock.MatchedBy(func(msg *models.Message) bool {
+		return msg.Text == "/echo hi" && msg.AuthorName == "alice"
+	})).Return(&models.Message{ID: 1, ChatID: 1, Text: "/echo hi", AuthorName: "alice"}, nil).Once()
+	mockRepo.On("CreateMessage", ctx, uint(1), mock.MatchedBy(func(msg *models.Message) bool {
+		return msg.Text == "hi" && msg.AuthorName == "Echo"
+	})).Return(&models.Message{ID: 2, ChatID: 1, Text: "hi", AuthorName: "Echo"}, nil).Once()
+
+	result, err := service.SendMessage(ctx, 1, "/echo hi")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), result.ID)
+	assert.Equal(t, []Command{{ChatID: 1, Name: "echo", Args: "hi", Text: "/echo hi", User: "alice"}}, commands.calls)
+	mockRepo.AssertExpectations(t)
+
+	commands.reply = &CommandReply{Author: "Echo", Text: "only you see this", Ephemeral: true}
+	result, err = service.SendMessage(ctx, 1, "/echo secret")
+	assert.NoError(t, err)
+	assert.True(t, result.Ephemeral)
+	assert.Equal(t, "only you see this", result.Text)
+	mockRepo.AssertNumberOfCalls(t, "CreateMessage", 2)
+
+	mockRepo.On("CreateMessage", ctx, uint(1), mock.MatchedBy(func(msg *models.Message) bool {
+		return msg.Text == "/shrug"
+	})).Return(&models.Message{ID: 3, ChatID: 1, Text: "/shrug"}, nil).Once()
+	_, err = service.SendMessage(ctx, 1, "/shrug")
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+// scriptedResponder - отвечает заданными частями и запоминает запросы
+type scriptedResponder struct {
+	chunks   []string
+	err      error
+	requests []AssistantRequest
+}
+
+func (r *scriptedResponder) Respond(ctx context.Context, req AssistantRequest, emit func(chunk string) error) error {
+	r.requests = append(r.requests, req)
+	for _, chunk := range r.chunks {
+		if err := emit(chunk); err != nil {
+			return err
+		}
+	}
+	return r.err
+}
+
+type nopLogger struct{}
+
+func (nopLogger) LogError(ctx context.Context, operation string, err error) {}
+
+func TestAssistant_Mentioned(t *testing.T) {
+	a := NewAssistant(nil, nil, AssistantConfig{Name: "helper"}, nopLogger{})
+
+	assert.True(t, a.Mentioned("@helper what time is it?"))
+	assert.True(t, a.Mentioned("hey @Helper, hi"))
+	assert.False(t, a.Mentioned("@helpers hi"))
+	assert.False(t, a.Mentioned("mail me at bob@helper.com"))
+	assert.False(t, a.Mentioned("helper"))
+}
+
+// TestAssistant_PublicError - подписчики не видят текст ошибок провайдера и хранилища
+func TestAssistant_PublicError(t *testing.T) {
+	ctx := WithAuthor(context.Background(), "alice")
+	repo := repository.NewMemoryRepository(nil)
+	responder := &scriptedResponder{err: errors.New("openai: 401 invalid api key sk-secret")}
+	assistant := NewAssistant(repo, responder, AssistantConfig{
+		Name: "helper", HistoryLimit: 10, Timeout: time.Second, Budget: 10, BudgetWindow: time.Hour,
+	}, nopLogger{})
+	service := NewChatService(repo, WithAssistant(assistant))
+
+	chat, err := service.CreateChat(ctx, "General")
+	assert.NoError(t, err)
+	assert.NoError(t, assistant.SetEnabled(ctx, chat.ID, true))
+	events, cancel := assistant.Subscribe(chat.ID)
+	defer cancel()
+
+	_, err = service.SendMessage(ctx, chat.ID, "@helper hi")
+	assert.NoError(t, err)
+	assistant.Wait()
+	assert.Equal(t, AssistantEvent{ChatID: chat.ID, Error: "assistant failed to reply"}, <-events)
+}
+
+func TestAssistant_Truncate(t *testing.T) {
+	assert.Equal(t, "abc", truncate("abc", 5))
+	assert.Equal(t, "ab", truncate("abc", 2))
+	// "я" занимает два байта и не разрезается
+	assert.Equal(t, "a", truncate("aя", 2))
+	assert.True(t, utf8.ValidString(truncate(strings.Repeat("я", 3000), 5000)))
+}
+
+func TestAssistant_BudgetsPruned(t *testing.T) {
+	now := time.Now()
+	a := NewAssistant(nil, nil, AssistantConfig{Name: "helper", Budget: 1, BudgetWindow: time.Minute}, nopLogger{})
+	a.now = func() time.Time { return now }
+
+	assert.True(t, a.spend(1))
+	assert.True(t, a.spend(2))
+	assert.False(t, a.spend(1))
+	assert.Len(t, a.budgets, 2)
+
+	// истёкшие окна удаляются, даже если чат больше не отвечает
+	now = now.Add(time.Minute)
+	assert.True(t, a.spend(3))
+	assert.Len(t, a.budgets, 1)
+}
+
+func TestSendMessage_Assistant(t *testing.T) {
+	ctx := WithAuthor(context.Background(), "alice")
+	repo := repository.NewMemoryRepository(nil)
+	responder := &scriptedResponder{chunks: []string{"Hello ", "alice"}}
+	assistant := NewAssistant(repo, responder, AssistantConfig{
+		Name: "helper", HistoryLimit: 10, Timeout: time.Second, Budget: 1, BudgetWindow: time.Hour,
+	}, nopLogger{})
+	service := NewChatService(repo, WithAssistant(assistant))
+
+	chat, err := service.CreateChat(ctx, "General")
+	assert.NoError(t, err)
+	events, cancel := assistant.Subscribe(chat.ID)
+	defer cancel()
+
+	// выключенный ассистент молчит
+	_, err = service.SendMessage(ctx, chat.ID, "@helper hi")
+	assert.NoError(t, err)
+	assistant.Wait()
+	assert.Empty(t, responder.requests)
+
+	assert.NoError(t, assistant.SetEnabled(ctx, chat.ID, true))
+	_, err = service.SendMessage(ctx, chat.ID, "no mention")
+	assert.NoError(t, err)
+	_, err = service.SendMessage(ctx, chat.ID, "@helper hi again")
+	assert.NoError(t, err)
+	assistant.Wait()
+
+	if assert.Len(t, responder.requests, 1) {
+		req := responder.requests[0]
+		assert.Equal(t, "General", req.Title)
+		assert.Equal(t, "@helper hi again", req.Message.Text)
+		assert.Equal(t, []string{"@helper hi", "no mention", "@helper hi again"},
+			[]string{req.History[0].Text, req.History[1].Text, req.History[2].Text})
+	}
+	assert.Equal(t, AssistantEvent{ChatID: chat.ID, Chunk: "Hello "}, <-events)
+	assert.Equal(t, AssistantEvent{ChatID: chat.ID, Chunk: "alice"}, <-events)
+	event := <-events
+	if assert.NotNil(t, event.Message) {
+		assert.Equal(t, "Hello alice", event.Message.Text)
+		assert.Equal(t, "helper", event.Message.AuthorName)
+	}
+
+	messages, err := service.ListMessages(ctx, chat.ID, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, messages, 4)
+
+	// бюджет исчерпан: ответа нет, подписчики получают ошибку
+	_, err = service.SendMessage(ctx, chat.ID, "@helper one more")
+	assert.NoError(t, err)
+	assistant.Wait()
+	assert.Len(t, responder.requests, 1)
+	assert.Contains(t, (<-events).Error, "budget")
+
+	assert.ErrorIs(t, assistant.SetEnabled(ctx, 999, true), repository.ErrNotFound)
+}
+
+func TestSetSlowMode(t *testing.T) {
+	mockRepo := new(MockChatRepository)
+	service := NewChatService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("SetSlowMode", ctx, uint(1), 30).Return(nil).Once()
+	assert.NoError(t, service.SetSlowMode(ctx, 1, 30*time.Second))
+
+	assert.Error(t, service.SetSlowMode(ctx, 0, time.Second))
+	assert.Error(t, service.SetSlowMode(ctx, 1, -time.Second))
+	assert.Error(t, service.SetSlowMode(ctx, 1, 7*time.Hour))
+	assert.Error(t, service.SetSlowMode(ctx, 1, 1500*time.Millisecond))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSendMessage_SlowMode(t *testing.T) {
+	mockRepo := new(MockChatRepository)
+	svc := NewChatService(mockRepo).(*service)
+	now := time.Unix(1000, 0)
+	svc.now = func() time.Time { return now }
+
+	alice := WithMember(context.Background(), "ip:10.0.0.1")
+	bob := WithMember(context.Background(), "ip:10.0.0.2")
+
+	mockRepo.On("Get", mock.Anything, uint(1), 1).Return(&models.Chat{ID: 1, SlowModeSeconds: 30}, nil)
+	mockRepo.On("CreateMessage", mock.Anything, uint(1), mock.Anything).Return(&models.Message{ID: 1, ChatID: 1}, nil)
+
+	_, err := svc.SendMessage(alice, 1, "first")
+	assert.NoError(t, err)
+
+	now = now.Add(10 * time.Second)
+	_, err = svc.SendMessage(alice, 1, "too soon")
+	var slowMode *SlowModeError
+	if assert.ErrorAs(t, err, &slowMode) {
+		assert.Equal(t, 20*time.Second, slowMode.RetryAfter())
+	}
+
+	// другие участники и отправители без участника не ограничены
+	_, err = svc.SendMessage(bob, 1, "hi")
+	assert.NoError(t, err)
+	_, err = svc.SendMessage(context.Background(), 1, "reminder")
+	assert.NoError(t, err)
+
+	now = now.Add(20 * time.Second)
+	_, err = svc.SendMessage(alice, 1, "second")
+	assert.NoError(t, err)
+	mockRepo.AssertNumberOfCalls(t, "CreateMessage", 4)
+}
+
+// TestSendMessage_SlowModeFailed - отклонённые и несохранённые сообщения не занимают слот участника
+func TestSendMessage_SlowModeFailed(t *testing.T) {
+	mockRepo := new(MockChatRepository)
+	svc := NewChatService(mockRepo).(*service)
+	alice := WithMember(context.Background(), "ip:10.0.0.1")
+
+	mockRepo.On("Get", mock.Anything, uint(1), 1).Return(&models.Chat{ID: 1, SlowModeSeconds: 30}, nil)
+	mockRepo.On("CreateMessage", mock.Anything, uint(1), mock.Anything).Return((*models.Message)(nil), errors.New("db down")).Once()
+	mockRepo.On("CreateMessage", mock.Anything, uint(1), mock.Anything).Return(&models.Message{ID: 1, ChatID: 1}, nil).Once()
+
+	_, err := svc.SendMessage(alice, 1, strings.Repeat("a", 5001))
+	assert.Error(t, err)
+	_, err = svc.SendMessage(alice, 1, "lost")
+	assert.EqualError(t, err, "db down")
+
+	_, err = svc.SendMessage(alice, 1, "saved")
+	assert.NoError(t, err)
+	_, err = svc.SendMessage(alice, 1, "too soon")
+	var slowMode *SlowModeError
+	assert.ErrorAs(t, err, &slowMode)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestSendMessage_SlowModeConcurrent - из параллельных отправок участника сохраняется одна
+func TestSendMessage_SlowModeConcurrent(t *testing.T) {
+	mockRepo := new(MockChatRepository)
+	svc := NewChatService(mockRepo)
+	alice := WithMember(context.Background(), "ip:10.0.0.1")
+
+	mockRepo.On("Get", mock.Anything, uint(1), 1).Return(&models.Chat{ID: 1, SlowModeSeconds: 30}, nil)
+	mockRepo.On("CreateMessage", mock.Anything, uint(1), mock.Anything).Return(&models.Message{ID: 1, ChatID: 1}, nil)
+
+	var wg sync.WaitGroup
+	var sent atomic.Int32
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := svc.SendMessage(alice, 1, "spam"); err == nil {
+				sent.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), sent.Load())
+	mockRepo.AssertNumberOfCalls(t, "CreateMessage", 1)
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// MaxSlowMode - наибольший интервал медленного режима
+const MaxSlowMode = 6 * time.Hour
+
+type memberKey struct{}
+
+// WithMember - отправитель сообщений с этим контекстом, например ключ API или адрес клиента.
+// Медленный режим чата ограничивает каждого участника отдельно; без участника не действует
+func WithMember(ctx context.Context, member string) context.Context {
+	return context.WithValue(ctx, memberKey{}, member)
+}
+
+func MemberFromContext(ctx context.Context) string {
+	member, _ := ctx.Value(memberKey{}).(string)
+	return member
+}
+
+// SlowModeError - участник пишет в чат с медленным режимом чаще, чем разрешено
+type SlowModeError struct {
+	Wait time.Duration
+}
+
+func (e *SlowModeError) Error() string {
+	return fmt.Sprintf("slow mode is on: wait %s before posting again", e.Wait.Round(time.Second))
+}
+
+// RetryAfter - через сколько участник сможет написать снова
+func (e *SlowModeError) RetryAfter() time.Duration {
+	return e.Wait
+}
+
+// slowMode - время последнего сообщения каждого участника в чатах. Состояние в памяти процесса
+type slowMode struct {
+	mu        sync.Mutex
+	last      map[slowModeKey]time.Time
+	lastSweep time.Time
+}
+
+type slowModeKey struct {
+	chatID uint
+	member string
+}
+
+func newSlowMode() *slowMode {
+	return &slowMode{last: make(map[slowModeKey]time.Time)}
+}
+
+// reserve - занимает слот участника, если с прошлого сообщения прошло interval, иначе возвращает,
+// сколько осталось ждать. Проверка и отметка атомарны, поэтому параллельные отправки не проходят вместе.
+// release возвращает слот к прежнему значению, если сообщение так и не сохранено
+func (m *slowMode) reserve(chatID uint, member string, interval time.Duration, now time.Time) (release func(), wait time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := slowModeKey{chatID: chatID, member: member}
+	last, ok := m.last[key]
+	if ok {
+		if wait := interval - now.Sub(last); wait > 0 {
+			return nil, time.Duration(math.Ceil(wait.Seconds())) * time.Second
+		}
+	}
+
+	// записи старше наибольшего интервала уже ничего не ограничивают
+	if now.Sub(m.lastSweep) >= time.Minute {
+		m.lastSweep = now
+		for key, last := range m.last {
+			if now.Sub(last) >= MaxSlowMode {
+				delete(m.last, key)
+			}
+		}
+	}
+
+	m.last[key] = now
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+
+			// слот мог заново занять следующий запрос после очистки
+			if !m.last[key].Equal(now) {
+				return
+			}
+			if ok {
+				m.last[key] = last
+			} else {
+				delete(m.last, key)
+			}
+		})
+	}, 0
+}
@@ -2,6 +2,7 @@ package tests
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -12,8 +13,11 @@ import (
 
 	"chat-api/handlers"
 	"chat-api/logger"
+	"chat-api/migrate"
+	"chat-api/migrations"
 	"chat-api/models"
 	"chat-api/repository"
+	"chat-api/repository/repotest"
 	"chat-api/service"
 	"chat-api/utils"
 
@@ -23,27 +27,6 @@ import (
 	"gorm.io/gorm"
 )
 
-// TODO: Сделать скрипт который берет миграции из файлов
-const migrationSQL = `
-CREATE TABLE IF NOT EXISTS chats (
-    id SERIAL PRIMARY KEY,
-    title VARCHAR(200) NOT NULL,
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-);
-
-CREATE TABLE IF NOT EXISTS messages (
-    id SERIAL PRIMARY KEY,
-    chat_id INTEGER NOT NULL,
-    text TEXT NOT NULL,
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-    FOREIGN KEY (chat_id) REFERENCES chats(id) ON DELETE CASCADE
-);
-
-CREATE INDEX IF NOT EXISTS idx_messages_chat_id ON messages(chat_id);
-`
-
 // IntegrationTestSuite - набор интеграционных тестов
 type IntegrationTestSuite struct {
 	suite.Suite
@@ -103,8 +86,13 @@ func (suite *IntegrationTestSuite) TearDownSuite() {
 
 // runMigrations - выполняет миграции для тестовой БД
 func (suite *IntegrationTestSuite) runMigrations() error {
-	if err := suite.db.Exec(migrationSQL).Error; err != nil {
-		return fmt.Errorf("failed to execute migration SQL: %w", err)
+	migrator, err := migrate.New(suite.sqlDB, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	if _, err := migrator.Up(context.Background()); err != nil {
+		return fmt.Errorf("failed to apply migrations: %w", err)
 	}
 
 	return nil
@@ -215,6 +203,13 @@ func (suite *IntegrationTestSuite) TestDeleteChat() {
 	assert.Empty(suite.T(), deletedMessages, "Messages should be deleted due to CASCADE")
 }
 
+// TestRepositoryContract - репозиторий PostgreSQL проходит общий набор проверок repotest
+func (suite *IntegrationTestSuite) TestRepositoryContract() {
+	repotest.Run(suite.T(), func(t *testing.T) repository.ChatRepository {
+		return repository.NewRepository(suite.db, logger.NewDatabaseLogger())
+	})
+}
+
 // TestRunSuite - запуск всех тестов
 func TestIntegrationSuite(t *testing.T) {
 	suite.Run(t, new(IntegrationTestSuite))
@@ -2,7 +2,6 @@ package utils
 
 import (
 	"os"
-	"strconv"
 )
 
 func GetEnv(key, defaultVal string) string {
@@ -11,21 +10,3 @@ func GetEnv(key, defaultVal string) string {
 	}
 	return defaultVal
 }
-
-func GetEnvAsInt(key string, defaultVal int) int {
-	if value := os.Getenv(key); value != "" {
-		if intVal, err := strconv.Atoi(value); err == nil {
-			return intVal
-		}
-	}
-	return defaultVal
-}
-
-func GetEnvAsBool(key string, defaultVal bool) bool {
-	if value := os.Getenv(key); value != "" {
-		if boolVal, err := strconv.ParseBool(value); err == nil {
-			return boolVal
-		}
-	}
-	return defaultVal
-}
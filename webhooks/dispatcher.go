@@ -0,0 +1,236 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"chat-api/database"
+	"chat-api/models"
+	"chat-api/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// lockID - ключ database.TryLock: очередь разбирает один экземпляр
+	lockID int64 = 0x636861745f776868 // "chat_whh"
+
+	// maxErrorLength - сколько текста ошибки хранить в доставке и попытке
+	maxErrorLength = 1000
+	// pruneEvery - как часто удалять старые успешные доставки
+	pruneEvery = time.Minute
+)
+
+// Config - параметры доставки
+type Config struct {
+	// Timeout - таймаут одного запроса
+	Timeout time.Duration
+	// MaxAttempts - после стольких неудач доставка становится мёртвой
+	MaxAttempts int
+	// Backoff - пауза перед первым повтором, дальше удваивается до MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Retention - сколько хранить успешные доставки с историей попыток
+	Retention time.Duration
+
+	BatchSize    int
+	PollInterval time.Duration
+}
+
+// Logger - логгер ошибок очереди
+type Logger interface {
+	LogError(ctx context.Context, operation string, err error)
+}
+
+// Metrics - счётчик попыток доставки по результату: delivered, retry или dead
+type Metrics interface {
+	WebhookAttempt(result string)
+}
+
+// Dispatcher - отправляет доставки из очереди: подпись, повторы с растущей паузой и
+// перевод в мёртвые после MaxAttempts неудач
+type Dispatcher struct {
+	store   *Store
+	client  *http.Client
+	config  Config
+	logger  Logger
+	metrics Metrics
+	now     func() time.Time
+
+	lastPrune time.Time
+}
+
+func NewDispatcher(store *Store, config Config, logger Logger, metrics Metrics) *Dispatcher {
+	return &Dispatcher{
+		store:   store,
+		client:  &http.Client{Timeout: config.Timeout},
+		config:  config,
+		logger:  logger,
+		metrics: metrics,
+		now:     time.Now,
+	}
+}
+
+// Run - разбирает очередь до отмены ctx
+func (d *Dispatcher) Run(ctx context.Context) {
+	for {
+		sent, err := d.ProcessDue(ctx)
+		if err != nil && ctx.Err() == nil {
+			d.logger.LogError(ctx, "Webhook dispatcher:", err)
+		}
+
+		wait := d.config.PollInterval
+		if err == nil && sent == d.config.BatchSize {
+			wait = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// ProcessDue - одна пачка доставок, время которых наступило. Возвращает число обработанных
+func (d *Dispatcher) ProcessDue(ctx context.Context) (int, error) {
+	unlock, ok, err := database.TryLock(ctx, d.store.db, lockID)
+	if err != nil || !ok {
+		return 0, err
+	}
+	defer unlock()
+
+	d.prune(ctx)
+
+	deliveries, err := d.store.Due(ctx, d.now(), d.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	subs := make(map[uint]*models.WebhookSubscription)
+	var errs []error
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		sub, ok := subs[delivery.SubscriptionID]
+		if !ok {
+			// подписку могли удалить вместе с доставками после выборки
+			if sub, err = d.store.GetSubscription(ctx, delivery.SubscriptionID); errors.Is(err, ErrNotFound) {
+				continue
+			} else if err != nil {
+				return i, err
+			}
+			subs[delivery.SubscriptionID] = sub
+		}
+
+		if err := d.attempt(ctx, sub, delivery); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return len(deliveries), errors.Join(errs...)
+}
+
+// attempt - одна попытка отправки и запись её результата
+func (d *Dispatcher) attempt(ctx context.Context, sub *models.WebhookSubscription, delivery *models.WebhookDelivery) error {
+	start := d.now()
+	statusCode, sendErr := d.send(ctx, sub, delivery)
+	attempt := &models.WebhookAttempt{
+		StatusCode: statusCode,
+		DurationMs: float64(time.Since(start).Nanoseconds()) / 1e6,
+	}
+
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+
+	result := models.DeliveryDelivered
+	switch {
+	case sendErr == nil:
+		delivered := d.now()
+		delivery.Status = models.DeliveryDelivered
+		delivery.DeliveredAt = &delivered
+		delivery.LastError = ""
+	case delivery.Attempts >= d.config.MaxAttempts:
+		result = models.DeliveryDead
+		delivery.Status = models.DeliveryDead
+		delivery.LastError = truncate(sendErr.Error())
+		attempt.Error = delivery.LastError
+	default:
+		result = "retry"
+		delivery.NextAttemptAt = d.now().Add(d.backoff(delivery.Attempts))
+		delivery.LastError = truncate(sendErr.Error())
+		attempt.Error = delivery.LastError
+	}
+	d.metrics.WebhookAttempt(result)
+
+	return d.store.RecordAttempt(ctx, delivery, attempt)
+}
+
+func (d *Dispatcher) send(ctx context.Context, sub *models.WebhookSubscription, delivery *models.WebhookDelivery) (statusCode int, err error) {
+	ctx, span := tracing.StartClient(ctx, "Webhook.Deliver",
+		attribute.Int64("webhook.subscription_id", int64(sub.ID)),
+		attribute.String("event.type", delivery.EventType),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	body := []byte(delivery.Payload)
+	timestamp := d.now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "chat-api-webhooks")
+	req.Header.Set(HeaderDeliveryID, strconv.FormatUint(delivery.ID, 10))
+	req.Header.Set(HeaderEventID, strconv.FormatUint(delivery.EventID, 10))
+	req.Header.Set(HeaderEventType, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, timestamp, body))
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff - пауза после attempts неудач: Backoff, 2*Backoff, 4*Backoff... не больше MaxBackoff
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.config.Backoff
+	for i := 1; i < attempts && wait < d.config.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, d.config.MaxBackoff)
+}
+
+func (d *Dispatcher) prune(ctx context.Context) {
+	if d.config.Retention <= 0 || d.now().Sub(d.lastPrune) < pruneEvery {
+		return
+	}
+	d.lastPrune = d.now()
+
+	if _, err := d.store.PruneDelivered(ctx, d.now().Add(-d.config.Retention)); err != nil {
+		d.logger.LogError(ctx, "Webhook dispatcher:", err)
+	}
+}
+
+func truncate(message string) string {
+	if len(message) > maxErrorLength {
+		return message[:maxErrorLength]
+	}
+	return message
+}
@@ -0,0 +1,179 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"chat-api/handlers"
+	"chat-api/models"
+)
+
+// Handler - API подписок: создание, просмотр, удаление, доставки и их повтор
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) Register(g *handlers.Group) {
+	g.Post("/webhooks", h.Create)
+	g.Get("/webhooks", h.List)
+	g.Get("/webhooks/{id}", h.Get)
+	g.Delete("/webhooks/{id}", h.Delete)
+	g.Get("/webhooks/{id}/deliveries", h.Deliveries)
+	g.Get("/webhooks/{id}/deliveries/{delivery_id}", h.Delivery)
+	g.Post("/webhooks/{id}/deliveries/{delivery_id}/replay", h.Replay)
+}
+
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := h.service.Create(r.Context(), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, webhook)
+}
+
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := h.service.List(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, webhooks)
+}
+
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := handlers.PathParamUint(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := h.service.Get(r.Context(), id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, webhook)
+}
+
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := handlers.PathParamUint(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), id); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Deliveries - доставки подписки; ?status=dead - мёртвые, ?limit - сколько последних
+func (h *Handler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := handlers.PathParamUint(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+
+	var limit int
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+
+	deliveries, err := h.service.Deliveries(r.Context(), id, query.Get("status"), limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+func (h *Handler) Delivery(w http.ResponseWriter, r *http.Request) {
+	id, deliveryID, ok := deliveryParams(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.service.Delivery(r.Context(), id, deliveryID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, delivery)
+}
+
+// Replay - повторная отправка доставленной или мёртвой доставки
+func (h *Handler) Replay(w http.ResponseWriter, r *http.Request) {
+	id, deliveryID, ok := deliveryParams(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.service.Replay(r.Context(), id, deliveryID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, delivery)
+}
+
+func deliveryParams(w http.ResponseWriter, r *http.Request) (uint, uint64, bool) {
+	id, err := handlers.PathParamUint(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+
+	deliveryID, err := strconv.ParseUint(handlers.PathParam(r, "delivery_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid delivery ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+
+	return id, deliveryID, true
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrNotReplayable):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
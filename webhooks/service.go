@@ -0,0 +1,217 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"chat-api/models"
+)
+
+const (
+	// secretPrefix - у сгенерированных секретов, чтобы их было легко узнать в конфигах
+	secretPrefix = "whsec_"
+	// maxURLLength - ограничение на адрес получателя
+	maxURLLength = 2000
+	// minSecretLength - собственный секрет короче не принимается
+	minSecretLength = 16
+
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 500
+)
+
+// ErrInvalid - запрос не прошёл проверку
+var ErrInvalid = errors.New("invalid webhook")
+
+// knownEvents - типы событий, на которые можно подписаться
+var knownEvents = []string{models.EventChatCreated, models.EventChatDeleted, models.EventMessageCreated}
+
+// Service - управление подписками и просмотр доставок для API
+type Service struct {
+	store *Store
+	now   func() time.Time
+}
+
+func NewService(store *Store) *Service {
+	return &Service{store: store, now: time.Now}
+}
+
+// Create - создаёт подписку; секрет генерируется, если не передан, и возвращается только здесь
+func (s *Service) Create(ctx context.Context, req models.CreateWebhookRequest) (*models.WebhookResponse, error) {
+	target, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http(s) url", ErrInvalid)
+	}
+	if len(target.String()) > maxURLLength {
+		return nil, fmt.Errorf("%w: url cannot exceed %d characters", ErrInvalid, maxURLLength)
+	}
+
+	for _, event := range req.Events {
+		if !slices.Contains(knownEvents, event) {
+			return nil, fmt.Errorf("%w: unknown event %q, expected one of %s", ErrInvalid, event, strings.Join(knownEvents, ", "))
+		}
+	}
+	events := slices.Clone(req.Events)
+	slices.Sort(events)
+	events = slices.Compact(events)
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = newSecret(); err != nil {
+			return nil, err
+		}
+	} else if len(secret) < minSecretLength {
+		return nil, fmt.Errorf("%w: secret must be at least %d characters", ErrInvalid, minSecretLength)
+	}
+
+	if req.ChatID != nil {
+		exists, err := s.store.ChatExists(ctx, *req.ChatID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("chat %d: %w", *req.ChatID, ErrNotFound)
+		}
+	}
+
+	sub := &models.WebhookSubscription{
+		URL:        target.String(),
+		Secret:     secret,
+		ChatID:     req.ChatID,
+		EventTypes: strings.Join(events, ","),
+	}
+	if err := s.store.CreateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	response := toWebhookResponse(*sub)
+	response.Secret = sub.Secret
+	return &response, nil
+}
+
+func (s *Service) List(ctx context.Context) ([]models.WebhookResponse, error) {
+	subs, err := s.store.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]models.WebhookResponse, len(subs))
+	for i, sub := range subs {
+		responses[i] = toWebhookResponse(sub)
+	}
+	return responses, nil
+}
+
+func (s *Service) Get(ctx context.Context, id uint) (*models.WebhookResponse, error) {
+	sub, err := s.store.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	response := toWebhookResponse(*sub)
+	return &response, nil
+}
+
+func (s *Service) Delete(ctx context.Context, id uint) error {
+	return s.store.DeleteSubscription(ctx, id)
+}
+
+// Deliveries - доставки подписки; status=dead даёт список мёртвых
+func (s *Service) Deliveries(ctx context.Context, id uint, status string, limit int) ([]models.WebhookDeliveryResponse, error) {
+	switch status {
+	case "", models.DeliveryPending, models.DeliveryDelivered, models.DeliveryDead:
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalid, status)
+	}
+	if limit <= 0 {
+		limit = defaultDeliveryLimit
+	}
+	limit = min(limit, maxDeliveryLimit)
+
+	if _, err := s.store.GetSubscription(ctx, id); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.store.ListDeliveries(ctx, id, status, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]models.WebhookDeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		responses[i] = toDeliveryResponse(delivery)
+	}
+	return responses, nil
+}
+
+// Delivery - доставка с историей попыток
+func (s *Service) Delivery(ctx context.Context, id uint, deliveryID uint64) (*models.WebhookDeliveryResponse, error) {
+	delivery, err := s.store.GetDelivery(ctx, id, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	response := toDeliveryResponse(*delivery)
+	return &response, nil
+}
+
+// Replay - ставит доставку в очередь заново
+func (s *Service) Replay(ctx context.Context, id uint, deliveryID uint64) (*models.WebhookDeliveryResponse, error) {
+	delivery, err := s.store.Replay(ctx, id, deliveryID, s.now())
+	if err != nil {
+		return nil, err
+	}
+	response := toDeliveryResponse(*delivery)
+	return &response, nil
+}
+
+func newSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed generate webhook secret: %w", err)
+	}
+	return secretPrefix + hex.EncodeToString(buf), nil
+}
+
+func toWebhookResponse(sub models.WebhookSubscription) models.WebhookResponse {
+	return models.WebhookResponse{
+		ID:        sub.ID,
+		URL:       sub.URL,
+		ChatID:    sub.ChatID,
+		Events:    eventTypes(sub),
+		CreatedAt: sub.CreatedAt,
+	}
+}
+
+func toDeliveryResponse(delivery models.WebhookDelivery) models.WebhookDeliveryResponse {
+	response := models.WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		SubscriptionID: delivery.SubscriptionID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		ChatID:         delivery.ChatID,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		LastError:      delivery.LastError,
+		LastStatusCode: delivery.LastStatusCode,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+	}
+	if delivery.Status == models.DeliveryPending {
+		next := delivery.NextAttemptAt
+		response.NextAttemptAt = &next
+	}
+	for _, attempt := range delivery.History {
+		response.History = append(response.History, models.WebhookAttemptResponse{
+			StatusCode: attempt.StatusCode,
+			Error:      attempt.Error,
+			DurationMs: attempt.DurationMs,
+			CreatedAt:  attempt.CreatedAt,
+		})
+	}
+	return response
+}
@@ -0,0 +1,57 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Заголовки запроса вебхука
+const (
+	HeaderDeliveryID = "X-Webhook-ID"
+	HeaderEventID    = "X-Event-ID"
+	HeaderEventType  = "X-Event-Type"
+	HeaderTimestamp  = "X-Webhook-Timestamp"
+	// HeaderSignature - "sha256=" и hex HMAC-SHA256 от "<timestamp>.<тело>" с секретом подписки
+	HeaderSignature = "X-Webhook-Signature"
+
+	signaturePrefix = "sha256="
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrStaleTimestamp   = errors.New("webhook timestamp is outside the tolerance")
+)
+
+// Sign - значение заголовка X-Webhook-Signature. Метка времени входит в подпись,
+// поэтому перехваченный запрос нельзя повторить позже допустимого окна
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify - проверка на стороне получателя: подпись совпадает, а метка времени
+// отличается от now не больше чем на tolerance
+func Verify(secret, signature, timestamp string, body []byte, tolerance time.Duration, now time.Time) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(ts, 0)); age > tolerance || age < -tolerance {
+		return ErrStaleTimestamp
+	}
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
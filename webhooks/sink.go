@@ -0,0 +1,69 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"chat-api/models"
+	"chat-api/outbox"
+)
+
+// Sink - приёмник outbox: ставит событие в очередь доставки каждой подходящей подписке.
+// Сама отправка - в Dispatcher, поэтому медленный получатель не задерживает outbox
+type Sink struct {
+	store *Store
+}
+
+func NewSink(store *Store) *Sink {
+	return &Sink{store: store}
+}
+
+func (s *Sink) Name() string {
+	return "webhooks"
+}
+
+func (s *Sink) Deliver(ctx context.Context, event outbox.Event) error {
+	subs, err := s.store.Match(ctx, event.ChatID)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var deliveries []models.WebhookDelivery
+	for _, sub := range subs {
+		if !subscribed(sub, event.Type) {
+			continue
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			ChatID:         event.ChatID,
+			Payload:        string(payload),
+			Status:         models.DeliveryPending,
+			NextAttemptAt:  now,
+		})
+	}
+
+	return s.store.Enqueue(ctx, deliveries)
+}
+
+func subscribed(sub models.WebhookSubscription, eventType string) bool {
+	types := eventTypes(sub)
+	return len(types) == 0 || slices.Contains(types, eventType)
+}
+
+func eventTypes(sub models.WebhookSubscription) []string {
+	if sub.EventTypes == "" {
+		return []string{}
+	}
+	return strings.Split(sub.EventTypes, ",")
+}
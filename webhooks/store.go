@@ -0,0 +1,194 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"chat-api/models"
+	"chat-api/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrNotFound - подписка или доставка не найдена; совпадает с repository.ErrNotFound
+	ErrNotFound = repository.ErrNotFound
+	// ErrNotReplayable - доставка ещё в очереди, повторять нечего
+	ErrNotReplayable = errors.New("delivery is still pending")
+)
+
+// Store - подписки, доставки и попытки в базе
+type Store struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	if err := s.db.WithContext(ctx).Create(sub).Error; err != nil {
+		return fmt.Errorf("failed create webhook: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	if err := s.db.WithContext(ctx).Order("id").Find(&subs).Error; err != nil {
+		return nil, fmt.Errorf("failed list webhooks: %w", err)
+	}
+	return subs, nil
+}
+
+func (s *Store) GetSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	if err := s.db.WithContext(ctx).First(&sub, id).Error; err != nil {
+		return nil, fmt.Errorf("failed get webhook: %w", err)
+	}
+	return &sub, nil
+}
+
+// DeleteSubscription - удаляет подписку вместе с её доставками
+func (s *Store) DeleteSubscription(ctx context.Context, id uint) error {
+	result := s.db.WithContext(ctx).Delete(&models.WebhookSubscription{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed delete webhook: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("failed delete webhook: %w", ErrNotFound)
+	}
+	return nil
+}
+
+func (s *Store) ChatExists(ctx context.Context, chatID uint) (bool, error) {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.Chat{}).Where("id = ?", chatID).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed check chat: %w", err)
+	}
+	return count > 0, nil
+}
+
+// Match - подписки на этот чат и глобальные; фильтр по типу события применяет вызывающий
+func (s *Store) Match(ctx context.Context, chatID uint) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	err := s.db.WithContext(ctx).Where("chat_id IS NULL OR chat_id = ?", chatID).Order("id").Find(&subs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed match webhooks: %w", err)
+	}
+	return subs, nil
+}
+
+// Enqueue - ставит доставки в очередь; уже поставленные для той же пары подписка-событие пропускаются
+func (s *Store) Enqueue(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	err := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+	if err != nil {
+		return fmt.Errorf("failed enqueue webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// Due - доставки, время попытки которых наступило, в порядке постановки
+func (s *Store) Due(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := s.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+		Order("next_attempt_at, id").Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed read due webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// RecordAttempt - сохраняет попытку и новое состояние доставки в одной транзакции
+func (s *Store) RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery, attempt *models.WebhookAttempt) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		attempt.DeliveryID = delivery.ID
+		if err := tx.Create(attempt).Error; err != nil {
+			return fmt.Errorf("failed record webhook attempt: %w", err)
+		}
+
+		err := tx.Model(&models.WebhookDelivery{}).Where("id = ?", delivery.ID).Updates(map[string]any{
+			"status":           delivery.Status,
+			"attempts":         delivery.Attempts,
+			"next_attempt_at":  delivery.NextAttemptAt,
+			"last_error":       delivery.LastError,
+			"last_status_code": delivery.LastStatusCode,
+			"delivered_at":     delivery.DeliveredAt,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed update webhook delivery: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListDeliveries - доставки подписки от новых к старым, status пустой - все
+func (s *Store) ListDeliveries(ctx context.Context, subscriptionID uint, status string, limit int) ([]models.WebhookDelivery, error) {
+	query := s.db.WithContext(ctx).Where("subscription_id = ?", subscriptionID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := query.Order("id DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("failed list webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// GetDelivery - доставка подписки вместе с историей попыток
+func (s *Store) GetDelivery(ctx context.Context, subscriptionID uint, id uint64) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := s.db.WithContext(ctx).
+		Preload("History", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Where("subscription_id = ?", subscriptionID).
+		First(&delivery, id).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed get webhook delivery: %w", err)
+	}
+	return &delivery, nil
+}
+
+// Replay - возвращает доставленную или мёртвую доставку в очередь с чистым счётчиком попыток;
+// история прежних попыток сохраняется
+func (s *Store) Replay(ctx context.Context, subscriptionID uint, id uint64, now time.Time) (*models.WebhookDelivery, error) {
+	result := s.db.WithContext(ctx).Model(&models.WebhookDelivery{}).
+		Where("id = ? AND subscription_id = ? AND status <> ?", id, subscriptionID, models.DeliveryPending).
+		Updates(map[string]any{
+			"status":          models.DeliveryPending,
+			"attempts":        0,
+			"next_attempt_at": now,
+			"delivered_at":    nil,
+		})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed replay webhook delivery: %w", result.Error)
+	}
+
+	delivery, err := s.GetDelivery(ctx, subscriptionID, id)
+	if err != nil {
+		return nil, err
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotReplayable
+	}
+	return delivery, nil
+}
+
+// PruneDelivered - удаляет успешные доставки старше before
+func (s *Store) PruneDelivered(ctx context.Context, before time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).
+		Where("status = ? AND delivered_at < ?", models.DeliveryDelivered, before).
+		Delete(&models.WebhookDelivery{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed prune webhook deliveries: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
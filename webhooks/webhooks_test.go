@@ -0,0 +1,310 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"chat-api/handlers"
+	"chat-api/internal/testutil"
+	"chat-api/models"
+	"chat-api/outbox"
+	"chat-api/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nopMetrics struct{}
+
+func (nopMetrics) WebhookAttempt(string) {}
+
+// env - SQLite со схемой, репозиторий с outbox и relay, раздающий события подпискам
+type env struct {
+	store *Store
+	repo  repository.ChatRepository
+	relay *outbox.Relay
+}
+
+func newEnv(t *testing.T) *env {
+	t.Helper()
+	db := testutil.SQLite(t)
+
+	store := NewStore(db)
+	return &env{
+		store: store,
+		repo:  repository.NewRepository(db, testutil.DiscardLogger{}, repository.WithOutbox()),
+		relay: outbox.NewRelay(db, []outbox.Sink{NewSink(store)}),
+	}
+}
+
+// receiver - получатель вебхуков, проверяющий подпись; отвечает status, пока он не 0
+type receiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newReceiver(t *testing.T, secret string, now func() time.Time) *receiver {
+	rcv := &receiver{status: http.StatusOK}
+	rcv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := Verify(secret, r.Header.Get(HeaderSignature), r.Header.Get(HeaderTimestamp), body, 5*time.Minute, now()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		rcv.mu.Lock()
+		defer rcv.mu.Unlock()
+		rcv.requests = append(rcv.requests, r)
+		rcv.bodies = append(rcv.bodies, body)
+		w.WriteHeader(rcv.status)
+	}))
+	t.Cleanup(rcv.Close)
+	return rcv
+}
+
+func (rcv *receiver) setStatus(status int) {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	rcv.status = status
+}
+
+func (rcv *receiver) count() int {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	return len(rcv.requests)
+}
+
+// clock - управляемое время для проверки пауз между повторами
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time { return c.now }
+
+func newDispatcher(store *Store, c *clock) *Dispatcher {
+	d := NewDispatcher(store, Config{
+		Timeout:     time.Second,
+		MaxAttempts: 3,
+		Backoff:     time.Minute,
+		MaxBackoff:  90 * time.Second,
+		BatchSize:   10,
+	}, testutil.DiscardLogger{}, nopMetrics{})
+	d.now = c.Now
+	return d
+}
+
+func subscribe(t *testing.T, e *env, url string, chatID *uint, events ...string) *models.WebhookResponse {
+	t.Helper()
+	sub, err := NewService(e.store).Create(context.Background(), models.CreateWebhookRequest{
+		URL: url, Secret: "0123456789abcdef", ChatID: chatID, Events: events,
+	})
+	require.NoError(t, err)
+	return sub
+}
+
+func TestSignVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"type":"message.created"}`)
+	signature := Sign("secret", now.Unix(), body)
+	ts := "1700000000"
+
+	assert.NoError(t, Verify("secret", signature, ts, body, time.Minute, now.Add(30*time.Second)))
+	assert.ErrorIs(t, Verify("other", signature, ts, body, time.Minute, now), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify("secret", signature, ts, []byte(`{}`), time.Minute, now), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify("secret", signature, ts, body, time.Minute, now.Add(2*time.Minute)), ErrStaleTimestamp)
+	assert.ErrorIs(t, Verify("secret", strings.TrimPrefix(signature, "sha256="), ts, body, time.Minute, now), ErrInvalidSignature)
+}
+
+// TestSink_FanOut - событие попадает в подписки на свой чат и глобальные с подходящим типом,
+// повторная передача того же события не дублирует доставки
+func TestSink_FanOut(t *testing.T) {
+	ctx := context.Background()
+	e := newEnv(t)
+
+	chat, err := e.repo.Create(ctx, &models.Chat{Title: "general"})
+	require.NoError(t, err)
+	other, err := e.repo.Create(ctx, &models.Chat{Title: "random"})
+	require.NoError(t, err)
+
+	global := subscribe(t, e, "http://example.com/all", nil)
+	messages := subscribe(t, e, "http://example.com/messages", nil, models.EventMessageCreated)
+	own := subscribe(t, e, "http://example.com/own", &chat.ID)
+	subscribe(t, e, "http://example.com/other", &other.ID)
+
+	event := outbox.Event{ID: 42, Type: models.EventMessageCreated, ChatID: chat.ID, Data: json.RawMessage(`{}`)}
+	require.NoError(t, NewSink(e.store).Deliver(ctx, event))
+	require.NoError(t, NewSink(e.store).Deliver(ctx, event))
+
+	for _, sub := range []*models.WebhookResponse{global, messages, own} {
+		deliveries, err := e.store.ListDeliveries(ctx, sub.ID, "", 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1, sub.URL)
+		assert.Equal(t, uint64(42), deliveries[0].EventID)
+	}
+
+	event = outbox.Event{ID: 43, Type: models.EventChatDeleted, ChatID: chat.ID, Data: json.RawMessage(`{}`)}
+	require.NoError(t, NewSink(e.store).Deliver(ctx, event))
+	deliveries, err := e.store.ListDeliveries(ctx, messages.ID, "", 10)
+	require.NoError(t, err)
+	assert.Len(t, deliveries, 1, "subscription filtered by event type")
+}
+
+func TestDispatcher_Delivers(t *testing.T) {
+	ctx := context.Background()
+	e := newEnv(t)
+	c := &clock{}
+	rcv := newReceiver(t, "0123456789abcdef", c.Now)
+	sub := subscribe(t, e, rcv.URL, nil)
+
+	chat, err := e.repo.Create(ctx, &models.Chat{Title: "general"})
+	require.NoError(t, err)
+	_, err = e.relay.ProcessBatch(ctx)
+	require.NoError(t, err)
+	c.now = time.Now()
+
+	testutil.WithTracing(t)
+	n, err := newDispatcher(e.store, c).ProcessDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	require.Equal(t, 1, rcv.count())
+
+	req := rcv.requests[0]
+	assert.Equal(t, models.EventChatCreated, req.Header.Get(HeaderEventType))
+	assert.Regexp(t, `^00-[0-9a-f]{32}-[0-9a-f]{16}-01$`, req.Header.Get("traceparent"))
+	assert.NotEmpty(t, req.Header.Get(HeaderDeliveryID))
+
+	var event outbox.Event
+	require.NoError(t, json.Unmarshal(rcv.bodies[0], &event))
+	assert.Equal(t, chat.ID, event.ChatID)
+
+	deliveries, err := e.store.ListDeliveries(ctx, sub.ID, models.DeliveryDelivered, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, http.StatusOK, deliveries[0].LastStatusCode)
+}
+
+// TestDispatcher_RetryDeadReplay - неудачи повторяются с растущей паузой, после MaxAttempts
+// доставка становится мёртвой, а replay отправляет её снова
+func TestDispatcher_RetryDeadReplay(t *testing.T) {
+	ctx := context.Background()
+	e := newEnv(t)
+	c := &clock{}
+	rcv := newReceiver(t, "0123456789abcdef", c.Now)
+	rcv.setStatus(http.StatusServiceUnavailable)
+	sub := subscribe(t, e, rcv.URL, nil)
+	d := newDispatcher(e.store, c)
+
+	_, err := e.repo.Create(ctx, &models.Chat{Title: "general"})
+	require.NoError(t, err)
+	_, err = e.relay.ProcessBatch(ctx)
+	require.NoError(t, err)
+	c.now = time.Now()
+
+	var delivery models.WebhookDelivery
+	for attempt, wait := range []time.Duration{time.Minute, 90 * time.Second} {
+		_, err = d.ProcessDue(ctx)
+		require.NoError(t, err)
+		require.Equal(t, attempt+1, rcv.count())
+
+		deliveries, err := e.store.ListDeliveries(ctx, sub.ID, models.DeliveryPending, 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		delivery = deliveries[0]
+		assert.WithinDuration(t, c.now.Add(wait), delivery.NextAttemptAt, time.Millisecond)
+
+		// до срока повтора доставка не уходит
+		n, err := d.ProcessDue(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		c.now = delivery.NextAttemptAt
+	}
+
+	_, err = d.ProcessDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, rcv.count())
+
+	dead, err := e.store.GetDelivery(ctx, sub.ID, delivery.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.DeliveryDead, dead.Status)
+	assert.Contains(t, dead.LastError, "503")
+	require.Len(t, dead.History, 3)
+	assert.Equal(t, http.StatusServiceUnavailable, dead.History[2].StatusCode)
+
+	rcv.setStatus(http.StatusNoContent)
+	_, err = NewService(e.store).Replay(ctx, sub.ID, delivery.ID)
+	require.NoError(t, err)
+	_, err = NewService(e.store).Replay(ctx, sub.ID, delivery.ID)
+	assert.ErrorIs(t, err, ErrNotReplayable)
+
+	_, err = d.ProcessDue(ctx)
+	require.NoError(t, err)
+	delivered, err := e.store.GetDelivery(ctx, sub.ID, delivery.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.DeliveryDelivered, delivered.Status)
+	assert.Len(t, delivered.History, 4, "history survives replay")
+}
+
+func TestHandler(t *testing.T) {
+	ctx := context.Background()
+	e := newEnv(t)
+	chat, err := e.repo.Create(ctx, &models.Chat{Title: "general"})
+	require.NoError(t, err)
+
+	router := handlers.NewRouter()
+	router.Mount("", NewHandler(NewService(e.store)))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusBadRequest, do("POST", "/webhooks", `{"url":"ftp://example.com"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do("POST", "/webhooks", `{"url":"http://example.com","events":["chat.renamed"]}`).Code)
+	assert.Equal(t, http.StatusNotFound, do("POST", "/webhooks", `{"url":"http://example.com","chat_id":999}`).Code)
+
+	rec := do("POST", "/webhooks", `{"url":"http://example.com","chat_id":`+jsonUint(chat.ID)+`,"events":["message.created"]}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created models.WebhookResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.True(t, strings.HasPrefix(created.Secret, secretPrefix))
+	assert.Equal(t, []string{models.EventMessageCreated}, created.Events)
+
+	rec = do("GET", "/webhooks", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), created.Secret)
+
+	path := "/webhooks/" + jsonUint(created.ID)
+	assert.Equal(t, http.StatusBadRequest, do("GET", path+"/deliveries?status=lost", "").Code)
+	assert.Equal(t, http.StatusNotFound, do("GET", path+"/deliveries/1", "").Code)
+	assert.Equal(t, http.StatusNotFound, do("POST", path+"/deliveries/1/replay", "").Code)
+
+	require.NoError(t, e.store.Enqueue(ctx, []models.WebhookDelivery{{
+		SubscriptionID: created.ID, EventID: 1, EventType: models.EventMessageCreated, ChatID: chat.ID,
+		Payload: `{}`, Status: models.DeliveryPending, NextAttemptAt: time.Now(),
+	}}))
+	rec = do("GET", path+"/deliveries", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var deliveries []models.WebhookDeliveryResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &deliveries))
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, http.StatusConflict, do("POST", path+"/deliveries/"+jsonUint64(deliveries[0].ID)+"/replay", "").Code)
+
+	assert.Equal(t, http.StatusNoContent, do("DELETE", path, "").Code)
+	assert.Equal(t, http.StatusNotFound, do("GET", path, "").Code)
+	_, err = e.store.GetDelivery(ctx, created.ID, deliveries[0].ID)
+	assert.True(t, errors.Is(err, ErrNotFound), "deliveries are deleted with the subscription")
+}
+
+func jsonUint(n uint) string     { return jsonUint64(uint64(n)) }
+func jsonUint64(n uint64) string { b, _ := json.Marshal(n); return string(b) }